@@ -0,0 +1,312 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// ApprovalPolicy requires Required of the Approvers to approve the
+// transfers of more than Threshold tokens of any colored token.
+//
+// The amounts transferred are those paid by the TXs of a proposal to
+// other wallets than their founder, whatever the proposal body says.
+//
+type ApprovalPolicy struct {
+	Threshold int64
+	Required  int
+	// Approvers maps the approver DIDs to their ed25519 public keys.
+	Approvers map[did.Identifier]ed25519.PublicKey
+}
+
+func (p *ApprovalPolicy) validate() error {
+	if p == nil || p.Required <= 0 || p.Required > len(p.Approvers) {
+		return fmt.Errorf("approval policy invalid")
+	}
+	return nil
+}
+
+// Approval is the ed25519 signature of an approver over the digest of
+// a proposal, see ProposalDigest.
+//
+type Approval struct {
+	Approver  did.Identifier `json:"approver"`
+	Signature []byte         `json:"signature"`
+}
+
+// SignApproval returns the approval of digest by approver.
+//
+func SignApproval(digest []byte, approver did.Identifier, privateKey ed25519.PrivateKey) *Approval {
+	return &Approval{
+		Approver:  approver,
+		Signature: ed25519.Sign(privateKey, digest),
+	}
+}
+
+// ProposalDigest returns the SHA-256 digest of the JSON encoding of the
+// body and txs of a proposal, and of the threshold of its policy.
+//
+func ProposalDigest(body *wallet.TransferCTokenBody, txs []*pw.TX, threshold int64) ([]byte, error) {
+	data, err := json.Marshal(&struct {
+		Body      *wallet.TransferCTokenBody `json:"body"`
+		Txs       []*pw.TX                   `json:"txs"`
+		Threshold int64                      `json:"threshold"`
+	}{body, txs, threshold})
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// ApprovalProposal is a transfer proposal waiting for the approvals
+// required by its policy before its TXs are signed.
+//
+// An ApprovalProposal can be JSON encoded and passed to the approvers,
+// the policy is not encoded and stays with the party enforcing it.
+//
+type ApprovalProposal struct {
+	Body      *wallet.TransferCTokenBody `json:"body"`
+	Txs       []*pw.TX                   `json:"txs"`
+	Digest    []byte                     `json:"digest"`
+	Approvals []*Approval                `json:"approvals"`
+
+	Policy *ApprovalPolicy `json:"-"`
+
+	// founders maps the founders of Txs to their endpoint.
+	founders map[string]string
+}
+
+// Required reports whether the transfer exceeds the policy threshold.
+// Approvals are always required without a policy.
+//
+// Every output of Txs counts against the threshold, except the change
+// paid back to the founder of its TX. Founder endpoints are resolved by
+// SendApprovalProposal and SignApprovedTxs only, so the change counts
+// too in a proposal decoded from JSON.
+//
+func (p *ApprovalProposal) Required() bool {
+	if p.Policy == nil {
+		return true
+	}
+	for _, amount := range p.amounts() {
+		if amount > p.Policy.Threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// amounts sums the outputs of Txs paid to other wallets than their
+// founder by colored token.
+func (p *ApprovalProposal) amounts() map[string]int64 {
+	amounts := make(map[string]int64)
+	for _, tx := range p.Txs {
+		addr, resolved := p.founders[tx.Founder]
+		for _, txout := range tx.Txout {
+			if resolved && txout.Addr == addr {
+				continue
+			}
+			amounts[txout.CTokenId] += txout.Value
+		}
+	}
+	return amounts
+}
+
+// verify checks that Digest is the digest of the proposal under its
+// policy.
+func (p *ApprovalProposal) verify() error {
+	digest, err := ProposalDigest(p.Body, p.Txs, p.Policy.Threshold)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(digest, p.Digest) {
+		return fmt.Errorf("proposal does not match the approved digest")
+	}
+	return nil
+}
+
+// Approve checks approval against the policy and adds it to the
+// proposal. An approver approves a proposal only once.
+//
+func (p *ApprovalProposal) Approve(approval *Approval) error {
+	if err := p.Policy.validate(); err != nil {
+		return err
+	}
+	if approval == nil {
+		return fmt.Errorf("approval invalid")
+	}
+	publicKey, ok := p.Policy.Approvers[approval.Approver]
+	if !ok {
+		return fmt.Errorf("%s is not an approver", approval.Approver)
+	}
+	for _, a := range p.Approvals {
+		if a.Approver == approval.Approver {
+			return fmt.Errorf("%s already approved", approval.Approver)
+		}
+	}
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, p.Digest, approval.Signature) {
+		return fmt.Errorf("approval signature of %s invalid", approval.Approver)
+	}
+	p.Approvals = append(p.Approvals, approval)
+	return nil
+}
+
+// Approved reports whether the proposal may be signed: either it does
+// not exceed the policy threshold, or enough approvers approved it.
+//
+// The digest and approvals are verified again, so that a proposal
+// decoded from JSON is only approved by valid signatures over its
+// content.
+//
+func (p *ApprovalProposal) Approved() bool {
+	if p.Policy.validate() != nil || p.verify() != nil {
+		return false
+	}
+	if !p.Required() {
+		return true
+	}
+	return p.approvals() >= p.Policy.Required
+}
+
+// approvals counts the valid approvals of distinct approvers.
+func (p *ApprovalProposal) approvals() int {
+	approved := make(map[did.Identifier]bool)
+	for _, a := range p.Approvals {
+		publicKey, ok := p.Policy.Approvers[a.Approver]
+		if !ok || len(publicKey) != ed25519.PublicKeySize {
+			continue
+		}
+		if ed25519.Verify(publicKey, p.Digest, a.Signature) {
+			approved[a.Approver] = true
+		}
+	}
+	return len(approved)
+}
+
+// SendApprovalProposal is used to send a transfer colored tokens
+// proposal whose TXs are signed only once approved according to
+// policy, see SignApprovedTxs.
+//
+func (w *WalletClient) SendApprovalProposal(header http.Header, body *wallet.TransferCTokenBody, policy *ApprovalPolicy) (result *ApprovalProposal, err error) {
+	op := w.begin(w.parentContext(), "SendApprovalProposal")
+	defer func() { op.end(err) }()
+
+	if body == nil {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	if err = policy.validate(); err != nil {
+		return
+	}
+	op.setAttributes(AttrFrom.String(body.From), AttrTo.String(body.To))
+
+	txs, err := w.sendTransferCTokenProposal(op.ctx, header, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.intent != nil {
+		if err = w.checkIntent(op.ctx, header, body, "", txs); err != nil {
+			return nil, err
+		}
+	}
+	digest, err := ProposalDigest(body, txs, policy.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &ApprovalProposal{
+		Body:   body,
+		Txs:    txs,
+		Digest: digest,
+		Policy: policy,
+	}
+	if err = w.resolveFounders(op.ctx, header, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveFounders resolves the endpoints of the founders of the TXs of
+// proposal, see ApprovalProposal.Required.
+func (w *WalletClient) resolveFounders(ctx context.Context, header http.Header, proposal *ApprovalProposal) error {
+	endpoint := w.endpointResolver(ctx, header, w.intentCheck())
+	founders := make(map[string]string)
+	for _, tx := range proposal.Txs {
+		addr, err := endpoint(tx.Founder)
+		if err != nil {
+			return err
+		}
+		founders[tx.Founder] = addr
+	}
+	proposal.founders = founders
+	return nil
+}
+
+// SignApprovedTxs is used to sign the TXs of an approved proposal, as
+// SignTxs does. The returned TXs are ready for ProcessTx.
+//
+// It fails if the proposal is not approved, or if its body or TXs do
+// not match the approved digest under the policy of proposal.
+//
+func (w *WalletClient) SignApprovedTxs(header http.Header, proposal *ApprovalProposal, signParams *pki.SignatureParam) (result []*pw.TX, err error) {
+	op := w.begin(w.parentContext(), "SignApprovedTxs")
+	defer func() { op.end(err) }()
+
+	if proposal == nil || proposal.Body == nil {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	if err = proposal.Policy.validate(); err != nil {
+		return
+	}
+	if err = proposal.verify(); err != nil {
+		return nil, err
+	}
+	if err = w.resolveFounders(op.ctx, header, proposal); err != nil {
+		return nil, err
+	}
+	if !proposal.Approved() {
+		err = fmt.Errorf("proposal not approved: %d of %d required approvals", proposal.approvals(), proposal.Policy.Required)
+		return nil, err
+	}
+
+	if w.s != nil {
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = w.signTxs(op.ctx, header, proposal.Txs, signParams)
+	if err != nil {
+		err = fmt.Errorf("sign Txs error: %v", err)
+		return nil, err
+	}
+	return proposal.Txs, nil
+}
@@ -0,0 +1,313 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+var testOfficers = []did.Identifier{"did:axn:officer-1", "did:axn:officer-2", "did:axn:officer-3"}
+
+// officerKey returns the deterministic private key of officer i.
+func officerKey(i int) ed25519.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = byte(i + 1)
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+// twoOfThree returns a policy requiring 2 of the 3 test officers to
+// approve transfers of more than 100 tokens.
+func twoOfThree() *ApprovalPolicy {
+	policy := &ApprovalPolicy{
+		Threshold: 100,
+		Required:  2,
+		Approvers: make(map[did.Identifier]ed25519.PublicKey),
+	}
+	for i, officer := range testOfficers {
+		policy.Approvers[officer] = officerKey(i).Public().(ed25519.PublicKey)
+	}
+	return policy
+}
+
+// approvalTxs returns the TXs of a transfer of amount tokens from
+// did:axn:001 to did:axn:002, and its fee TX.
+func approvalTxs(t *testing.T, amount int64) []*pw.TX {
+	return []*pw.TX{
+		spending(intentTx(t, "did:axn:001", out("token-a", amount, "endpoint-002"), out("token-a", 1000-amount, "endpoint-001")), "utxo-a:0"),
+		spending(intentTx(t, testPlatformDID, out("fee-token", 1, "endpoint-fee"), out("fee-token", 99, "endpoint-platform")), "utxo-fee:0"),
+	}
+}
+
+// mockApprovalWallets mocks the wallet info of the founders of
+// approvalTxs.
+func mockApprovalWallets(t *testing.T) {
+	mockWalletInfo(t, "did:axn:001", "endpoint-001")
+	mockWalletInfo(t, testPlatformDID, "endpoint-platform")
+}
+
+// newTestApprovalProposal returns a proposal of a transfer of amount
+// tokens without sending it.
+func newTestApprovalProposal(t *testing.T, amount int64) *ApprovalProposal {
+	body := &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-a", Amount: amount}},
+	}
+	txs := approvalTxs(t, amount)
+	policy := twoOfThree()
+	digest, err := ProposalDigest(body, txs, policy.Threshold)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return &ApprovalProposal{
+		Body:   body,
+		Txs:    txs,
+		Digest: digest,
+		Policy: policy,
+	}
+}
+
+func TestApprovalProposalSucc(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	const token = "user-token-001"
+
+	reqBody := &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-a", Amount: 500}},
+	}
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+
+	//mock http request
+	mockApprovalWallets(t)
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		MatchHeader("X-Auth-Token", token).
+		Reply(200).
+		JSON(jsonPayload(t, approvalTxs(t, 500)))
+	process := gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001":   sign,
+			testPlatformDID: platformSignParam(),
+		})).
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"trans-id-001"}}))
+
+	header := http.Header{}
+	header.Set("X-Auth-Token", token)
+
+	proposal, err := walletClient.SendApprovalProposal(header, reqBody, twoOfThree())
+	if err != nil {
+		t.Fatalf("send approval proposal fail: %v", err)
+	}
+	if !proposal.Required() || proposal.Approved() {
+		t.Fatalf("proposal over the threshold should require approvals")
+	}
+
+	//the first officer approves
+	if err = proposal.Approve(SignApproval(proposal.Digest, testOfficers[0], officerKey(0))); err != nil {
+		t.Fatalf("approve fail: %v", err)
+	}
+	if _, err = walletClient.SignApprovedTxs(header, proposal, sign); err == nil {
+		t.Fatalf("err should not be nil when proposal approved by 1 of 2 officers")
+	}
+
+	//the third officer approves offline
+	data, err := json.Marshal(proposal)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var offline ApprovalProposal
+	if err = json.Unmarshal(data, &offline); err != nil {
+		t.Fatalf("%v", err)
+	}
+	approval := SignApproval(offline.Digest, testOfficers[2], officerKey(2))
+	if err = proposal.Approve(approval); err != nil {
+		t.Fatalf("approve fail: %v", err)
+	}
+	if !proposal.Approved() {
+		t.Fatalf("proposal approved by 2 of 3 officers should be approved")
+	}
+
+	txs, err := walletClient.SignApprovedTxs(header, proposal, sign)
+	if err != nil {
+		t.Fatalf("sign approved txs fail: %v", err)
+	}
+	if _, err = walletClient.ProcessTx(header, txs); err != nil {
+		t.Fatalf("process tx fail: %v", err)
+	}
+	if !process.Mock.Done() {
+		t.Fatalf("proposal and process requests should be sent")
+	}
+}
+
+func TestApprovalProposalBelowThreshold(t *testing.T) {
+	//init gock & walletclient
+	defer gock.Off()
+	w := newTestWalletClientWithConfig(t, enterpriseConfig())
+	mockApprovalWallets(t)
+
+	proposal := newTestApprovalProposal(t, 100)
+	if !proposal.Required() {
+		t.Fatalf("change should count until the founder endpoints are resolved")
+	}
+	if err := w.resolveFounders(context.Background(), nil, proposal); err != nil {
+		t.Fatalf("resolve founders fail: %v", err)
+	}
+	if proposal.Required() {
+		t.Fatalf("transfer of the threshold amount should not require approvals")
+	}
+	if !proposal.Approved() {
+		t.Fatalf("transfer below the threshold should be approved")
+	}
+
+	proposal.Policy = nil
+	if !proposal.Required() || proposal.Approved() {
+		t.Fatalf("proposal without policy should never be approved")
+	}
+}
+
+func TestApprovalProposalApproveInvalid(t *testing.T) {
+	proposal := newTestApprovalProposal(t, 500)
+
+	cases := map[string]*Approval{
+		"nil approval":     nil,
+		"unknown approver": SignApproval(proposal.Digest, "did:axn:intruder", officerKey(0)),
+		"wrong key":        SignApproval(proposal.Digest, testOfficers[1], officerKey(0)),
+		"wrong digest":     SignApproval([]byte("another digest"), testOfficers[1], officerKey(1)),
+		"short signature":  {Approver: testOfficers[1], Signature: []byte("short")},
+	}
+	for name, approval := range cases {
+		if err := proposal.Approve(approval); err == nil {
+			t.Fatalf("%s: err should not be nil", name)
+		}
+	}
+
+	if err := proposal.Approve(SignApproval(proposal.Digest, testOfficers[0], officerKey(0))); err != nil {
+		t.Fatalf("approve fail: %v", err)
+	}
+	if err := proposal.Approve(SignApproval(proposal.Digest, testOfficers[0], officerKey(0))); err == nil {
+		t.Fatalf("err should not be nil when an officer approves twice")
+	}
+
+	//approvals added to the JSON encoding are verified again
+	forged := *proposal
+	forged.Approvals = append(forged.Approvals,
+		proposal.Approvals[0],
+		&Approval{Approver: testOfficers[1], Signature: make([]byte, ed25519.SignatureSize)},
+	)
+	if forged.Approved() {
+		t.Fatalf("duplicate and invalid approvals should not count")
+	}
+}
+
+func TestSignApprovedTxsTampered(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+	proposal := newTestApprovalProposal(t, 500)
+	for i := 0; i < 2; i++ {
+		if err := proposal.Approve(SignApproval(proposal.Digest, testOfficers[i], officerKey(i))); err != nil {
+			t.Fatalf("approve fail: %v", err)
+		}
+	}
+
+	//redirect the approved transfer
+	proposal.Txs[0].Txout[0].Addr = "endpoint-intruder"
+	_, err := walletClient.SignApprovedTxs(nil, proposal, sign)
+	if err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Fatalf("err should report the digest mismatch, got %v", err)
+	}
+	if txSigned(proposal.Txs[0]) {
+		t.Fatalf("tampered txs should not be signed")
+	}
+
+	//the enforcing party raises the threshold of the approved proposal
+	proposal = newTestApprovalProposal(t, 500)
+	proposal.Policy.Threshold = 1000
+	if _, err = walletClient.SignApprovedTxs(nil, proposal, sign); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Fatalf("err should report the digest mismatch, got %v", err)
+	}
+}
+
+func TestSignApprovedTxsTamperedBody(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+	mockApprovalWallets(t)
+
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+	proposal := newTestApprovalProposal(t, 500)
+	if err := proposal.Approve(SignApproval(proposal.Digest, testOfficers[0], officerKey(0))); err != nil {
+		t.Fatalf("approve fail: %v", err)
+	}
+
+	//lower the body amount below the threshold and recompute the digest
+	proposal.Body.Tokens[0].Amount = 50
+	digest, err := ProposalDigest(proposal.Body, proposal.Txs, proposal.Policy.Threshold)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	proposal.Digest = digest
+	if _, err = walletClient.SignApprovedTxs(nil, proposal, sign); err == nil || !strings.Contains(err.Error(), "not approved") {
+		t.Fatalf("err should report the proposal not approved, got %v", err)
+	}
+	if proposal.Approved() {
+		t.Fatalf("the TXs of the proposal should still require approvals")
+	}
+
+	//approvals of the original digest do not approve the tampered body
+	if err = proposal.Approve(SignApproval(proposal.Digest, testOfficers[1], officerKey(1))); err != nil {
+		t.Fatalf("approve fail: %v", err)
+	}
+	if _, err = walletClient.SignApprovedTxs(nil, proposal, sign); err == nil {
+		t.Fatalf("err should not be nil when approved by 1 valid approval of 2 required")
+	}
+	if txSigned(proposal.Txs[0]) {
+		t.Fatalf("tampered txs should not be signed")
+	}
+}
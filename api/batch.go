@@ -0,0 +1,186 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// DefaultBatchConcurrency is the number of recipients of a batch
+// transfer processed at once when BatchTransferCTokenBody.Concurrency
+// is not set.
+const DefaultBatchConcurrency = 1
+
+// BatchTransferEntry is one recipient of a batch transfer.
+//
+type BatchTransferEntry struct {
+	To     string
+	Tokens []*wallet.TokenAmount
+}
+
+// BatchTransferCTokenBody describes colored tokens paid by one sender
+// to many recipients.
+//
+type BatchTransferCTokenBody struct {
+	From    string
+	AssetId string
+	Entries []*BatchTransferEntry
+
+	// Concurrency bounds the number of recipients processed at once,
+	// it defaults to DefaultBatchConcurrency.
+	Concurrency int
+}
+
+// BatchTransferResult is the outcome of the transfer to one recipient.
+//
+type BatchTransferResult struct {
+	To     string
+	Tokens []*wallet.TokenAmount
+
+	// Response is the ProcessTx response, it is nil if Err is set.
+	Response *wallet.WalletResponse
+	Err      error
+}
+
+// BatchTransferCTokenResponse holds one result per recipient, in the
+// order the recipients first appear in the request entries.
+//
+type BatchTransferCTokenResponse struct {
+	Results []*BatchTransferResult
+}
+
+// Failed returns the results of the recipients whose transfer failed.
+//
+func (r *BatchTransferCTokenResponse) Failed() (failed []*BatchTransferResult) {
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return
+}
+
+// BatchTransferCToken is used to transfer colored tokens from one user
+// to many recipients.
+//
+// Entries paying the same recipient are merged into a single transfer
+// proposal, summing the amounts of the same colored token. The TXs of
+// every proposal are signed with signParams, fee TXs excepted, and
+// processed with at most body.Concurrency recipients in flight.
+//
+// Proposals do not lock the UTXOs they spend: concurrent recipients may
+// be proposed the same sender outputs, in which case the platform
+// rejects all but one of them. Keep the default concurrency unless the
+// sender holds enough distinct outputs, and retry the failed recipients.
+//
+// The returned error only reports an invalid request or a failed
+// private key lookup, a failed recipient is reported by its result.
+//
+func (w *WalletClient) BatchTransferCToken(header http.Header, body *BatchTransferCTokenBody, signParams *pki.SignatureParam) (result *BatchTransferCTokenResponse, err error) {
+	op := w.begin(w.parentContext(), "BatchTransferCToken")
+	defer func() { op.end(err) }()
+
+	if body == nil || len(body.Entries) == 0 {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	op.setAttributes(AttrFrom.String(body.From))
+
+	// every entry is checked before merging, so that a negative
+	// amount cannot offset another one
+	var v validator
+	for i, entry := range body.Entries {
+		name := fmt.Sprintf("entries[%d]", i)
+		if entry == nil {
+			v.fail(name, "must be set")
+			continue
+		}
+		v.required(name+".to", entry.To)
+		v.tokens(name+".tokens", entry.Tokens)
+	}
+	if err = v.err(); err != nil {
+		return
+	}
+
+	result = &BatchTransferCTokenResponse{}
+	index := make(map[string]*BatchTransferResult)
+	for _, entry := range body.Entries {
+		r, ok := index[entry.To]
+		if !ok {
+			r = &BatchTransferResult{To: entry.To}
+			index[entry.To] = r
+			result.Results = append(result.Results, r)
+		}
+		r.Tokens = mergeTokens(r.Tokens, entry.Tokens)
+	}
+	op.setAttributes(AttrTxCount.Int(len(result.Results)))
+
+	if w.s != nil {
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
+		if err != nil {
+			result = nil
+			return
+		}
+	}
+
+	concurrency := body.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, r := range result.Results {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *BatchTransferResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			r.Response, r.Err = w.transferCToken(op.ctx, cloneHeader(header), &wallet.TransferCTokenBody{
+				From:    body.From,
+				To:      r.To,
+				AssetId: body.AssetId,
+				Tokens:  r.Tokens,
+			}, signParams)
+		}(r)
+	}
+	wg.Wait()
+
+	return
+}
+
+// mergeTokens adds tokens to merged, summing the amounts of the same
+// colored token.
+func mergeTokens(merged, tokens []*wallet.TokenAmount) []*wallet.TokenAmount {
+next:
+	for _, token := range tokens {
+		for _, m := range merged {
+			if m.TokenId == token.TokenId {
+				m.Amount += token.Amount
+				continue next
+			}
+		}
+		merged = append(merged, &wallet.TokenAmount{TokenId: token.TokenId, Amount: token.Amount})
+	}
+	return merged
+}
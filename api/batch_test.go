@@ -0,0 +1,213 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/rest"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestBatchTransferCTokenSucc(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	const token = "user-token-001"
+
+	//request body & response body
+	reqBody := &BatchTransferCTokenBody{
+		From:    "did:axn:001",
+		AssetId: "asset-id-001",
+		Entries: []*BatchTransferEntry{
+			{To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}}},
+			{To: "did:axn:003", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 20}}},
+			{To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 30}}},
+		},
+	}
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+	signers := map[string]*pki.SignatureParam{
+		"did:axn:001":   sign,
+		testPlatformDID: platformSignParam(),
+	}
+
+	//mock http request, recipients are processed in order
+	for _, to := range []string{"did:axn:002", "did:axn:003"} {
+		gock.New("http://127.0.0.1:8006").
+			Post("/v2/transaction/tokens/transfer/prepare").
+			MatchHeader("X-Auth-Token", token).
+			AddMatcher(matchBodyContains(`"to":"` + to + `"`)).
+			Reply(200).
+			JSON(jsonPayload(t, []*pw.TX{
+				newTestTx(t, "did:axn:001", 50, 950),
+				newTestTx(t, testPlatformDID, 1, 99),
+			}))
+		gock.New("http://127.0.0.1:8006").
+			Post("/v2/transaction/process").
+			MatchHeader("X-Auth-Token", token).
+			AddMatcher(matchSignedTxs(signers)).
+			Reply(200).
+			JSON(jsonPayload(t, &sw.WalletResponse{
+				TransactionIds: []string{"trans-id-" + to},
+			}))
+	}
+
+	//set http header
+	header := http.Header{}
+	header.Set("X-Auth-Token", token)
+
+	//do batch transfer colored token
+	resp, err := walletClient.BatchTransferCToken(header, reqBody, sign)
+	if err != nil {
+		t.Fatalf("batch transfer colored token fail: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("entries of the same recipient should be merged, got %d results", len(resp.Results))
+	}
+	if len(resp.Failed()) != 0 {
+		t.Fatalf("no transfer should fail: %v", resp.Failed()[0].Err)
+	}
+	first := resp.Results[0]
+	if first.To != "did:axn:002" || len(first.Tokens) != 1 || first.Tokens[0].Amount != 80 {
+		t.Fatalf("first result should merge the transfers to did:axn:002")
+	}
+	for _, result := range resp.Results {
+		if result.Response == nil || result.Response.TransactionIds[0] != "trans-id-"+result.To {
+			t.Fatalf("response of %s should be its process response", result.To)
+		}
+	}
+	if reqBody.Entries[0].Tokens[0].Amount != 50 {
+		t.Fatalf("request entries should not be modified")
+	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal and process requests should be sent")
+	}
+}
+
+func TestBatchTransferCTokenPartialFail(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	const (
+		errCode = 5015
+		errMsg  = "BalancesNotSufficient"
+	)
+
+	reqBody := &BatchTransferCTokenBody{
+		From: "did:axn:001",
+		Entries: []*BatchTransferEntry{
+			{To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}}},
+			{To: "did:axn:003", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 5000}}},
+		},
+		Concurrency: 2,
+	}
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		AddMatcher(matchBodyContains(`"to":"did:axn:002"`)).
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 50, 950)}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		AddMatcher(matchBodyContains(`"to":"did:axn:003"`)).
+		Reply(200).
+		JSON(&rtstructs.Response{
+			ErrCode:    errCode,
+			ErrMessage: errMsg,
+		})
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"trans-id-001"}}))
+
+	resp, err := walletClient.BatchTransferCToken(http.Header{}, reqBody, sign)
+	if err != nil {
+		t.Fatalf("partial failure should be reported by the results: %v", err)
+	}
+	if resp.Results[0].Err != nil || resp.Results[0].Response == nil {
+		t.Fatalf("transfer to did:axn:002 should succeed: %v", resp.Results[0].Err)
+	}
+	failed := resp.Failed()
+	if len(failed) != 1 || failed[0].To != "did:axn:003" {
+		t.Fatalf("transfer to did:axn:003 should fail")
+	}
+	errWitherrCode, ok := failed[0].Err.(rest.HTTPCodedError)
+	if !ok {
+		t.Fatalf("error type should be HTTPCodedError not %v", reflect.TypeOf(failed[0].Err))
+	}
+	if errWitherrCode.Code() != errCode {
+		t.Fatalf("Error code should be %d", errCode)
+	}
+	if failed[0].Response != nil {
+		t.Fatalf("response should be nil when transfer fail")
+	}
+}
+
+func TestBatchTransferCTokenInvalid(t *testing.T) {
+	//init gock & walletclient
+	initWalletClient(t)
+	defer gock.Off()
+
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+	bodies := []*BatchTransferCTokenBody{
+		nil,
+		{From: "did:axn:001"},
+		{From: "did:axn:001", Entries: []*BatchTransferEntry{nil}},
+		{From: "did:axn:001", Entries: []*BatchTransferEntry{{Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 1}}}}},
+		{From: "did:axn:001", Entries: []*BatchTransferEntry{{To: "did:axn:002"}}},
+		{From: "did:axn:001", Entries: []*BatchTransferEntry{{To: "did:axn:002", Tokens: []*sw.TokenAmount{nil}}}},
+		{From: "did:axn:001", Entries: []*BatchTransferEntry{{To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001"}}}}},
+		{From: "did:axn:001", Entries: []*BatchTransferEntry{
+			{To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: -5}}},
+			{To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 10}}},
+		}},
+	}
+	for i, body := range bodies {
+		resp, err := walletClient.BatchTransferCToken(http.Header{}, body, sign)
+		if err == nil {
+			t.Fatalf("body %d: err should not be nil when request payload invalid", i)
+		}
+		if resp != nil {
+			t.Fatalf("body %d: response should be nil when request payload invalid", i)
+		}
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("no proposal should be sent when request payload invalid")
+	}
+}
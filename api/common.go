@@ -82,21 +82,7 @@ func buildSignatureBody(signParams *pki.SignatureParam, data []byte) (*pki.Signa
 	if err != nil {
 		return nil, err
 	}
-	signBase64 := utils.EncodeBase64(signData.Sign)
-
-	sign := &pki.SignatureBody{
-		Creator:        signParams.Creator,
-		Created:        signParams.Created,
-		Nonce:          signParams.Nonce,
-		SignatureValue: signBase64,
-	}
-
-	return sign, nil
-}
-
-// without base64 encode
-func buildSignatureBodyBase(signParams *pki.SignatureParam, data []byte) (*pki.SignatureBody, error) {
-	signData, err := buildSignature(signParams, data)
+	value, err := Signature(signData.Sign).Encode(SignatureBase64)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +91,7 @@ func buildSignatureBodyBase(signParams *pki.SignatureParam, data []byte) (*pki.S
 		Creator:        signParams.Creator,
 		Created:        signParams.Created,
 		Nonce:          signParams.Nonce,
-		SignatureValue: string(signData.Sign),
+		SignatureValue: value,
 	}
 
 	return sign, nil
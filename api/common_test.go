@@ -0,0 +1,190 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+const signingVectorsFile = "signing_vectors.json"
+
+// signingVector is a golden signing test vector: the sign params and
+// payload, and the expected outputs of each signing function.
+type signingVector struct {
+	Name       string `json:"name"`
+	Creator    string `json:"creator"`
+	Created    string `json:"created"`
+	Nonce      string `json:"nonce"`
+	PrivateKey string `json:"private_key"`
+	Payload    []byte `json:"payload"`
+
+	// Signature is the signature built by buildSignature.
+	Signature []byte `json:"signature"`
+	// SignatureBody is the signature body built by buildSignatureBody.
+	SignatureBody *pki.SignatureBody `json:"signature_body"`
+	// UTXOScript is the script of a TX output carrying Payload as
+	// public key, once signed by SignTx.
+	UTXOScript json.RawMessage `json:"utxo_script"`
+}
+
+func (v *signingVector) signParams() *pki.SignatureParam {
+	return &pki.SignatureParam{
+		Creator:    did.Identifier(v.Creator),
+		Created:    v.Created,
+		Nonce:      v.Nonce,
+		PrivateKey: v.PrivateKey,
+	}
+}
+
+// sign runs every signing function over the vector inputs, and returns
+// a copy of the vector with the outputs filled in.
+func (v *signingVector) sign(t *testing.T) *signingVector {
+	result := *v
+
+	signature, err := buildSignature(v.signParams(), v.Payload)
+	if err != nil {
+		t.Fatalf("%s: build signature fail: %v", v.Name, err)
+	}
+	if signature.Header == nil || string(signature.Header.Creator) != v.Creator || string(signature.Header.Nonce) != v.Nonce {
+		t.Fatalf("%s: signature header should carry creator and nonce, got %+v", v.Name, signature.Header)
+	}
+	result.Signature = signature.Sign
+
+	result.SignatureBody, err = buildSignatureBody(v.signParams(), v.Payload)
+	if err != nil {
+		t.Fatalf("%s: build signature body fail: %v", v.Name, err)
+	}
+
+	script, err := json.Marshal(&pw.UTXOSignature{PublicKey: v.Payload})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	tx := &pw.TX{
+		Founder: v.Creator,
+		Txout:   []*pw.TX_TXOUT{&pw.TX_TXOUT{Script: script}},
+	}
+	if err = signTx(tx, v.signParams()); err != nil {
+		t.Fatalf("%s: sign tx fail: %v", v.Name, err)
+	}
+	result.UTXOScript = tx.Txout[0].Script
+
+	return &result
+}
+
+func loadSigningVectors(t *testing.T) []*signingVector {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", signingVectorsFile))
+	if err != nil {
+		t.Fatalf("read signing vectors fail: %v", err)
+	}
+	var vectors []*signingVector
+	if err = json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("parse signing vectors fail: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatalf("signing vectors should not be empty")
+	}
+	return vectors
+}
+
+func compactJSON(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestSigningVectors checks the signing functions against the golden
+// vectors of testdata/signing_vectors.json. Any change in how signed
+// data is serialized breaks production signing and fails this test.
+//
+// Run with -update to regenerate the expected outputs after an
+// intended change.
+//
+func TestSigningVectors(t *testing.T) {
+	vectors := loadSigningVectors(t)
+
+	if *update {
+		for i, v := range vectors {
+			vectors[i] = v.sign(t)
+		}
+		data, err := json.MarshalIndent(vectors, "", "  ")
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = ioutil.WriteFile(filepath.Join("testdata", signingVectorsFile), append(data, '\n'), 0644)
+		if err != nil {
+			t.Fatalf("write signing vectors fail: %v", err)
+		}
+		return
+	}
+
+	for _, v := range vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			got := v.sign(t)
+			if !bytes.Equal(got.Signature, v.Signature) {
+				t.Fatalf("signature should be %x not %x", v.Signature, got.Signature)
+			}
+			if v.SignatureBody == nil || *got.SignatureBody != *v.SignatureBody {
+				t.Fatalf("signature body should be %+v not %+v", v.SignatureBody, got.SignatureBody)
+			}
+			if !bytes.Equal(got.UTXOScript, compactJSON(t, v.UTXOScript)) {
+				t.Fatalf("utxo script should be %s not %s", compactJSON(t, v.UTXOScript), got.UTXOScript)
+			}
+		})
+	}
+}
+
+// TestSigningVectorsDeterministic checks that signing the same input
+// twice yields the same outputs, which the golden vectors rely on.
+//
+func TestSigningVectorsDeterministic(t *testing.T) {
+	for _, v := range loadSigningVectors(t) {
+		first, second := v.sign(t), v.sign(t)
+		if !bytes.Equal(first.Signature, second.Signature) || !bytes.Equal(first.UTXOScript, second.UTXOScript) {
+			t.Fatalf("%s: signing should be deterministic", v.Name)
+		}
+	}
+}
+
+func TestBuildSignatureInvalidParams(t *testing.T) {
+	cases := []struct {
+		name       string
+		signParams *pki.SignatureParam
+	}{
+		{"NilParams", nil},
+		{"NoCreator", &pki.SignatureParam{PrivateKey: testPrivateKey}},
+		{"NoPrivateKey", &pki.SignatureParam{Creator: "did:axn:001"}},
+		{"InvalidPrivateKey", &pki.SignatureParam{Creator: "did:axn:001", PrivateKey: "not base64!"}},
+	}
+	for _, c := range cases {
+		if _, err := buildSignature(c.signParams, []byte("payload")); err == nil {
+			t.Fatalf("%s: build signature should fail", c.name)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/arxanchain/sdk-go-common/errors"
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// dryRunPayloads maps the calls changing the platform state to the
+// payload returned in their place by a dry-run client.
+var dryRunPayloads = map[string]string{
+	"Register":          "{}",
+	"RegisterSubWallet": "{}",
+	"CreatePOE":         "{}",
+	"UpdatePOE":         "{}",
+	"UploadPOEFile":     "{}",
+	"IndexSet":          "[]",
+	"ProcessTx":         "{}",
+}
+
+// DryRunRequest is a state-changing request a dry-run client stopped
+// before sending.
+//
+type DryRunRequest struct {
+	// Name is the name of the WalletClient method issuing the request,
+	// e.g. "CreatePOE" or "ProcessTx".
+	Name string
+
+	Method      string
+	Path        string
+	Header      http.Header
+	Params      map[string]string
+	ContentType string
+
+	// Body is the request body as it would be sent, JSON encoded
+	// unless the call body is a []byte.
+	Body []byte
+
+	// Txs holds the signed TXs of a "ProcessTx" request.
+	Txs []*pw.TX
+}
+
+// DryRunRecorder collects the requests stopped by a dry-run client.
+//
+// It is safe for concurrent use.
+//
+type DryRunRecorder struct {
+	mu       sync.Mutex
+	requests []*DryRunRequest
+}
+
+// Requests returns the recorded requests in the order they were issued.
+//
+func (r *DryRunRecorder) Requests() []*DryRunRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*DryRunRequest(nil), r.requests...)
+}
+
+// Reset discards the recorded requests.
+//
+func (r *DryRunRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = nil
+}
+
+func (r *DryRunRecorder) record(req *DryRunRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+}
+
+// WithDryRun turns the client into a dry-run client recording into r,
+// see DryRun.
+//
+func WithDryRun(r *DryRunRecorder) Option {
+	return func(w *WalletClient) {
+		w.dryRun = r
+	}
+}
+
+// DryRun returns a copy of the client simulating write operations:
+//
+//	rec := &DryRunRecorder{}
+//	_, err := client.DryRun(rec).TransferCToken(header, body, signParams)
+//
+// Read requests, proposals and local signing are performed as usual,
+// but every request changing the platform state (Register,
+// RegisterSubWallet, CreatePOE, UpdatePOE, UploadPOEFile, IndexSet and
+// ProcessTx) is recorded into r instead of being sent, and answered with
+// an empty successful response. Key pairs are not trusteed to the
+// safebox.
+//
+// Middlewares still see the stopped requests, so the recorded headers
+// are the ones that would be sent.
+//
+// The copy shares the configuration of the client, it should not be
+// used to add middlewares or hooks.
+//
+func (w *WalletClient) DryRun(r *DryRunRecorder) *WalletClient {
+	c := *w
+	c.dryRun = r
+	return &c
+}
+
+// handler returns the innermost handler of the middleware chain.
+func (w *WalletClient) handler() Handler {
+	if w.dryRun == nil {
+		return w.do
+	}
+	return w.simulate
+}
+
+// simulate is the innermost handler of a dry-run client, it sends read
+// requests and records the state-changing ones.
+func (w *WalletClient) simulate(call *Call) error {
+	payload, ok := dryRunPayloads[call.Name]
+	if !ok {
+		return w.do(call)
+	}
+
+	req := &DryRunRequest{
+		Name:        call.Name,
+		Method:      call.Method,
+		Path:        call.Path,
+		Header:      cloneHeader(call.Header),
+		ContentType: call.ContentType,
+	}
+	if call.Params != nil {
+		req.Params = make(map[string]string, len(call.Params))
+		for key, value := range call.Params {
+			req.Params[key] = value
+		}
+	}
+	switch body := call.Body.(type) {
+	case nil:
+	case []byte:
+		req.Body = append([]byte(nil), body...)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		req.Body = data
+	}
+	if body, ok := call.Body.(*wallet.ProcessTxBody); ok {
+		req.Txs = body.Txs
+	}
+	w.dryRun.record(req)
+
+	call.Response = &rtstructs.Response{
+		ErrCode: errors.SuccCode,
+		Payload: payload,
+	}
+	return nil
+}
+
+func cloneHeader(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+	clone := make(http.Header, len(header))
+	for key, values := range header {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
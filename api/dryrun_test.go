@@ -0,0 +1,201 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestDryRunIssueCToken(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	const (
+		token    = "user-token-001"
+		ctokenID = "colored-token-id-001"
+	)
+
+	reqBody := &sw.IssueBody{
+		Issuer:  "did:axn:001",
+		Owner:   "did:axn:002",
+		AssetId: "asset-id-001",
+		Amount:  1000,
+	}
+	signParam := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+	prepared := &sw.IssueCTokenPrepareResponse{
+		TokenId: ctokenID,
+		Txs: []*pw.TX{
+			newTestTx(t, "did:axn:001", 1000),
+			newTestTx(t, testPlatformDID, 1, 99),
+		},
+	}
+
+	//only the proposal is mocked, processing must not be sent
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/issue/prepare").
+		MatchHeader("X-Auth-Token", token).
+		Reply(200).
+		JSON(jsonPayload(t, prepared))
+
+	header := http.Header{}
+	header.Set("X-Auth-Token", token)
+
+	rec := &DryRunRecorder{}
+	resp, err := walletClient.(*WalletClient).DryRun(rec).IssueCToken(header, reqBody, signParam)
+	if err != nil {
+		t.Fatalf("dry-run issue colored token fail: %v", err)
+	}
+	if resp == nil || resp.TokenId != ctokenID {
+		t.Fatalf("response colored token id should be %v", ctokenID)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal request should be sent")
+	}
+
+	requests := rec.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("one request should be recorded, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Name != "ProcessTx" || req.Method != "POST" || req.Path != "/v2/transaction/process" {
+		t.Fatalf("unexpected recorded request %s %s %s", req.Name, req.Method, req.Path)
+	}
+	if req.Header.Get("X-Auth-Token") != token {
+		t.Fatalf("recorded header should be the sent one")
+	}
+	if len(req.Txs) != 2 {
+		t.Fatalf("recorded request should carry the signed TXs")
+	}
+
+	httpReq, err := http.NewRequest(req.Method, req.Path, bytes.NewReader(req.Body))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	ok, err := matchSignedTxs(map[string]*pki.SignatureParam{
+		"did:axn:001":   signParam,
+		testPlatformDID: platformSignParam(),
+	})(httpReq, nil)
+	if !ok || err != nil {
+		t.Fatalf("recorded TXs should be signed: %v", err)
+	}
+}
+
+func TestDryRunCreatePOE(t *testing.T) {
+	//init gock & walletclient
+	initWalletClient(t)
+	defer gock.Off()
+
+	reqBody := &sw.POEBody{
+		Name:     "piaoju001",
+		Owner:    "did:axn:001",
+		Metadata: []byte("this is metadata"),
+	}
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:arxan-provider",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+
+	rec := &DryRunRecorder{}
+	resp, err := walletClient.(*WalletClient).DryRun(rec).CreatePOE(http.Header{}, reqBody, sign)
+	if err != nil {
+		t.Fatalf("dry-run create poe fail: %v", err)
+	}
+	if resp == nil {
+		t.Fatalf("response should not be nil")
+	}
+
+	requests := rec.Requests()
+	if len(requests) != 1 || requests[0].Path != "/v1/poe/create" {
+		t.Fatalf("create poe request should be recorded")
+	}
+	var body sw.WalletRequest
+	if err = json.Unmarshal(requests[0].Body, &body); err != nil {
+		t.Fatalf("recorded body should be JSON: %v", err)
+	}
+	var payload sw.POEBody
+	if err = json.Unmarshal([]byte(body.Payload), &payload); err != nil {
+		t.Fatalf("recorded payload should be JSON: %v", err)
+	}
+	if payload.Name != reqBody.Name || body.Signature == nil {
+		t.Fatalf("recorded body should carry the signed payload")
+	}
+
+	rec.Reset()
+	if len(rec.Requests()) != 0 {
+		t.Fatalf("recorder should be empty after reset")
+	}
+}
+
+func TestDryRunRegisterSkipsSafebox(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithTrustKeypair(t)
+	defer gock.Off()
+
+	rec := &DryRunRecorder{}
+	resp, err := walletClient.(*WalletClient).DryRun(rec).Register(http.Header{}, &sw.RegisterWalletBody{
+		Type:   pw.DidType_ORGANIZATION,
+		Access: "alice",
+		Secret: "123456",
+	})
+	if err != nil {
+		t.Fatalf("dry-run register fail: %v", err)
+	}
+	if resp == nil {
+		t.Fatalf("response should not be nil")
+	}
+	if requests := rec.Requests(); len(requests) != 1 || requests[0].Name != "Register" {
+		t.Fatalf("register request should be recorded")
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("no request should be sent")
+	}
+}
+
+func TestWithDryRunIndexSet(t *testing.T) {
+	//init gock & walletclient
+	rec := &DryRunRecorder{}
+	w := newTestWalletClient(t, WithDryRun(rec))
+	defer gock.Off()
+
+	txIDs, err := w.IndexSet(http.Header{}, &sw.IndexSetPayload{
+		Id:     "did:axn:001",
+		Indexs: &sw.IndexTags{CombinedIndex: []string{"index-001"}},
+	})
+	if err != nil {
+		t.Fatalf("dry-run index set fail: %v", err)
+	}
+	if len(txIDs) != 0 {
+		t.Fatalf("dry-run index set should return no transaction")
+	}
+	if requests := rec.Requests(); len(requests) != 1 || requests[0].Path != "/v1/index/set" {
+		t.Fatalf("index set request should be recorded")
+	}
+}
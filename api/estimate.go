@@ -0,0 +1,133 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// Fee is the fee paid by one fee TX of a proposal.
+//
+type Fee struct {
+	Tx *pw.TX
+	// Payer is the founder of the fee TX, whose tokens pay the fee.
+	Payer did.Identifier
+	// Amounts are the fee amounts by colored token id: the value of
+	// the outputs not sent back to the payer.
+	Amounts map[string]int64
+}
+
+// FeeEstimate is the fee a transaction would cost.
+//
+type FeeEstimate struct {
+	// Txs are the TXs of the proposal, not signed.
+	Txs  []*pw.TX
+	Fees []*Fee
+	// Total is the total fee by colored token id.
+	Total map[string]int64
+}
+
+// EstimateFee reports the fee an issuance or transfer would cost, and
+// who would pay it, without signing nor processing it.
+//
+// body is one of *wallet.IssueBody, *wallet.IssueAssetBody,
+// *wallet.TransferCTokenBody or *wallet.TransferAssetBody. The proposal
+// is sent to the platform, and the TXs not founded by the issuer or the
+// sender are inspected as fee TXs.
+//
+func (w *WalletClient) EstimateFee(header http.Header, body interface{}) (result *FeeEstimate, err error) {
+	op := w.begin(w.parentContext(), "EstimateFee")
+	defer func() { op.end(err) }()
+
+	// 1 send proposal to get wallet.Tx
+	var txs []*pw.TX
+	var caller string
+	switch body := body.(type) {
+	case *wallet.IssueBody:
+		var issueRsp *wallet.IssueCTokenPrepareResponse
+		issueRsp, err = w.sendIssueCTokenProposal(op.ctx, header, body)
+		if err == nil {
+			txs, caller = issueRsp.Txs, body.Issuer
+		}
+	case *wallet.IssueAssetBody:
+		txs, err = w.sendIssueAssetProposal(op.ctx, header, body)
+		if err == nil {
+			caller = body.Issuer
+		}
+	case *wallet.TransferCTokenBody:
+		txs, err = w.sendTransferCTokenProposal(op.ctx, header, body)
+		if err == nil {
+			caller = body.From
+		}
+	case *wallet.TransferAssetBody:
+		txs, err = w.sendTransferAssetProposal(op.ctx, header, body)
+		if err == nil {
+			caller = body.From
+		}
+	default:
+		err = fmt.Errorf("request payload invalid")
+	}
+	if err != nil {
+		return nil, err
+	}
+	op.setAttributes(AttrFrom.String(caller))
+
+	// 2 sum the outputs of the fee TXs not sent back to their payer
+	result = &FeeEstimate{
+		Txs:   txs,
+		Total: make(map[string]int64),
+	}
+	endpoints := make(map[string]did.DidEndpoint)
+	for _, tx := range txs {
+		if tx.Founder == caller {
+			continue
+		}
+		payer := tx.Founder
+		endpoint, ok := endpoints[payer]
+		if !ok {
+			info, err := w.getWalletInfo(op.ctx, header, did.Identifier(payer))
+			if err != nil {
+				return nil, fmt.Errorf("query fee payer %s error: %v", payer, err)
+			}
+			if info == nil {
+				return nil, fmt.Errorf("fee payer %s not found", payer)
+			}
+			endpoint = info.Endpoint
+			endpoints[payer] = endpoint
+		}
+
+		fee := &Fee{
+			Tx:      tx,
+			Payer:   did.Identifier(payer),
+			Amounts: make(map[string]int64),
+		}
+		for _, txout := range tx.Txout {
+			if txout.Addr == string(endpoint) {
+				continue
+			}
+			fee.Amounts[txout.CTokenId] += txout.Value
+			result.Total[txout.CTokenId] += txout.Value
+		}
+		result.Fees = append(result.Fees, fee)
+	}
+	return result, nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestEstimateFeeSucc(t *testing.T) {
+	//init gock & walletclient, the fee payer is not asked to sign
+	walletClient = newTestWalletClient(t, WithFeePayer(FeePayerFunc(func(fee *FeeTx) (*pki.SignatureParam, error) {
+		return nil, fmt.Errorf("fee payer should not be called")
+	})))
+	defer gock.Off()
+
+	const token = "user-token-001"
+
+	// the fee tx pays 2 to the platform and gives 98 back to the payer
+	feeTx := newTestTx(t, testPlatformDID, 2, 98)
+	feeTx.Txout[1].Addr = "endpoint-platform"
+	prepared := []*pw.TX{
+		newTestTx(t, "did:axn:001", 50, 950),
+		feeTx,
+	}
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		MatchHeader("X-Auth-Token", token).
+		Reply(200).
+		JSON(jsonPayload(t, prepared))
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/info").
+		MatchParam("id", testPlatformDID).
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletInfo{Id: testPlatformDID, Endpoint: "endpoint-platform"}))
+
+	//set http header
+	header := http.Header{}
+	header.Set("X-Auth-Token", token)
+
+	estimate, err := walletClient.(*WalletClient).EstimateFee(header, &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}},
+	})
+	if err != nil {
+		t.Fatalf("estimate fee fail: %v", err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal and wallet info requests should be sent")
+	}
+	if len(estimate.Txs) != 2 {
+		t.Fatalf("estimate should contain the proposal txs")
+	}
+	if len(estimate.Fees) != 1 {
+		t.Fatalf("estimate should contain one fee not %d", len(estimate.Fees))
+	}
+	fee := estimate.Fees[0]
+	if fee.Tx.Founder != testPlatformDID || fee.Payer != testPlatformDID {
+		t.Fatalf("fee should be paid by the enterprise, got %+v", fee)
+	}
+	if fee.Amounts["ctoken-id-001"] != 2 || estimate.Total["ctoken-id-001"] != 2 {
+		t.Fatalf("fee amount should be 2, got %v", fee.Amounts)
+	}
+	for _, tx := range estimate.Txs {
+		for _, txout := range tx.Txout {
+			var sig pw.UTXOSignature
+			if err = json.Unmarshal(txout.Script, &sig); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if len(sig.Signature) != 0 {
+				t.Fatalf("estimate should not sign txs")
+			}
+		}
+	}
+}
+
+func TestEstimateFeeNoFee(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/assets/issue/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 1)}))
+
+	estimate, err := walletClient.(*WalletClient).EstimateFee(nil, &sw.IssueAssetBody{
+		Issuer:  "did:axn:001",
+		Owner:   "did:axn:002",
+		AssetId: "asset-id-001",
+	})
+	if err != nil {
+		t.Fatalf("estimate fee fail: %v", err)
+	}
+	if len(estimate.Fees) != 0 || len(estimate.Total) != 0 {
+		t.Fatalf("estimate should not contain fees, got %+v", estimate)
+	}
+}
+
+func TestEstimateFeeFail(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	const (
+		errCode = 5015
+		errMsg  = "BalancesNotSufficient"
+	)
+
+	if _, err := walletClient.(*WalletClient).EstimateFee(nil, &sw.POEBody{}); err == nil {
+		t.Fatalf("estimate fee of unsupported body should fail")
+	}
+
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/issue/prepare").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: errCode, ErrMessage: errMsg})
+
+	estimate, err := walletClient.(*WalletClient).EstimateFee(nil, &sw.IssueBody{
+		Issuer:  "did:axn:001",
+		Owner:   "did:axn:002",
+		AssetId: "asset-id-001",
+		Amount:  1000,
+	})
+	if err == nil || err.Error() != errMsg {
+		t.Fatalf("estimate fee should fail with %s, got %v", errMsg, err)
+	}
+	if estimate != nil {
+		t.Fatalf("estimate should be nil when proposal fail")
+	}
+}
+
+func TestEstimateFeePayerNotFound(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 50), newTestTx(t, testPlatformDID, 2)}))
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/info").
+		MatchParam("id", testPlatformDID).
+		Reply(200).
+		JSON(jsonPayload(t, nil))
+
+	estimate, err := walletClient.(*WalletClient).EstimateFee(nil, &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}},
+	})
+	if err == nil {
+		t.Fatalf("estimate fee should fail when the fee payer is not found")
+	}
+	if estimate != nil {
+		t.Fatalf("estimate should be nil when the fee payer is not found")
+	}
+}
+
+func TestEstimateFeeNilBody(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	bodies := []interface{}{
+		(*sw.IssueBody)(nil),
+		(*sw.IssueAssetBody)(nil),
+		(*sw.TransferCTokenBody)(nil),
+		(*sw.TransferAssetBody)(nil),
+	}
+	for _, body := range bodies {
+		estimate, err := walletClient.(*WalletClient).EstimateFee(nil, body)
+		if err == nil {
+			t.Fatalf("estimate fee of nil %T should fail", body)
+		}
+		if estimate != nil {
+			t.Fatalf("estimate of nil %T should be nil", body)
+		}
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("no http request should be sent")
+	}
+}
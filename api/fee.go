@@ -0,0 +1,196 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+)
+
+// FeeTx is a TX of a proposal not founded by the caller, such as the
+// TX paying the platform fee, which has to be signed by a fee payer.
+//
+type FeeTx struct {
+	Context context.Context
+	// Header is the http header of the call, nil when signing with
+	// SignTxs or PlanSignTxs without header.
+	Header http.Header
+	// Creator is the caller, signing the TXs it founded.
+	Creator did.Identifier
+	Tx      *pw.TX
+}
+
+// FeePayer decides who signs fee TXs.
+//
+// By default fee TXs are signed with the enterprise sign params of the
+// client config.
+//
+type FeePayer interface {
+	// FeeSignParam returns the sign params to sign the fee TX with.
+	FeeSignParam(fee *FeeTx) (*pki.SignatureParam, error)
+}
+
+// FeePayerFunc is a callback deciding who signs each fee TX.
+//
+type FeePayerFunc func(fee *FeeTx) (*pki.SignatureParam, error)
+
+// FeeSignParam calls f(fee).
+//
+func (f FeePayerFunc) FeeSignParam(fee *FeeTx) (*pki.SignatureParam, error) {
+	return f(fee)
+}
+
+// StaticFeePayer signs every fee TX with the same sign params.
+//
+func StaticFeePayer(signParams *pki.SignatureParam) FeePayer {
+	return FeePayerFunc(func(fee *FeeTx) (*pki.SignatureParam, error) {
+		return signParams, nil
+	})
+}
+
+// TenantFeePayer signs fee TXs with the sign params of the tenant the
+// call is made for.
+//
+type TenantFeePayer struct {
+	// Tenant returns the tenant of a fee TX, see TenantFromHeader and
+	// TenantFromCreator.
+	Tenant func(fee *FeeTx) string
+	// Payers maps tenants to the sign params of their fee payer.
+	Payers map[string]*pki.SignatureParam
+	// Default signs the fee TXs of unknown tenants. If nil, they
+	// fail to be signed.
+	Default FeePayer
+}
+
+// FeeSignParam returns the sign params of the tenant of fee.
+//
+func (p *TenantFeePayer) FeeSignParam(fee *FeeTx) (*pki.SignatureParam, error) {
+	tenant := ""
+	if p.Tenant != nil {
+		tenant = p.Tenant(fee)
+	}
+	if signParams, ok := p.Payers[tenant]; ok {
+		return signParams, nil
+	}
+	if p.Default != nil {
+		return p.Default.FeeSignParam(fee)
+	}
+	return nil, fmt.Errorf("no fee payer for tenant %q", tenant)
+}
+
+// TenantFromHeader returns the tenant carried by the http header name.
+//
+func TenantFromHeader(name string) func(fee *FeeTx) string {
+	return func(fee *FeeTx) string {
+		return fee.Header.Get(name)
+	}
+}
+
+// TenantFromCreator uses the DID of the caller as tenant.
+//
+func TenantFromCreator(fee *FeeTx) string {
+	return string(fee.Creator)
+}
+
+// WithFeePayer sets the fee payer of the client.
+//
+func WithFeePayer(p FeePayer) Option {
+	return func(w *WalletClient) {
+		w.feePayer = p
+	}
+}
+
+// FeePaidBy returns a copy of the client whose fee TXs are signed by p,
+// to choose the fee payer of a single call:
+//
+//	client.FeePaidBy(StaticFeePayer(feeSignParams)).TransferCToken(header, body, signParams)
+//
+// The copy shares the configuration of the client, it should not be
+// used to add middlewares or hooks.
+//
+func (w *WalletClient) FeePaidBy(p FeePayer) *WalletClient {
+	c := *w
+	c.feePayer = p
+	return &c
+}
+
+// TxSigner tells who signs a TX of a proposal.
+//
+type TxSigner struct {
+	Tx *pw.TX
+	// Signer is the DID whose key signs the TX.
+	Signer did.Identifier
+	// Fee reports whether the TX is signed by the fee payer rather
+	// than by the caller.
+	Fee bool
+
+	signParams *pki.SignatureParam
+}
+
+// PlanSignTxs reports who will sign each TX when calling SignTxs,
+// without signing anything.
+//
+func (w *WalletClient) PlanSignTxs(header http.Header, txs []*pw.TX, signParams *pki.SignatureParam) (plan []*TxSigner, err error) {
+	return w.planSignTxs(w.parentContext(), header, txs, signParams)
+}
+
+func (w *WalletClient) planSignTxs(ctx context.Context, header http.Header, txs []*pw.TX, signParams *pki.SignatureParam) (plan []*TxSigner, err error) {
+	if signParams == nil {
+		err = fmt.Errorf("request signature params invalid")
+		return
+	}
+
+	signCreator := string(signParams.Creator)
+	for _, tx := range txs {
+		if tx.Founder == signCreator {
+			plan = append(plan, &TxSigner{Tx: tx, Signer: signParams.Creator, signParams: signParams})
+			continue
+		}
+
+		// sign fee by fee payer private key
+		feeSignParams, err := w.payer().FeeSignParam(&FeeTx{
+			Context: ctx,
+			Header:  header,
+			Creator: signParams.Creator,
+			Tx:      tx,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if feeSignParams == nil {
+			return nil, fmt.Errorf("no fee payer for tx founded by %s", tx.Founder)
+		}
+		plan = append(plan, &TxSigner{Tx: tx, Signer: feeSignParams.Creator, Fee: true, signParams: feeSignParams})
+	}
+	return plan, nil
+}
+
+// payer returns the fee payer of the client, defaulting to the
+// enterprise sign params of the config.
+func (w *WalletClient) payer() FeePayer {
+	if w.feePayer != nil {
+		return w.feePayer
+	}
+	return FeePayerFunc(func(fee *FeeTx) (*pki.SignatureParam, error) {
+		return w.c.GetEnterpriseSignParam()
+	})
+}
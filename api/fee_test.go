@@ -0,0 +1,208 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func testSignParam(creator string) *pki.SignatureParam {
+	return &pki.SignatureParam{
+		Creator:    did.Identifier(creator),
+		Nonce:      "nonce-" + creator,
+		PrivateKey: testPrivateKey,
+	}
+}
+
+// mockTransfer mocks a colored token transfer whose proposal has one TX
+// founded by the caller and one fee TX, which must be signed by feeSigner.
+func mockTransfer(t *testing.T, feeSigner *pki.SignatureParam) {
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{
+			newTestTx(t, "did:axn:001", 50),
+			newTestTx(t, testPlatformDID, 1),
+		}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001":   testSignParam("did:axn:001"),
+			testPlatformDID: feeSigner,
+		})).
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"trans-id-001"}}))
+}
+
+func doTransfer(client *WalletClient, header http.Header) (*sw.WalletResponse, error) {
+	return client.TransferCToken(header, &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}},
+	}, testSignParam("did:axn:001"))
+}
+
+func TestFeePayerStatic(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	payer := testSignParam("did:axn:fee-payer")
+	client := walletClient.(*WalletClient)
+	WithFeePayer(StaticFeePayer(payer))(client)
+	mockTransfer(t, payer)
+
+	if _, err := doTransfer(client, nil); err != nil {
+		t.Fatalf("transfer with static fee payer fail: %v", err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal and process requests should be sent")
+	}
+}
+
+func TestFeePaidBy(t *testing.T) {
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	payer := testSignParam("did:axn:fee-payer")
+	client := walletClient.(*WalletClient)
+
+	// the fee of this call only is paid by payer
+	mockTransfer(t, payer)
+	if _, err := doTransfer(client.FeePaidBy(StaticFeePayer(payer)), nil); err != nil {
+		t.Fatalf("transfer paid by fee payer fail: %v", err)
+	}
+
+	// the client still uses the enterprise sign params
+	mockTransfer(t, platformSignParam())
+	if _, err := doTransfer(client, nil); err != nil {
+		t.Fatalf("transfer paid by enterprise fail: %v", err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal and process requests should be sent")
+	}
+}
+
+func TestFeePayerTenant(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	tenantA := testSignParam("did:axn:tenant-a")
+	tenantB := testSignParam("did:axn:tenant-b")
+	client := walletClient.(*WalletClient).FeePaidBy(&TenantFeePayer{
+		Tenant: TenantFromHeader("X-Tenant"),
+		Payers: map[string]*pki.SignatureParam{
+			"a": tenantA,
+			"b": tenantB,
+		},
+	})
+
+	for tenant, payer := range map[string]*pki.SignatureParam{"a": tenantA, "b": tenantB} {
+		mockTransfer(t, payer)
+		header := http.Header{}
+		header.Set("X-Tenant", tenant)
+		if _, err := doTransfer(client, header); err != nil {
+			t.Fatalf("transfer of tenant %s fail: %v", tenant, err)
+		}
+	}
+
+	// unknown tenants without default payer fail before processing
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 50), newTestTx(t, testPlatformDID, 1)}))
+	header := http.Header{}
+	header.Set("X-Tenant", "c")
+	_, err := doTransfer(client, header)
+	if err == nil || !strings.Contains(err.Error(), `no fee payer for tenant "c"`) {
+		t.Fatalf("transfer of unknown tenant should fail, got %v", err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal requests should be sent")
+	}
+}
+
+func TestFeePayerFunc(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	payer := testSignParam("did:axn:fee-payer")
+	var fees []*FeeTx
+	client := walletClient.(*WalletClient).FeePaidBy(FeePayerFunc(func(fee *FeeTx) (*pki.SignatureParam, error) {
+		fees = append(fees, fee)
+		return payer, nil
+	}))
+	mockTransfer(t, payer)
+
+	header := http.Header{}
+	header.Set("X-Auth-Token", "user-token-001")
+	if _, err := doTransfer(client, header); err != nil {
+		t.Fatalf("transfer with fee payer callback fail: %v", err)
+	}
+	if len(fees) != 1 {
+		t.Fatalf("callback should be called for the fee tx only, got %d calls", len(fees))
+	}
+	fee := fees[0]
+	if fee.Tx.Founder != testPlatformDID || fee.Creator != "did:axn:001" {
+		t.Fatalf("callback should receive the fee tx and the caller, got %+v", fee)
+	}
+	if fee.Header.Get("X-Auth-Token") != "user-token-001" || fee.Context == nil {
+		t.Fatalf("callback should receive the call header and context")
+	}
+}
+
+func TestPlanSignTxs(t *testing.T) {
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	txs := []*pw.TX{
+		newTestTx(t, "did:axn:001", 50),
+		newTestTx(t, testPlatformDID, 1),
+	}
+	scripts := [][]byte{txs[0].Txout[0].Script, txs[1].Txout[0].Script}
+
+	plan, err := walletClient.(*WalletClient).PlanSignTxs(nil, txs, testSignParam("did:axn:001"))
+	if err != nil {
+		t.Fatalf("plan sign txs fail: %v", err)
+	}
+	if len(plan) != 2 {
+		t.Fatalf("plan should contain 2 txs not %d", len(plan))
+	}
+	if plan[0].Tx != txs[0] || plan[0].Signer != "did:axn:001" || plan[0].Fee {
+		t.Fatalf("first tx should be signed by the caller, got %+v", plan[0])
+	}
+	if plan[1].Tx != txs[1] || plan[1].Signer != testPlatformDID || !plan[1].Fee {
+		t.Fatalf("fee tx should be signed by the enterprise, got %+v", plan[1])
+	}
+	for i, tx := range txs {
+		if !bytes.Equal(tx.Txout[0].Script, scripts[i]) {
+			t.Fatalf("planning should not sign tx %d", i)
+		}
+	}
+
+	if _, err = walletClient.(*WalletClient).PlanSignTxs(nil, txs, nil); err == nil {
+		t.Fatalf("planning without sign params should fail")
+	}
+}
@@ -24,8 +24,6 @@ import (
 
 	"github.com/arxanchain/sdk-go-common/errors"
 	"github.com/arxanchain/sdk-go-common/rest"
-	restapi "github.com/arxanchain/sdk-go-common/rest/api"
-	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
 	"github.com/arxanchain/sdk-go-common/structs/wallet"
 )
 
@@ -39,28 +37,32 @@ import (
 // it will not return until the blockchain transaction is confirmed.
 //
 func (w *WalletClient) IndexSet(header http.Header, body *wallet.IndexSetPayload) (txIDs []string, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "IndexSet")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
+	op.setAttributes(AttrDID.String(string(body.Id)))
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v1/index/set")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "IndexSet",
+		Method:  "POST",
+		Path:    "/v1/index/set",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -81,28 +83,32 @@ func (w *WalletClient) IndexSet(header http.Header, body *wallet.IndexSetPayload
 // IndexGet is used to query object-id via indexs
 //
 func (w *WalletClient) IndexGet(header http.Header, body *wallet.IndexGetPayload) (IDs []string, err error) {
+	op := w.begin(w.parentContext(), "IndexGet")
+	defer func() { op.end(err) }()
+
 	if body == nil {
 		err = fmt.Errorf("request payload invalid")
 		return
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v1/index/get")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "IndexGet",
+		Method:  "POST",
+		Path:    "/v1/index/get",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -0,0 +1,456 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// MismatchKind classifies the differences between prepared TXs and the
+// request they were prepared for.
+//
+type MismatchKind string
+
+// Kinds of Mismatch.
+const (
+	// MismatchFounder: no TX is founded by the issuer or the sender.
+	MismatchFounder MismatchKind = "founder"
+	// MismatchRecipient: an output is sent to an address that is
+	// neither the recipient nor the caller.
+	MismatchRecipient MismatchKind = "recipient"
+	// MismatchToken: a token that was not requested is sent to the
+	// recipient.
+	MismatchToken MismatchKind = "token"
+	// MismatchAmount: the recipient does not receive the requested
+	// amount of a token.
+	MismatchAmount MismatchKind = "amount"
+	// MismatchFee: the fee TXs pay more than IntentCheck.MaxFee.
+	MismatchFee MismatchKind = "fee"
+	// MismatchInput: an input does not spend an unspent output of the
+	// TX founder, or spends an output already spent by another input.
+	MismatchInput MismatchKind = "input"
+	// MismatchBalance: the outputs of a TX do not add up to its inputs
+	// for a token, outside of the token issued by an issuance.
+	MismatchBalance MismatchKind = "balance"
+)
+
+// Mismatch is a difference between prepared TXs and the request.
+//
+type Mismatch struct {
+	Kind MismatchKind
+	// Tx and Output are the indexes of the offending TX and output,
+	// or input for MismatchInput, or -1 if the mismatch is not about a
+	// single one.
+	Tx     int
+	Output int
+
+	TokenId  string
+	Addr     string
+	Expected int64
+	Actual   int64
+}
+
+func (m *Mismatch) String() string {
+	switch m.Kind {
+	case MismatchFounder:
+		return "no tx founded by the caller"
+	case MismatchRecipient:
+		return fmt.Sprintf("tx %d output %d sends %d %s to unexpected address %s", m.Tx, m.Output, m.Actual, m.TokenId, m.Addr)
+	case MismatchToken:
+		return fmt.Sprintf("tx %d output %d sends unrequested token %s", m.Tx, m.Output, m.TokenId)
+	case MismatchAmount:
+		return fmt.Sprintf("recipient receives %d %s instead of %d", m.Actual, m.TokenId, m.Expected)
+	case MismatchFee:
+		return fmt.Sprintf("fee of %d %s exceeds %d", m.Actual, m.TokenId, m.Expected)
+	case MismatchInput:
+		return fmt.Sprintf("tx %d input %d does not spend an unspent output of %s", m.Tx, m.Output, m.Addr)
+	case MismatchBalance:
+		return fmt.Sprintf("tx %d outputs %d %s instead of the %d spent", m.Tx, m.Actual, m.TokenId, m.Expected)
+	}
+	return string(m.Kind)
+}
+
+// IntentError reports prepared TXs not matching the request they were
+// prepared for. They are not signed.
+//
+type IntentError struct {
+	Mismatches []*Mismatch
+}
+
+func (e *IntentError) Error() string {
+	reasons := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		reasons[i] = m.String()
+	}
+	return "prepared txs do not match the request: " + strings.Join(reasons, "; ")
+}
+
+// IntentCheck configures the verification of prepared TXs.
+//
+type IntentCheck struct {
+	// MaxFee caps the fee paid by the fee TXs of a proposal, by
+	// colored token id. Fees are not checked if MaxFee is nil, and
+	// tokens missing from MaxFee may not be paid as fee.
+	MaxFee map[string]int64
+	// FeeAddrs are the addresses fee TXs may pay to, such as the
+	// platform fee address. Fee TX outputs not sent back to the fee
+	// payer nor to FeeAddrs are rejected, so are all of them if
+	// FeeAddrs is empty.
+	FeeAddrs []string
+	// Endpoints pins the endpoints of wallets by DID. The endpoints
+	// of the other wallets are queried from the platform gateway,
+	// which is then trusted not to substitute its own address.
+	Endpoints map[did.Identifier]did.DidEndpoint
+	// PinnedOnly rejects TXs involving wallets missing from
+	// Endpoints, rather than querying their endpoint.
+	PinnedOnly bool
+}
+
+// WithIntentCheck makes the client verify that the prepared TXs match
+// the request before signing them, see CheckIntent. A nil c checks the
+// TXs without fee limit, and rejects any fee TX paying to an address.
+//
+// The check queries the wallet info of the caller, the counterparty
+// and the fee payers not pinned in c.Endpoints, and the unspent
+// outputs of the TX founders.
+//
+func WithIntentCheck(c *IntentCheck) Option {
+	return func(w *WalletClient) {
+		if c == nil {
+			c = &IntentCheck{}
+		}
+		w.intent = c
+	}
+}
+
+// intent describes what the caller requested.
+type intent struct {
+	caller    string
+	recipient string
+	// amounts are the requested amounts by token id, a zero amount
+	// only requires the token to be sent, as for digital assets.
+	amounts map[string]int64
+	// change allows outputs sent back to the caller.
+	change bool
+	// issued is the amount of an issuance whose token id is unknown,
+	// the first token sent to the recipient is taken as issued.
+	issued int64
+	// issuance allows the caller TXs to output tokens they do not
+	// spend.
+	issuance bool
+}
+
+func newIntent(body interface{}, tokenID string) (*intent, error) {
+	if err := Validate(body); err != nil {
+		return nil, err
+	}
+	in := &intent{amounts: make(map[string]int64)}
+	switch body := body.(type) {
+	case *wallet.IssueBody:
+		in.caller, in.recipient, in.issuance = body.Issuer, body.Owner, true
+		if tokenID == "" {
+			in.issued = body.Amount
+		} else {
+			in.amounts[tokenID] = body.Amount
+		}
+	case *wallet.IssueAssetBody:
+		in.caller, in.recipient, in.issuance = body.Issuer, body.Owner, true
+		in.amounts[body.AssetId] = 0
+	case *wallet.TransferCTokenBody:
+		in.caller, in.recipient, in.change = body.From, body.To, true
+		for _, token := range body.Tokens {
+			in.amounts[token.TokenId] += token.Amount
+		}
+	case *wallet.TransferAssetBody:
+		in.caller, in.recipient, in.change = body.From, body.To, true
+		for _, asset := range body.Assets {
+			in.amounts[asset] = 0
+		}
+	default:
+		return nil, fmt.Errorf("request payload type invalid: %T", body)
+	}
+	return in, nil
+}
+
+// CheckIntent verifies that txs exactly match the request body they
+// were prepared for, and reports the differences by an *IntentError.
+//
+// body is one of *wallet.IssueBody, *wallet.IssueAssetBody,
+// *wallet.TransferCTokenBody or *wallet.TransferAssetBody. The TXs
+// founded by the issuer or the sender must only send the requested
+// tokens to the recipient, and their change back to the sender. The
+// other TXs are fee TXs, which may only pay the fee addresses within
+// the fee limit of the client, see WithIntentCheck. The token id of an
+// issuance is not checked.
+//
+// Every input must spend an unspent output of the TX founder, and the
+// outputs of each TX must add up to its inputs, but for the token
+// issued by an issuance.
+//
+func (w *WalletClient) CheckIntent(header http.Header, body interface{}, txs []*pw.TX) (err error) {
+	op := w.begin(w.parentContext(), "CheckIntent", AttrTxCount.Int(len(txs)))
+	defer func() { op.end(err) }()
+
+	return w.checkIntent(op.ctx, header, body, "", txs)
+}
+
+// intentCheck returns the IntentCheck of the client, defaulting to a
+// check without fee limit nor fee address.
+func (w *WalletClient) intentCheck() *IntentCheck {
+	if w.intent == nil {
+		return &IntentCheck{}
+	}
+	return w.intent
+}
+
+// endpointResolver returns a function resolving the endpoints of
+// wallets by DID, pinned by check or queried once from the platform.
+func (w *WalletClient) endpointResolver(ctx context.Context, header http.Header, check *IntentCheck) func(id string) (string, error) {
+	endpoints := make(map[string]string)
+	return func(id string) (string, error) {
+		if e, ok := endpoints[id]; ok {
+			return e, nil
+		}
+		if e, ok := check.Endpoints[did.Identifier(id)]; ok {
+			endpoints[id] = string(e)
+			return endpoints[id], nil
+		}
+		if check.PinnedOnly {
+			return "", fmt.Errorf("endpoint of wallet %s not pinned", id)
+		}
+		info, err := w.getWalletInfo(ctx, header, did.Identifier(id))
+		if err != nil {
+			return "", fmt.Errorf("query wallet %s error: %v", id, err)
+		}
+		if info == nil {
+			return "", fmt.Errorf("wallet %s not found", id)
+		}
+		endpoints[id] = string(info.Endpoint)
+		return endpoints[id], nil
+	}
+}
+
+// checkIntent verifies txs against body, tokenID is the id of the
+// colored token issued by a *wallet.IssueBody if known.
+func (w *WalletClient) checkIntent(ctx context.Context, header http.Header, body interface{}, tokenID string, txs []*pw.TX) error {
+	in, err := newIntent(body, tokenID)
+	if err != nil {
+		return err
+	}
+	check := w.intentCheck()
+	endpoint := w.endpointResolver(ctx, header, check)
+	callerAddr, err := endpoint(in.caller)
+	if err != nil {
+		return err
+	}
+	recipientAddr, err := endpoint(in.recipient)
+	if err != nil {
+		return err
+	}
+	feeAddrs := make(map[string]bool, len(check.FeeAddrs))
+	for _, addr := range check.FeeAddrs {
+		feeAddrs[addr] = true
+	}
+
+	var mismatches []*Mismatch
+	inputs := &txInputs{w: w, ctx: ctx, header: header, spent: make(map[string]bool)}
+	received := make(map[string]int64)
+	founded := false
+	fees := make(map[string]int64)
+	for i, tx := range txs {
+		founderAddr, err := endpoint(tx.Founder)
+		if err != nil {
+			return err
+		}
+		spent, bad, err := inputs.resolve(i, tx, founderAddr)
+		if err != nil {
+			return err
+		}
+		mismatches = append(mismatches, bad...)
+
+		if tx.Founder != in.caller {
+			for j, txout := range tx.Txout {
+				switch {
+				case txout.Addr == founderAddr:
+				case feeAddrs[txout.Addr]:
+					fees[txout.CTokenId] += txout.Value
+				default:
+					mismatches = append(mismatches, &Mismatch{Kind: MismatchRecipient, Tx: i, Output: j, TokenId: txout.CTokenId, Addr: txout.Addr, Actual: txout.Value})
+				}
+			}
+		} else {
+			founded = true
+			for j, txout := range tx.Txout {
+				switch {
+				case txout.Addr == recipientAddr:
+					if in.issued != 0 {
+						in.amounts[txout.CTokenId] = in.issued
+						in.issued = 0
+					}
+					if _, ok := in.amounts[txout.CTokenId]; !ok {
+						mismatches = append(mismatches, &Mismatch{Kind: MismatchToken, Tx: i, Output: j, TokenId: txout.CTokenId, Addr: txout.Addr, Actual: txout.Value})
+						continue
+					}
+					received[txout.CTokenId] += txout.Value
+				case txout.Addr == callerAddr && in.change:
+				default:
+					mismatches = append(mismatches, &Mismatch{Kind: MismatchRecipient, Tx: i, Output: j, TokenId: txout.CTokenId, Addr: txout.Addr, Actual: txout.Value})
+				}
+			}
+		}
+
+		// The balance of a TX with invalid inputs is meaningless
+		if len(bad) == 0 {
+			issuance := in.issuance && tx.Founder == in.caller
+			mismatches = append(mismatches, checkBalance(i, tx, spent, issuance)...)
+		}
+	}
+	if in.issued != 0 {
+		in.amounts[""] = in.issued
+	}
+	if !founded {
+		mismatches = append(mismatches, &Mismatch{Kind: MismatchFounder, Tx: -1, Output: -1})
+	}
+
+	for _, token := range sortedKeys(in.amounts) {
+		amount := in.amounts[token]
+		actual, ok := received[token]
+		if (amount == 0 && !ok) || (amount != 0 && actual != amount) {
+			mismatches = append(mismatches, &Mismatch{Kind: MismatchAmount, Tx: -1, Output: -1, TokenId: token, Addr: recipientAddr, Expected: amount, Actual: actual})
+		}
+	}
+	if check.MaxFee != nil {
+		for _, token := range sortedKeys(fees) {
+			if max := check.MaxFee[token]; fees[token] > max {
+				mismatches = append(mismatches, &Mismatch{Kind: MismatchFee, Tx: -1, Output: -1, TokenId: token, Expected: max, Actual: fees[token]})
+			}
+		}
+	}
+
+	if len(mismatches) != 0 {
+		return &IntentError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+// checkBalance reports the tokens whose outputs of tx i do not add up
+// to the spent inputs. An issuance may output tokens it does not spend.
+func checkBalance(i int, tx *pw.TX, spent map[string]int64, issuance bool) (mismatches []*Mismatch) {
+	outputs := make(map[string]int64)
+	for _, txout := range tx.Txout {
+		outputs[txout.CTokenId] += txout.Value
+	}
+	tokens := make(map[string]int64, len(spent)+len(outputs))
+	for token := range spent {
+		tokens[token] = 0
+	}
+	for token := range outputs {
+		tokens[token] = 0
+	}
+	for _, token := range sortedKeys(tokens) {
+		if _, ok := spent[token]; !ok && issuance {
+			continue
+		}
+		if spent[token] != outputs[token] {
+			mismatches = append(mismatches, &Mismatch{Kind: MismatchBalance, Tx: i, Output: -1, TokenId: token, Expected: spent[token], Actual: outputs[token]})
+		}
+	}
+	return
+}
+
+// txInputs resolves the inputs of TXs against the unspent outputs of
+// their founders.
+type txInputs struct {
+	w      *WalletClient
+	ctx    context.Context
+	header http.Header
+	// utxos are the unspent outputs of the founders by input key.
+	utxos map[string]map[string]*pw.UTXO
+	spent map[string]bool
+}
+
+func inputKey(hash string, ix string) string {
+	return hash + ":" + ix
+}
+
+// resolve returns the value spent by the inputs of tx i by token id,
+// and reports the inputs not spending an unspent output of founderAddr
+// or spending an output already spent.
+func (t *txInputs) resolve(i int, tx *pw.TX, founderAddr string) (spent map[string]int64, mismatches []*Mismatch, err error) {
+	spent = make(map[string]int64)
+	if len(tx.Txin) == 0 {
+		return
+	}
+	utxos, err := t.unspent(tx.Founder)
+	if err != nil {
+		return
+	}
+	for j, txin := range tx.Txin {
+		key := inputKey(txin.SourceTxDataHash, strconv.Itoa(int(txin.Ix)))
+		utxo, ok := utxos[key]
+		if !ok || utxo.Addr != founderAddr || t.spent[key] {
+			mismatches = append(mismatches, &Mismatch{Kind: MismatchInput, Tx: i, Output: j, Addr: tx.Founder})
+			continue
+		}
+		t.spent[key] = true
+		spent[utxo.CTokenId] += utxo.Value
+	}
+	return
+}
+
+// unspent returns the unspent outputs of wallet id by input key.
+func (t *txInputs) unspent(id string) (utxos map[string]*pw.UTXO, err error) {
+	if utxos, ok := t.utxos[id]; ok {
+		return utxos, nil
+	}
+	utxos = make(map[string]*pw.UTXO)
+	for page := int32(1); ; page++ {
+		list, err := t.w.queryTransactionUTXO(t.ctx, t.header, did.Identifier(id), DefaultSweepPageSize, page)
+		if err != nil {
+			return nil, fmt.Errorf("query unspent outputs of %s error: %v", id, err)
+		}
+		for _, utxo := range list {
+			utxos[inputKey(utxo.SourceTxDataHash, utxo.Ix)] = utxo
+		}
+		if len(list) < DefaultSweepPageSize {
+			break
+		}
+	}
+	if t.utxos == nil {
+		t.utxos = make(map[string]map[string]*pw.UTXO)
+	}
+	t.utxos[id] = utxos
+	return
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
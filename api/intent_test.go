@@ -0,0 +1,336 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+// mockWalletInfo mocks the wallet info of id, queried any number of times.
+func mockWalletInfo(t *testing.T, id did.Identifier, endpoint did.DidEndpoint) {
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/info").
+		MatchParam("id", string(id)).
+		Persist().
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletInfo{Id: id, Endpoint: endpoint}))
+}
+
+// mockUTXOs mocks the unspent outputs of id, queried any number of times.
+func mockUTXOs(t *testing.T, id did.Identifier, utxos ...*pw.UTXO) {
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		MatchParam("id", string(id)).
+		Persist().
+		Reply(200).
+		JSON(jsonPayload(t, utxos))
+}
+
+// initIntentClient inits a wallet client checking intents with maxFee,
+// and mocks the wallet info and unspent outputs of the test wallets.
+func initIntentClient(t *testing.T, maxFee map[string]int64) *WalletClient {
+	w := newTestWalletClientWithConfig(t, enterpriseConfig(), WithIntentCheck(&IntentCheck{
+		MaxFee:   maxFee,
+		FeeAddrs: []string{"endpoint-fee"},
+	}))
+	mockWalletInfo(t, "did:axn:001", "endpoint-001")
+	mockWalletInfo(t, "did:axn:002", "endpoint-002")
+	mockWalletInfo(t, testPlatformDID, "endpoint-platform")
+	mockUTXOs(t, "did:axn:001",
+		&pw.UTXO{SourceTxDataHash: "utxo-a", Ix: "0", CTokenId: "token-a", Value: 1000, Addr: "endpoint-001"},
+		&pw.UTXO{SourceTxDataHash: "utxo-b", Ix: "1", CTokenId: "token-b", Value: 10, Addr: "endpoint-001"},
+		&pw.UTXO{SourceTxDataHash: "utxo-asset", Ix: "0", CTokenId: "asset-1", CType: 1, Value: 1, Addr: "endpoint-001"},
+	)
+	mockUTXOs(t, testPlatformDID,
+		&pw.UTXO{SourceTxDataHash: "utxo-fee", Ix: "0", CTokenId: "fee-token", Value: 100, Addr: "endpoint-platform"},
+		&pw.UTXO{SourceTxDataHash: "utxo-fee-a", Ix: "0", CTokenId: "token-a", Value: 1, Addr: "endpoint-platform"},
+	)
+	return w
+}
+
+// intentTx returns a TX founded by founder with outs.
+func intentTx(t *testing.T, founder string, outs ...*pw.TX_TXOUT) *pw.TX {
+	script, err := json.Marshal(&pw.UTXOSignature{PublicKey: []byte("public-key-" + founder)})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	tx := &pw.TX{Founder: founder}
+	for _, out := range outs {
+		out.Script = script
+		tx.Txout = append(tx.Txout, out)
+	}
+	return tx
+}
+
+// spending sets the inputs of tx, spending the outputs "hash:ix".
+func spending(tx *pw.TX, outputs ...string) *pw.TX {
+	for _, output := range outputs {
+		i := strings.LastIndex(output, ":")
+		ix, _ := strconv.Atoi(output[i+1:])
+		tx.Txin = append(tx.Txin, &pw.TX_TXIN{SourceTxDataHash: output[:i], Ix: int32(ix)})
+	}
+	return tx
+}
+
+func out(tokenID string, value int64, addr string) *pw.TX_TXOUT {
+	return &pw.TX_TXOUT{CTokenId: tokenID, Value: value, Addr: addr}
+}
+
+func mismatchKinds(t *testing.T, err error) []MismatchKind {
+	if err == nil {
+		return nil
+	}
+	ierr, ok := err.(*IntentError)
+	if !ok {
+		t.Fatalf("error should be an *IntentError not %v", err)
+	}
+	var kinds []MismatchKind
+	for _, m := range ierr.Mismatches {
+		kinds = append(kinds, m.Kind)
+	}
+	return kinds
+}
+
+func TestCheckIntent(t *testing.T) {
+	defer gock.Off()
+	w := initIntentClient(t, map[string]int64{"fee-token": 1})
+
+	transfer := &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-a", Amount: 30}, {TokenId: "token-a", Amount: 20}},
+	}
+	fee := spending(intentTx(t, testPlatformDID, out("fee-token", 1, "endpoint-fee"), out("fee-token", 99, "endpoint-platform")), "utxo-fee:0")
+	sender := func(outs ...*pw.TX_TXOUT) *pw.TX {
+		return spending(intentTx(t, "did:axn:001", outs...), "utxo-a:0")
+	}
+
+	cases := []struct {
+		name  string
+		body  interface{}
+		txs   []*pw.TX
+		kinds []MismatchKind
+	}{
+		{"transfer", transfer, []*pw.TX{
+			sender(out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001")),
+			fee,
+		}, nil},
+		{"transfer redirected", transfer, []*pw.TX{
+			sender(out("token-a", 50, "endpoint-intruder"), out("token-a", 950, "endpoint-001")),
+		}, []MismatchKind{MismatchRecipient, MismatchAmount}},
+		{"transfer wrong amount", transfer, []*pw.TX{
+			sender(out("token-a", 500, "endpoint-002"), out("token-a", 500, "endpoint-001")),
+		}, []MismatchKind{MismatchAmount}},
+		{"transfer unrequested token", transfer, []*pw.TX{
+			spending(intentTx(t, "did:axn:001", out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001"), out("token-b", 10, "endpoint-002")), "utxo-a:0", "utxo-b:1"),
+		}, []MismatchKind{MismatchToken}},
+		{"transfer without sender tx", transfer, []*pw.TX{
+			fee,
+		}, []MismatchKind{MismatchFounder, MismatchAmount}},
+		{"transfer fee too high", transfer, []*pw.TX{
+			sender(out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001")),
+			spending(intentTx(t, testPlatformDID, out("fee-token", 5, "endpoint-fee"), out("fee-token", 95, "endpoint-platform"), out("token-a", 1, "endpoint-fee")), "utxo-fee:0", "utxo-fee-a:0"),
+		}, []MismatchKind{MismatchFee, MismatchFee}},
+		{"transfer fee redirected", transfer, []*pw.TX{
+			sender(out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001")),
+			spending(intentTx(t, testPlatformDID, out("fee-token", 1, "endpoint-intruder"), out("fee-token", 99, "endpoint-platform")), "utxo-fee:0"),
+		}, []MismatchKind{MismatchRecipient}},
+		{"transfer spending foreign output", transfer, []*pw.TX{
+			spending(intentTx(t, "did:axn:001", out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001")), "utxo-a:0", "utxo-fee:0"),
+		}, []MismatchKind{MismatchInput}},
+		{"transfer spending output twice", transfer, []*pw.TX{
+			sender(out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001")),
+			spending(intentTx(t, "did:axn:001"), "utxo-a:0"),
+		}, []MismatchKind{MismatchInput}},
+		{"transfer burning change", transfer, []*pw.TX{
+			sender(out("token-a", 50, "endpoint-002"), out("token-a", 900, "endpoint-001")),
+		}, []MismatchKind{MismatchBalance}},
+		{"transfer without inputs", transfer, []*pw.TX{
+			intentTx(t, "did:axn:001", out("token-a", 50, "endpoint-002")),
+		}, []MismatchKind{MismatchBalance}},
+		{"issue", &sw.IssueBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "did:axn:poe-001", Amount: 1000}, []*pw.TX{
+			intentTx(t, "did:axn:001", out("token-new", 1000, "endpoint-002")),
+		}, nil},
+		{"issue to issuer", &sw.IssueBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "did:axn:poe-001", Amount: 1000}, []*pw.TX{
+			intentTx(t, "did:axn:001", out("token-new", 1000, "endpoint-002"), out("token-new", 1000, "endpoint-001")),
+		}, []MismatchKind{MismatchRecipient}},
+		{"issue two tokens", &sw.IssueBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "did:axn:poe-001", Amount: 1000}, []*pw.TX{
+			intentTx(t, "did:axn:001", out("token-new", 1000, "endpoint-002"), out("token-other", 1000, "endpoint-002")),
+		}, []MismatchKind{MismatchToken}},
+		{"issue nothing", &sw.IssueBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "did:axn:poe-001", Amount: 1000}, []*pw.TX{
+			intentTx(t, "did:axn:001"),
+		}, []MismatchKind{MismatchAmount}},
+		{"issue asset", &sw.IssueAssetBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "did:axn:poe-001"}, []*pw.TX{
+			intentTx(t, "did:axn:001", out("did:axn:poe-001", 1, "endpoint-002")),
+		}, nil},
+		{"transfer asset missing", &sw.TransferAssetBody{From: "did:axn:001", To: "did:axn:002", Assets: []string{"asset-1", "asset-2"}}, []*pw.TX{
+			spending(intentTx(t, "did:axn:001", out("asset-1", 1, "endpoint-002")), "utxo-asset:0"),
+		}, []MismatchKind{MismatchAmount}},
+	}
+	for _, c := range cases {
+		kinds := mismatchKinds(t, w.CheckIntent(nil, c.body, c.txs))
+		if !reflect.DeepEqual(kinds, c.kinds) {
+			t.Fatalf("%s: mismatches should be %v not %v", c.name, c.kinds, kinds)
+		}
+	}
+}
+
+func TestCheckIntentMismatchReport(t *testing.T) {
+	defer gock.Off()
+	w := initIntentClient(t, nil)
+
+	err := w.CheckIntent(nil, &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-a", Amount: 50}},
+	}, []*pw.TX{
+		spending(intentTx(t, testPlatformDID, out("fee-token", 100, "endpoint-fee")), "utxo-fee:0"),
+		spending(intentTx(t, "did:axn:001", out("token-a", 50, "endpoint-intruder"), out("token-a", 950, "endpoint-001")), "utxo-a:0"),
+	})
+	ierr, ok := err.(*IntentError)
+	if !ok {
+		t.Fatalf("error should be an *IntentError not %v", err)
+	}
+	expected := []*Mismatch{
+		{Kind: MismatchRecipient, Tx: 1, Output: 0, TokenId: "token-a", Addr: "endpoint-intruder", Actual: 50},
+		{Kind: MismatchAmount, Tx: -1, Output: -1, TokenId: "token-a", Addr: "endpoint-002", Expected: 50},
+	}
+	if !reflect.DeepEqual(ierr.Mismatches, expected) {
+		t.Fatalf("mismatches should be %+v not %+v", expected, ierr.Mismatches)
+	}
+	const msg = "prepared txs do not match the request: tx 1 output 0 sends 50 token-a to unexpected address endpoint-intruder; recipient receives 0 token-a instead of 50"
+	if err.Error() != msg {
+		t.Fatalf("error message should be %q not %q", msg, err.Error())
+	}
+}
+
+func TestTransferCTokenIntentMismatch(t *testing.T) {
+	defer gock.Off()
+	w := initIntentClient(t, nil)
+
+	reqBody := &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-a", Amount: 50}},
+	}
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+	redirected := intentTx(t, "did:axn:001", out("token-a", 50, "endpoint-intruder"))
+
+	//mock http request, process is not mocked and must not be sent
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{redirected}))
+
+	resp, err := w.TransferCToken(nil, reqBody, sign)
+	if kinds := mismatchKinds(t, err); len(kinds) == 0 {
+		t.Fatalf("redirected transfer should be rejected")
+	}
+	if resp != nil {
+		t.Fatalf("response should be nil when intent mismatch")
+	}
+	if txSigned(redirected) {
+		t.Fatalf("redirected tx should not be signed")
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("process request should not be sent")
+	}
+}
+
+func TestCheckIntentPinnedEndpoints(t *testing.T) {
+	defer gock.Off()
+
+	//the gateway substitutes its own address for the recipient's
+	mockWalletInfo(t, "did:axn:001", "endpoint-001")
+	mockWalletInfo(t, "did:axn:002", "endpoint-intruder")
+	mockUTXOs(t, "did:axn:001",
+		&pw.UTXO{SourceTxDataHash: "utxo-a", Ix: "0", CTokenId: "token-a", Value: 1000, Addr: "endpoint-001"},
+	)
+	transfer := &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-a", Amount: 50}},
+	}
+	redirected := []*pw.TX{
+		spending(intentTx(t, "did:axn:001", out("token-a", 50, "endpoint-intruder"), out("token-a", 950, "endpoint-001")), "utxo-a:0"),
+	}
+
+	w := newTestWalletClient(t, WithIntentCheck(nil))
+	if err := w.CheckIntent(nil, transfer, redirected); err != nil {
+		t.Fatalf("unpinned endpoints are trusted from the gateway: %v", err)
+	}
+
+	w = newTestWalletClient(t, WithIntentCheck(&IntentCheck{
+		Endpoints: map[did.Identifier]did.DidEndpoint{"did:axn:002": "endpoint-002"},
+	}))
+	kinds := mismatchKinds(t, w.CheckIntent(nil, transfer, redirected))
+	if !reflect.DeepEqual(kinds, []MismatchKind{MismatchRecipient, MismatchAmount}) {
+		t.Fatalf("transfer to a substituted endpoint should be rejected, got %v", kinds)
+	}
+
+	w = newTestWalletClient(t, WithIntentCheck(&IntentCheck{
+		Endpoints:  map[did.Identifier]did.DidEndpoint{"did:axn:002": "endpoint-002"},
+		PinnedOnly: true,
+	}))
+	err := w.CheckIntent(nil, transfer, redirected)
+	if err == nil || !strings.Contains(err.Error(), "did:axn:001 not pinned") {
+		t.Fatalf("unpinned wallet should be rejected, got %v", err)
+	}
+}
+
+func TestCheckIntentWalletNotFound(t *testing.T) {
+	defer gock.Off()
+
+	//the recipient wallet info is null
+	mockWalletInfo(t, "did:axn:001", "endpoint-001")
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/info").
+		MatchParam("id", "did:axn:002").
+		Reply(200).
+		JSON(jsonPayload(t, nil))
+	mockUTXOs(t, "did:axn:001",
+		&pw.UTXO{SourceTxDataHash: "utxo-a", Ix: "0", CTokenId: "token-a", Value: 1000, Addr: "endpoint-001"},
+	)
+	transfer := &sw.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*sw.TokenAmount{{TokenId: "token-a", Amount: 50}},
+	}
+	txs := []*pw.TX{
+		spending(intentTx(t, "did:axn:001", out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001")), "utxo-a:0"),
+	}
+
+	w := newTestWalletClient(t, WithIntentCheck(nil))
+	err := w.CheckIntent(nil, transfer, txs)
+	if err == nil || !strings.Contains(err.Error(), "did:axn:002 not found") {
+		t.Fatalf("unknown wallet should be rejected, got %v", err)
+	}
+}
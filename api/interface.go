@@ -0,0 +1,117 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"crypto/ed25519"
+	"net/http"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// Registrar registers wallets and queries their balance and information.
+//
+type Registrar interface {
+	Register(header http.Header, body *wallet.RegisterWalletBody) (*wallet.WalletResponse, error)
+	RegisterSubWallet(header http.Header, body *wallet.RegisterSubWalletBody) (*wallet.WalletResponse, error)
+	RegisterWithPublicKey(header http.Header, body *wallet.RegisterWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error)
+	RegisterSubWalletWithPublicKey(header http.Header, body *wallet.RegisterSubWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error)
+	GetWalletBalance(header http.Header, id did.Identifier) (*wallet.WalletBalance, error)
+	GetWalletInfo(header http.Header, id did.Identifier) (*wallet.WalletInfo, error)
+}
+
+// KeyManager hands the key pairs of wallets over to the safebox, and
+// rotates their keys by sweeping their funds to a new wallet.
+//
+type KeyManager interface {
+	TrusteeKeyPair(header http.Header, id did.Identifier, keyPair *wallet.KeyPair) (*wallet.WalletResponse, error)
+	RotateKey(header http.Header, body *RotateKeyBody, signParams *pki.SignatureParam) (*RotateKeyResponse, error)
+	SweepWallet(header http.Header, body *SweepBody, signParams *pki.SignatureParam) (*SweepResponse, error)
+}
+
+// POEManager creates, updates and queries POE digital assets, and
+// uploads their files.
+//
+type POEManager interface {
+	CreatePOE(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	UpdatePOE(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	QueryPOE(header http.Header, id did.Identifier) (*wallet.POEPayload, error)
+	UploadPOEFile(header http.Header, poeID string, poeFile string, readOnly bool) (*wallet.UploadResponse, error)
+}
+
+// TokenTransferer issues and transfers colored tokens and digital
+// assets, either in one call or step by step (proposal, signature and
+// processing), and queries transaction logs.
+//
+type TokenTransferer interface {
+	IssueCToken(header http.Header, body *wallet.IssueBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	IssueAsset(header http.Header, body *wallet.IssueAssetBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	TransferCToken(header http.Header, body *wallet.TransferCTokenBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	TransferAsset(header http.Header, body *wallet.TransferAssetBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	BatchTransferCToken(header http.Header, body *BatchTransferCTokenBody, signParams *pki.SignatureParam) (*BatchTransferCTokenResponse, error)
+	SendApprovalProposal(header http.Header, body *wallet.TransferCTokenBody, policy *ApprovalPolicy) (*ApprovalProposal, error)
+	SignApprovedTxs(header http.Header, proposal *ApprovalProposal, signParams *pki.SignatureParam) ([]*pw.TX, error)
+	PrepareSwap(header http.Header, body *SwapBody) (*Swap, error)
+	SignSwap(header http.Header, swap *Swap, body *SwapBody, signParams *pki.SignatureParam) error
+	SubmitSwap(header http.Header, swap *Swap) (*wallet.WalletResponse, error)
+	SubmitSwapSequential(header http.Header, swap *Swap) (*wallet.WalletResponse, error)
+
+	SendIssueCTokenProposal(header http.Header, body *wallet.IssueBody) (*wallet.IssueCTokenPrepareResponse, error)
+	SendIssueAssetProposal(header http.Header, body *wallet.IssueAssetBody) ([]*pw.TX, error)
+	SendTransferCTokenProposal(header http.Header, body *wallet.TransferCTokenBody) ([]*pw.TX, error)
+	SendTransferAssetProposal(header http.Header, body *wallet.TransferAssetBody) ([]*pw.TX, error)
+	EstimateFee(header http.Header, body interface{}) (*FeeEstimate, error)
+	CheckIntent(header http.Header, body interface{}, txs []*pw.TX) error
+	PlanSignTxs(header http.Header, txs []*pw.TX, signParams *pki.SignatureParam) ([]*TxSigner, error)
+	SignTxs(txs []*pw.TX, signParams *pki.SignatureParam) error
+	SignTx(tx *pw.TX, signParams *pki.SignatureParam) error
+	ProcessTx(header http.Header, txs []*pw.TX) (*wallet.WalletResponse, error)
+
+	QueryTransactionLogs(header http.Header, id did.Identifier, txType string, num, page int32) ([]*pw.UTXO, error)
+	QueryTransactionUTXO(header http.Header, id did.Identifier, num, page int32) ([]*pw.UTXO, error)
+	QueryTransactionSTXO(header http.Header, id did.Identifier, num, page int32) ([]*pw.UTXO, error)
+}
+
+// Indexer sets and queries the indexes of objects.
+//
+type Indexer interface {
+	IndexSet(header http.Header, body *wallet.IndexSetPayload) ([]string, error)
+	IndexGet(header http.Header, body *wallet.IndexGetPayload) ([]string, error)
+}
+
+// IWalletClient is the complete set of wallet service operations
+// provided by WalletClient.
+//
+// It is a superset of wallet.IWalletClient from sdk-go-common which
+// also covers the SDK-only methods, so that code depending on it can
+// be tested against a mock such as the one in the mock package.
+//
+type IWalletClient interface {
+	Registrar
+	KeyManager
+	POEManager
+	TokenTransferer
+	Indexer
+}
+
+var (
+	_ IWalletClient        = (*WalletClient)(nil)
+	_ wallet.IWalletClient = (*WalletClient)(nil)
+)
@@ -0,0 +1,184 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/arxanchain/sdk-go-common/structs/did"
+)
+
+// KeyCache caches the private keys returned by the safebox, so that
+// signing with a security code does not query the safebox every time.
+//
+// Keys are cached by DID and sha256 hash of the security code, for TTL
+// at most, and the least recently used key is evicted beyond MaxSize
+// keys. Evicted and purged keys are overwritten with zeros. Where the
+// platform supports it, each key is held in its own memory page locked
+// with mlock so that it is never swapped to disk.
+//
+// The key returned by a lookup is copied into the PrivateKey string of
+// the signature params, which is not zeroized.
+//
+type KeyCache struct {
+	ttl     time.Duration
+	maxSize int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type keyCacheEntry struct {
+	key     string
+	id      did.Identifier
+	secret  []byte
+	locked  bool
+	expires time.Time
+}
+
+// NewKeyCache returns a key cache keeping keys for ttl and at most
+// maxSize keys, maxSize 0 meaning no limit.
+//
+func NewKeyCache(ttl time.Duration, maxSize int) *KeyCache {
+	return &KeyCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// WithKeyCache caches the private keys queried from the safebox in c.
+//
+func WithKeyCache(c *KeyCache) Option {
+	return func(w *WalletClient) {
+		w.keyCache = c
+	}
+}
+
+// Len returns the number of cached keys, including expired keys not
+// evicted yet.
+//
+func (c *KeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Purge evicts every key.
+//
+func (c *KeyCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.lru.Len() != 0 {
+		c.remove(c.lru.Front())
+	}
+}
+
+// PurgeDID evicts the keys of id, whatever their security code.
+//
+func (c *KeyCache) PurgeDID(id did.Identifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(*keyCacheEntry).id == id {
+			c.remove(e)
+		}
+		e = next
+	}
+}
+
+// get returns the private key of id and securityCode, if cached and
+// not expired.
+func (c *KeyCache) get(id did.Identifier, securityCode string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[keyCacheKey(id, securityCode)]
+	if !ok {
+		return "", false
+	}
+	entry := e.Value.(*keyCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(e)
+		return "", false
+	}
+	c.lru.MoveToFront(e)
+	return string(entry.secret), true
+}
+
+// put caches the private key of id and securityCode.
+func (c *KeyCache) put(id did.Identifier, securityCode, privateKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := keyCacheKey(id, securityCode)
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	secret, locked := allocKey(len(privateKey))
+	copy(secret, privateKey)
+	c.entries[key] = c.lru.PushFront(&keyCacheEntry{
+		key:     key,
+		id:      id,
+		secret:  secret,
+		locked:  locked,
+		expires: c.now().Add(c.ttl),
+	})
+
+	// evict the least recently used keys, expired ones first
+	now := c.now()
+	for e := c.lru.Back(); e != nil; {
+		prev := e.Prev()
+		if !now.Before(e.Value.(*keyCacheEntry).expires) {
+			c.remove(e)
+		}
+		e = prev
+	}
+	for c.maxSize > 0 && c.lru.Len() > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove evicts e and zeroizes its key.
+func (c *KeyCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*keyCacheEntry)
+	delete(c.entries, entry.key)
+	for i := range entry.secret {
+		entry.secret[i] = 0
+	}
+	freeKey(entry.secret, entry.locked)
+	entry.secret = nil
+}
+
+// keyCacheKey returns the cache key of id and securityCode, the
+// security code itself is not kept.
+func keyCacheKey(id did.Identifier, securityCode string) string {
+	sum := sha256.Sum256([]byte(securityCode))
+	return string(id) + "/" + hex.EncodeToString(sum[:])
+}
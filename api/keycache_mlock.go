@@ -0,0 +1,53 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"syscall"
+)
+
+// allocKey returns n bytes for a cached key in their own anonymous
+// memory mapping locked with mlock, or on the heap if the mapping or
+// the lock fails, such as beyond RLIMIT_MEMLOCK. locked reports
+// whether the key is mapped and locked.
+func allocKey(n int) (b []byte, locked bool) {
+	if n == 0 {
+		return nil, false
+	}
+	b, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, n), false
+	}
+	if err = syscall.Mlock(b); err != nil {
+		syscall.Munmap(b)
+		return make([]byte, n), false
+	}
+	return b, true
+}
+
+// freeKey releases a key returned by allocKey, already zeroized. Heap
+// allocated keys are left to the garbage collector.
+var freeKey = func(b []byte, locked bool) {
+	if !locked {
+		return
+	}
+	syscall.Munlock(b)
+	syscall.Munmap(b)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/rest/api"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+// newTestKeyCache returns a key cache with a settable clock, recording
+// the keys it frees.
+func newTestKeyCache(t *testing.T, ttl time.Duration, maxSize int) (*KeyCache, *time.Time, *[][]byte) {
+	now := time.Unix(1500000000, 0)
+	c := NewKeyCache(ttl, maxSize)
+	c.now = func() time.Time { return now }
+
+	var freed [][]byte
+	free := freeKey
+	freeKey = func(b []byte, locked bool) {
+		freed = append(freed, append([]byte(nil), b...))
+		free(b, locked)
+	}
+	t.Cleanup(func() { freeKey = free })
+	return c, &now, &freed
+}
+
+func zeroed(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestKeyCache(t *testing.T) {
+	c, now, freed := newTestKeyCache(t, time.Minute, 2)
+
+	c.put("did:axn:001", "code-1", "private-key-1")
+	if key, ok := c.get("did:axn:001", "code-1"); !ok || key != "private-key-1" {
+		t.Fatalf("cached key should be returned: %q %v", key, ok)
+	}
+	if _, ok := c.get("did:axn:001", "code-2"); ok {
+		t.Fatalf("key should not be returned for another security code")
+	}
+
+	// the least recently used key is evicted beyond the max size
+	c.put("did:axn:002", "code", "private-key-2")
+	c.get("did:axn:001", "code-1")
+	c.put("did:axn:003", "code", "private-key-3")
+	if c.Len() != 2 {
+		t.Fatalf("cache should hold 2 keys not %d", c.Len())
+	}
+	if _, ok := c.get("did:axn:002", "code"); ok {
+		t.Fatalf("least recently used key should be evicted")
+	}
+	if len(*freed) != 1 || len((*freed)[0]) != len("private-key-2") || !zeroed((*freed)[0]) {
+		t.Fatalf("evicted key should be zeroized: %q", *freed)
+	}
+
+	// keys expire after the ttl
+	*now = now.Add(time.Minute)
+	if _, ok := c.get("did:axn:001", "code-1"); ok {
+		t.Fatalf("expired key should not be returned")
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expired key should be evicted on lookup")
+	}
+
+	c.put("did:axn:001", "code-1", "private-key-1")
+	c.put("did:axn:001", "code-2", "private-key-1")
+	c.PurgeDID("did:axn:001")
+	if c.Len() != 0 {
+		t.Fatalf("keys of did:axn:001 should be purged, %d left", c.Len())
+	}
+	c.put("did:axn:004", "code", "private-key-4")
+	c.Purge()
+	if c.Len() != 0 {
+		t.Fatalf("every key should be purged")
+	}
+	for _, b := range *freed {
+		if !zeroed(b) {
+			t.Fatalf("purged keys should be zeroized: %q", *freed)
+		}
+	}
+}
+
+func TestKeyCacheQueryPrivateKey(t *testing.T) {
+	const securityCode = "security-code-001"
+
+	cache := NewKeyCache(time.Minute, 10)
+	w := newTestWalletClientWithConfig(t, &api.Config{TrusteeKeyPairEnable: true}, WithKeyCache(cache))
+	defer gock.Off()
+
+	//the safebox is queried once
+	gock.New("http://127.0.0.1:8006").
+		Post("/").
+		AddMatcher(matchBodyContains(securityCode)).
+		Times(1).
+		Reply(200).
+		JSON(jsonPayload(t, map[string]string{"private_key": testPrivateKey}))
+	signer := map[string]*pki.SignatureParam{
+		"did:axn:001": {Creator: "did:axn:001", Nonce: "helloalice", PrivateKey: testPrivateKey},
+	}
+	for i := 0; i < 2; i++ {
+		gock.New("http://127.0.0.1:8006").
+			Post("/v2/transaction/tokens/transfer/prepare").
+			Reply(200).
+			JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 1)}))
+		gock.New("http://127.0.0.1:8006").
+			Post("/v2/transaction/process").
+			AddMatcher(matchSignedTxs(signer)).
+			Reply(200).
+			JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"trans-id-001"}}))
+	}
+
+	body := &sw.TransferCTokenBody{From: "did:axn:001", To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}}}
+	for i := 0; i < 2; i++ {
+		_, err := w.TransferCToken(http.Header{}, body, &pki.SignatureParam{
+			Creator:      "did:axn:001",
+			Nonce:        "helloalice",
+			SecurityCode: securityCode,
+		})
+		if err != nil {
+			t.Fatalf("transfer %d with cached key fail: %v", i, err)
+		}
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending mocks: %v", gock.Pending())
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("cache should hold the key")
+	}
+
+	//another security code is not served from the cache
+	gock.New("http://127.0.0.1:8006").
+		Post("/").
+		AddMatcher(matchBodyContains("wrong-code")).
+		Reply(500).
+		BodyString("security code invalid")
+	_, err := w.TransferCToken(http.Header{}, body, &pki.SignatureParam{
+		Creator:      "did:axn:001",
+		Nonce:        "helloalice",
+		SecurityCode: "wrong-code",
+	})
+	if err == nil {
+		t.Fatalf("transfer with an uncached security code should query the safebox and fail")
+	}
+	if !gock.IsDone() {
+		t.Fatalf("safebox should be queried for another security code")
+	}
+}
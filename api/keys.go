@@ -0,0 +1,229 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/arxanchain/sdk-go-common/errors"
+	"github.com/arxanchain/sdk-go-common/rest"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/sdk-go-common/utils"
+)
+
+// KeyPair is an ed25519 key pair generated locally, whose private key
+// never leaves the caller.
+//
+type KeyPair struct {
+	PublicKey  ed25519.PublicKey
+	PrivateKey ed25519.PrivateKey
+}
+
+// GenerateKeyPair generates an ed25519 key pair from crypto/rand.
+//
+func GenerateKeyPair() (*KeyPair, error) {
+	return GenerateKeyPairFrom(rand.Reader)
+}
+
+// GenerateKeyPairFrom generates an ed25519 key pair from the entropy
+// read from r.
+//
+func GenerateKeyPairFrom(r io.Reader) (*KeyPair, error) {
+	public, private, err := ed25519.GenerateKey(r)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{PublicKey: public, PrivateKey: private}, nil
+}
+
+// EncodedPublicKey returns the base64 encoded public key, as sent to
+// and returned by the wallet platform.
+//
+func (k *KeyPair) EncodedPublicKey() string {
+	return utils.EncodeBase64(k.PublicKey)
+}
+
+// EncodedPrivateKey returns the base64 encoded private key, as expected
+// by the PrivateKey of pki.SignatureParam.
+//
+func (k *KeyPair) EncodedPrivateKey() string {
+	return utils.EncodeBase64(k.PrivateKey)
+}
+
+// SignParam returns the signature params of creator signing with the
+// private key.
+//
+func (k *KeyPair) SignParam(creator did.Identifier, nonce string) *pki.SignatureParam {
+	return &pki.SignatureParam{
+		Creator:    creator,
+		Nonce:      nonce,
+		PrivateKey: k.EncodedPrivateKey(),
+	}
+}
+
+// PublicKeyError reports a wallet the platform registered without
+// binding it to the caller's public key. The wallet exists: the caller
+// can recover it from Id, with the key pair the platform returned if
+// any, or have it disabled.
+//
+type PublicKeyError struct {
+	Id did.Identifier
+	// KeyPair is the key pair returned by the platform, nil if none.
+	KeyPair *wallet.KeyPair
+	Reason  string
+}
+
+func (e *PublicKeyError) Error() string {
+	return fmt.Sprintf("wallet %s registered %s", e.Id, e.Reason)
+}
+
+// registerWithPublicKeyBody is the register request body carrying the
+// public key of a key pair generated by the caller.
+type registerWithPublicKeyBody struct {
+	*wallet.RegisterWalletBody
+	PublicKey string `json:"public_key"`
+}
+
+// registerSubWalletWithPublicKeyBody is the register sub wallet request
+// body carrying the public key of a key pair generated by the caller.
+type registerSubWalletWithPublicKeyBody struct {
+	*wallet.RegisterSubWalletBody
+	PublicKey string `json:"public_key"`
+}
+
+// RegisterWithPublicKey is used to register user wallet with the public
+// key of a key pair generated by the caller, see GenerateKeyPair.
+//
+// Only the public key is sent, the platform neither generates nor
+// returns a private key, and nothing is trusteed to the safebox. The
+// wallet is then signed for with the caller's KeyPair.SignParam.
+//
+// The registration fails unless the platform echoes the public key the
+// wallet is bound to, and if it responds with a private key or with
+// another public key. The wallet is then registered all the same, the
+// error is a *PublicKeyError carrying its id.
+//
+func (w *WalletClient) RegisterWithPublicKey(header http.Header, body *wallet.RegisterWalletBody, publicKey ed25519.PublicKey) (result *wallet.WalletResponse, err error) {
+	return w.registerWithPublicKey(w.parentContext(), header, body, publicKey)
+}
+
+func (w *WalletClient) registerWithPublicKey(ctx context.Context, header http.Header, body *wallet.RegisterWalletBody, publicKey ed25519.PublicKey) (result *wallet.WalletResponse, err error) {
+	op := w.begin(ctx, "RegisterWithPublicKey")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
+		return
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		err = fmt.Errorf("public key invalid")
+		return
+	}
+
+	return w.registerPublicKey(&Call{
+		Context: op.ctx,
+		Name:    "Register",
+		Method:  "POST",
+		Path:    "/v1/wallet/register",
+		Header:  header,
+		Body: &registerWithPublicKeyBody{
+			RegisterWalletBody: body,
+			PublicKey:          utils.EncodeBase64(publicKey),
+		},
+	}, publicKey)
+}
+
+// RegisterSubWalletWithPublicKey is used to register user subwallet
+// with the public key of a key pair generated by the caller, like
+// RegisterWithPublicKey.
+//
+func (w *WalletClient) RegisterSubWalletWithPublicKey(header http.Header, body *wallet.RegisterSubWalletBody, publicKey ed25519.PublicKey) (result *wallet.WalletResponse, err error) {
+	op := w.begin(w.parentContext(), "RegisterSubWalletWithPublicKey")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
+		return
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		err = fmt.Errorf("public key invalid")
+		return
+	}
+
+	return w.registerPublicKey(&Call{
+		Context: op.ctx,
+		Name:    "RegisterSubWallet",
+		Method:  "POST",
+		Path:    "/v1/wallet/register/subwallet",
+		Header:  header,
+		Body: &registerSubWalletWithPublicKeyBody{
+			RegisterSubWalletBody: body,
+			PublicKey:             utils.EncodeBase64(publicKey),
+		},
+	}, publicKey)
+}
+
+func (w *WalletClient) registerPublicKey(call *Call, publicKey ed25519.PublicKey) (result *wallet.WalletResponse, err error) {
+	err = w.invoke(call)
+	if err != nil {
+		return
+	}
+
+	// Parse http response
+	respBody := call.Response
+
+	if respBody.ErrCode != errors.SuccCode {
+		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
+		return
+	}
+
+	payload, ok := respBody.Payload.(string)
+	if !ok {
+		err = fmt.Errorf("response payload type invalid: %v", reflect.TypeOf(respBody.Payload))
+		return
+	}
+
+	if err = json.Unmarshal([]byte(payload), &result); err != nil {
+		return
+	}
+	if result == nil {
+		return nil, fmt.Errorf("response payload invalid")
+	}
+
+	// The platform must keep the caller's key pair, and confirm the
+	// binding of the wallet to its public key
+	reason := ""
+	switch {
+	case result.KeyPair == nil || result.KeyPair.PublicKey == "":
+		reason = "without confirming the public key"
+	case result.KeyPair.PrivateKey != "":
+		reason = "with a private key generated by the platform"
+	case result.KeyPair.PublicKey != utils.EncodeBase64(publicKey):
+		reason = "with another public key"
+	}
+	if reason != "" {
+		return nil, &PublicKeyError{Id: result.Id, KeyPair: result.KeyPair, Reason: reason}
+	}
+	return
+}
@@ -0,0 +1,216 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/sdk-go-common/utils"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+// echoPublicKey is a gock response mapper answering a registration
+// with the wallet id and the public key sent in the request, as the
+// platform does.
+func echoPublicKey(t *testing.T, id did.Identifier) gock.MapResponseFunc {
+	return func(res *http.Response) *http.Response {
+		var body struct {
+			PublicKey string `json:"public_key"`
+		}
+		if err := json.NewDecoder(res.Request.Body).Decode(&body); err != nil {
+			t.Fatalf("%v", err)
+		}
+		data, err := json.Marshal(jsonPayload(t, &wallet.WalletResponse{
+			Id:      id,
+			KeyPair: &wallet.KeyPair{PublicKey: body.PublicKey},
+		}))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(data))
+		return res
+	}
+}
+
+func TestGenerateKeyPair(t *testing.T) {
+	key, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("generate key pair fail: %v", err)
+	}
+	if len(key.PublicKey) != ed25519.PublicKeySize || len(key.PrivateKey) != ed25519.PrivateKeySize {
+		t.Fatalf("key pair size invalid")
+	}
+	if !bytes.Equal(key.PrivateKey.Public().(ed25519.PublicKey), key.PublicKey) {
+		t.Fatalf("public key should match the private key")
+	}
+
+	seed := bytes.Repeat([]byte{7}, ed25519.SeedSize)
+	a, _ := GenerateKeyPairFrom(bytes.NewReader(seed))
+	b, _ := GenerateKeyPairFrom(bytes.NewReader(seed))
+	if !bytes.Equal(a.PrivateKey, b.PrivateKey) {
+		t.Fatalf("key pairs generated from the same entropy should be equal")
+	}
+	if _, err = GenerateKeyPairFrom(bytes.NewReader(nil)); err == nil {
+		t.Fatalf("generate key pair without entropy should fail")
+	}
+
+	signParam := a.SignParam("did:axn:001", "nonce")
+	decoded, err := utils.DecodeBase64(signParam.PrivateKey)
+	if err != nil || !bytes.Equal([]byte(decoded), a.PrivateKey) {
+		t.Fatalf("sign param private key should be the encoded private key")
+	}
+}
+
+func TestRegisterWithPublicKeySucc(t *testing.T) {
+	//trusteeship enabled, the safebox must not be called
+	initWalletClientWithTrustKeypair(t)
+	defer gock.Off()
+
+	key, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		AddMatcher(matchBodyContains(`"public_key":"` + key.EncodedPublicKey() + `"`)).
+		AddMatcher(matchBodyContains(`"access":"alice"`)).
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{
+			Id:       "did:axn:001",
+			Endpoint: "endpoint-001",
+			KeyPair:  &wallet.KeyPair{PublicKey: key.EncodedPublicKey()},
+		}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register/subwallet").
+		AddMatcher(matchBodyContains(`"public_key":"` + key.EncodedPublicKey() + `"`)).
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{
+			Id:       "did:axn:002",
+			Endpoint: "endpoint-002",
+			KeyPair:  &wallet.KeyPair{PublicKey: key.EncodedPublicKey()},
+		}))
+
+	client := walletClient.(*WalletClient)
+	resp, err := client.RegisterWithPublicKey(http.Header{}, &wallet.RegisterWalletBody{
+		Type:   pw.DidType_ORGANIZATION,
+		Access: "alice",
+		Secret: "123456",
+	}, key.PublicKey)
+	if err != nil {
+		t.Fatalf("register wallet with public key fail: %v", err)
+	}
+	if resp.Id != "did:axn:001" || resp.SecurityCode != "" || resp.KeyPair.PrivateKey != "" {
+		t.Fatalf("response invalid: %+v", resp)
+	}
+
+	resp, err = client.RegisterSubWalletWithPublicKey(http.Header{}, &wallet.RegisterSubWalletBody{
+		Id:   "did:axn:001",
+		Type: pw.DidType_ORGANIZATION,
+	}, key.PublicKey)
+	if err != nil {
+		t.Fatalf("register sub wallet with public key fail: %v", err)
+	}
+	if resp.Id != "did:axn:002" {
+		t.Fatalf("response invalid: %+v", resp)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending mocks: %v", gock.Pending())
+	}
+}
+
+func TestRegisterWithPublicKeyFail(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	key, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	other, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	body := &wallet.RegisterWalletBody{
+		Type:   pw.DidType_ORGANIZATION,
+		Access: "alice",
+		Secret: "123456",
+	}
+	client := walletClient.(*WalletClient)
+
+	//invalid public key, no request is sent
+	if _, err = client.RegisterWithPublicKey(http.Header{}, body, key.PublicKey[:16]); err == nil {
+		t.Fatalf("register with an invalid public key should fail")
+	}
+
+	cases := []struct {
+		name    string
+		keyPair *wallet.KeyPair
+		errMsg  string
+	}{
+		{
+			name:    "private key returned",
+			keyPair: &wallet.KeyPair{PrivateKey: other.EncodedPrivateKey(), PublicKey: other.EncodedPublicKey()},
+			errMsg:  "private key generated by the platform",
+		},
+		{
+			name:    "no key pair",
+			keyPair: nil,
+			errMsg:  "without confirming the public key",
+		},
+		{
+			name:    "no public key",
+			keyPair: &wallet.KeyPair{},
+			errMsg:  "without confirming the public key",
+		},
+		{
+			name:    "other public key",
+			keyPair: &wallet.KeyPair{PublicKey: other.EncodedPublicKey()},
+			errMsg:  "another public key",
+		},
+	}
+	for _, c := range cases {
+		gock.New("http://127.0.0.1:8006").
+			Post("/v1/wallet/register").
+			Reply(200).
+			JSON(jsonPayload(t, &wallet.WalletResponse{Id: "did:axn:001", KeyPair: c.keyPair}))
+
+		resp, err := client.RegisterWithPublicKey(http.Header{}, body, key.PublicKey)
+		if err == nil || !strings.Contains(err.Error(), c.errMsg) {
+			t.Fatalf("%s: error should contain %q, got %v", c.name, c.errMsg, err)
+		}
+		var keyErr *PublicKeyError
+		if !errors.As(err, &keyErr) || keyErr.Id != "did:axn:001" || !reflect.DeepEqual(keyErr.KeyPair, c.keyPair) {
+			t.Fatalf("%s: error should carry the registered wallet, got %#v", c.name, err)
+		}
+		if resp != nil {
+			t.Fatalf("%s: response should be nil", c.name)
+		}
+	}
+}
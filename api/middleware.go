@@ -0,0 +1,198 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	restapi "github.com/arxanchain/sdk-go-common/rest/api"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+)
+
+// Call describes one request/response cycle between the wallet client
+// and the wallet platform.
+//
+// Middlewares may inspect or modify the request fields before calling
+// the next handler, and inspect the decoded Response after it returns.
+//
+type Call struct {
+	// Context carries the trace of the WalletClient method issuing
+	// the call, it is never nil.
+	Context context.Context
+
+	// Name is the name of the WalletClient method issuing the call,
+	// e.g. "Register" or "SendTransferCTokenProposal".
+	Name string
+
+	// Method and Path identify the platform endpoint, e.g. "POST"
+	// and "/v1/wallet/register".
+	Method string
+	Path   string
+
+	// Header is a copy of the http header supplied by the caller,
+	// middlewares may change it.
+	Header http.Header
+
+	// Params holds the URL query parameters of the request.
+	Params map[string]string
+
+	// ContentType overrides the request Content-Type if set.
+	ContentType string
+
+	// Body is the request body, it is JSON encoded unless it is a []byte.
+	Body interface{}
+
+	// Response is the decoded platform response. It is set by the
+	// innermost handler once the http round trip succeeded.
+	Response *rtstructs.Response
+}
+
+// SetParam sets the URL query parameter key to value.
+//
+func (c *Call) SetParam(key, value string) {
+	if c.Params == nil {
+		c.Params = make(map[string]string)
+	}
+	c.Params[key] = value
+}
+
+// Handler performs a Call against the wallet platform.
+//
+type Handler func(call *Call) error
+
+// Middleware wraps a Handler with additional behaviour, such as
+// refreshing auth headers, tracing, audit logging or fault injection.
+//
+// A Middleware must call next to continue the chain, or return
+// without calling it to short-circuit the request. A short-circuiting
+// middleware returns an error or sets call.Response, the call fails
+// otherwise.
+//
+type Middleware func(next Handler) Handler
+
+// Use appends middlewares to the client's chain. The first middleware
+// registered is the outermost one.
+//
+// Use is not safe for concurrent use with in-flight requests, it should
+// be called right after NewWalletClient.
+//
+func (w *WalletClient) Use(mws ...Middleware) {
+	w.middlewares = append(w.middlewares, mws...)
+}
+
+// invoke runs the call through the middleware chain.
+func (w *WalletClient) invoke(call *Call) error {
+	if call.Context == nil {
+		call.Context = w.parentContext()
+	}
+	// Middlewares may set headers, never on the caller's header
+	call.Header = cloneHeader(call.Header)
+	if call.Header == nil {
+		call.Header = http.Header{}
+	}
+
+	h := w.handler()
+	for i := len(w.middlewares) - 1; i >= 0; i-- {
+		h = w.middlewares[i](h)
+	}
+	err := h(call)
+	if err == nil && call.Response == nil {
+		err = fmt.Errorf("middleware returned no response for %s", call.Name)
+	}
+	w.telemetry.recordResponse(call)
+
+	return err
+}
+
+// do is the innermost handler, it sends the http request and decodes
+// the platform response into call.Response.
+func (w *WalletClient) do(call *Call) error {
+	r := w.c.NewRequest(call.Method, call.Path)
+	r.SetHeaders(call.Header)
+	if call.ContentType != "" {
+		r.SetHeader("Content-Type", call.ContentType)
+	}
+	for key, value := range call.Params {
+		r.SetParam(key, value)
+	}
+	if call.Body != nil {
+		r.SetBody(call.Body)
+	}
+
+	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var respBody rtstructs.Response
+	if err = restapi.DecodeBody(resp, &respBody); err != nil {
+		return err
+	}
+	call.Response = &respBody
+
+	return nil
+}
+
+// SafeboxEvent describes a safebox operation performed by a WalletClient,
+// such as trusteeing a newly registered key pair or looking up the
+// private key of a signer.
+//
+type SafeboxEvent struct {
+	// Context carries the trace of the WalletClient method.
+	Context context.Context
+
+	// Operation is either "TrusteeKeyPair" or "QueryPrivateKey".
+	Operation string
+
+	// DID is the wallet whose key pair is stored or queried.
+	DID did.Identifier
+
+	// Err is the error returned by the safebox service, if any.
+	Err error
+}
+
+// SafeboxHook is called after every safebox operation.
+//
+type SafeboxHook func(event *SafeboxEvent)
+
+// OnSafebox registers hooks called after every safebox operation.
+//
+// OnSafebox is not safe for concurrent use with in-flight requests, it
+// should be called right after NewWalletClient.
+//
+func (w *WalletClient) OnSafebox(hooks ...SafeboxHook) {
+	w.safeboxHooks = append(w.safeboxHooks, hooks...)
+}
+
+func (w *WalletClient) notifySafebox(ctx context.Context, operation string, id did.Identifier, err error) {
+	if len(w.safeboxHooks) == 0 {
+		return
+	}
+	event := &SafeboxEvent{
+		Context:   ctx,
+		Operation: operation,
+		DID:       id,
+		Err:       err,
+	}
+	for _, hook := range w.safeboxHooks {
+		hook(event)
+	}
+}
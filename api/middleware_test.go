@@ -0,0 +1,271 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/rest/api"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+// newTestWalletClient returns a client of the mocked wallet platform
+// built with opts.
+func newTestWalletClient(t *testing.T, opts ...Option) *WalletClient {
+	return newTestWalletClientWithConfig(t, &api.Config{}, opts...)
+}
+
+// newTestWalletClientWithConfig returns a client of the mocked wallet
+// platform built from config, whose address and http client are set.
+func newTestWalletClientWithConfig(t *testing.T, config *api.Config, opts ...Option) *WalletClient {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	config.Address = "http://127.0.0.1:8006"
+	config.HttpClient = client
+	c, err := NewWalletClient(config, opts...)
+	if err != nil {
+		t.Fatalf("New wallet client fail: %v", err)
+	}
+	return c
+}
+
+func TestMiddlewareOrderAndCallInfo(t *testing.T) {
+	//init gock & walletclient
+	client := newTestWalletClient(t)
+	defer gock.Off()
+
+	const (
+		id    = "did:axn:001"
+		token = "refreshed-token"
+	)
+
+	payload := &wallet.WalletResponse{Id: id}
+	byPayload, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	respBody := &rtstructs.Response{
+		ErrCode: 0,
+		Payload: string(byPayload),
+	}
+
+	//mock http request, only matches if the middleware injected the header
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		MatchHeader("X-Auth-Token", token).
+		Reply(200).
+		JSON(respBody)
+
+	var trace []string
+	var seen *Call
+	client.Use(
+		func(next Handler) Handler {
+			return func(call *Call) error {
+				trace = append(trace, "outer-before")
+				err := next(call)
+				trace = append(trace, "outer-after")
+				seen = call
+				return err
+			}
+		},
+		func(next Handler) Handler {
+			return func(call *Call) error {
+				trace = append(trace, "inner-before")
+				call.Header.Set("X-Auth-Token", token)
+				err := next(call)
+				trace = append(trace, "inner-after")
+				return err
+			}
+		},
+	)
+
+	reqBody := &wallet.RegisterWalletBody{
+		Type:   pw.DidType_ORGANIZATION,
+		Access: "alice",
+		Secret: "123456",
+	}
+	resp, err := client.Register(http.Header{}, reqBody)
+	if err != nil {
+		t.Fatalf("register wallet fail: %v", err)
+	}
+	if resp.Id != id {
+		t.Fatalf("wallet id should be %v", id)
+	}
+
+	expected := []string{"outer-before", "inner-before", "inner-after", "outer-after"}
+	if !reflect.DeepEqual(trace, expected) {
+		t.Fatalf("middleware order should be %v, not %v", expected, trace)
+	}
+	if seen == nil {
+		t.Fatalf("middleware should see the call")
+	}
+	if seen.Name != "Register" {
+		t.Fatalf("call name should be Register, not %v", seen.Name)
+	}
+	if seen.Method != "POST" || seen.Path != "/v1/wallet/register" {
+		t.Fatalf("call endpoint should be POST /v1/wallet/register, not %v %v", seen.Method, seen.Path)
+	}
+	if seen.Body != reqBody {
+		t.Fatalf("call body should be the request payload")
+	}
+	if seen.Response == nil || seen.Response.Payload != string(byPayload) {
+		t.Fatalf("call response should be the decoded platform response")
+	}
+}
+
+func TestMiddlewareFaultInjection(t *testing.T) {
+	//init gock & walletclient
+	client := newTestWalletClient(t)
+	defer gock.Off()
+
+	const errMsg = "injected fault"
+
+	client.Use(func(next Handler) Handler {
+		return func(call *Call) error {
+			return fmt.Errorf(errMsg)
+		}
+	})
+
+	resp, err := client.GetWalletBalance(http.Header{}, "did:axn:001")
+	if err == nil {
+		t.Fatalf("get wallet balance should fail")
+	}
+	if !strings.Contains(err.Error(), errMsg) {
+		t.Fatalf("error message should be return [%s]", errMsg)
+	}
+	if resp != nil {
+		t.Fatalf("wallet balance should be nil")
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("no http request should be sent")
+	}
+}
+
+func TestMiddlewareInjectResponse(t *testing.T) {
+	//init gock & walletclient
+	client := newTestWalletClient(t)
+	defer gock.Off()
+
+	const (
+		errCode = 5015
+		errMsg  = "BalancesNotSufficient"
+	)
+
+	client.Use(func(next Handler) Handler {
+		return func(call *Call) error {
+			if call.Params["id"] != "did:axn:001" {
+				return fmt.Errorf("unexpected params: %v", call.Params)
+			}
+			call.Response = &rtstructs.Response{
+				ErrCode:    errCode,
+				ErrMessage: errMsg,
+			}
+			return nil
+		}
+	})
+
+	_, err := client.GetWalletInfo(http.Header{}, "did:axn:001")
+	if err == nil {
+		t.Fatalf("get wallet info should fail")
+	}
+	if err.Error() != errMsg {
+		t.Fatalf("error message should be %s, not %v", errMsg, err)
+	}
+}
+
+func TestMiddlewareHeaderIsCopied(t *testing.T) {
+	//init gock & walletclient
+	client := newTestWalletClient(t)
+	defer gock.Off()
+
+	payload := &wallet.WalletResponse{Id: "did:axn:001"}
+	byPayload, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	respBody := &rtstructs.Response{
+		ErrCode: 0,
+		Payload: string(byPayload),
+	}
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		Times(2).
+		MatchHeader("X-Auth-Token", "injected").
+		Reply(200).
+		JSON(respBody)
+
+	client.Use(func(next Handler) Handler {
+		return func(call *Call) error {
+			call.Header.Set("X-Auth-Token", "injected")
+			return next(call)
+		}
+	})
+
+	reqBody := &wallet.RegisterWalletBody{
+		Type:   pw.DidType_ORGANIZATION,
+		Access: "alice",
+		Secret: "123456",
+	}
+	header := http.Header{}
+	if _, err := client.Register(header, reqBody); err != nil {
+		t.Fatalf("register wallet fail: %v", err)
+	}
+	if len(header) != 0 {
+		t.Fatalf("caller header should not be changed, got %v", header)
+	}
+
+	//a nil header is valid
+	if _, err := client.Register(nil, reqBody); err != nil {
+		t.Fatalf("register wallet with nil header fail: %v", err)
+	}
+}
+
+func TestMiddlewareShortCircuitWithoutResponse(t *testing.T) {
+	//init gock & walletclient
+	client := newTestWalletClient(t)
+	defer gock.Off()
+
+	client.Use(func(next Handler) Handler {
+		return func(call *Call) error {
+			return nil
+		}
+	})
+
+	resp, err := client.GetWalletInfo(http.Header{}, "did:axn:001")
+	if err == nil {
+		t.Fatalf("get wallet info should fail without response")
+	}
+	if !strings.Contains(err.Error(), "no response for GetWalletInfo") {
+		t.Fatalf("error should report the missing response, not %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("wallet info should be nil")
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("no http request should be sent")
+	}
+}
@@ -0,0 +1,29 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+// Option configures optional behaviour of a WalletClient.
+//
+type Option func(*WalletClient)
+
+// WithMiddleware appends middlewares to the client's chain, see Use.
+//
+func WithMiddleware(mws ...Middleware) Option {
+	return func(w *WalletClient) {
+		w.Use(mws...)
+	}
+}
@@ -31,8 +31,6 @@ import (
 
 	"github.com/arxanchain/sdk-go-common/errors"
 	"github.com/arxanchain/sdk-go-common/rest"
-	restapi "github.com/arxanchain/sdk-go-common/rest/api"
-	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
 	"github.com/arxanchain/sdk-go-common/structs/did"
 	"github.com/arxanchain/sdk-go-common/structs/pki"
 	"github.com/arxanchain/sdk-go-common/structs/wallet"
@@ -47,13 +45,16 @@ import (
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) CreatePOE(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "CreatePOE")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
+	op.setAttributes(AttrOwner.String(string(body.Owner)))
 
 	if w.s != nil {
-		signParams, err = w.queryPrivateKey(header, signParams)
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
 		if err != nil {
 			return
 		}
@@ -70,28 +71,29 @@ func (w *WalletClient) CreatePOE(header http.Header, body *wallet.POEBody, signP
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v1/poe/create")
-	r.SetHeaders(header)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "CreatePOE",
+		Method:  "POST",
+		Path:    "/v1/poe/create",
+		Header:  header,
+	}
 
 	// Build request body
 	reqBody := &wallet.WalletRequest{
 		Payload:   string(reqPayload),
 		Signature: sign,
 	}
-	r.SetBody(reqBody)
+	call.Body = reqBody
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -118,13 +120,16 @@ func (w *WalletClient) CreatePOE(header http.Header, body *wallet.POEBody, signP
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) UpdatePOE(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "UpdatePOE")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
+	op.setAttributes(AttrOwner.String(string(body.Owner)))
 
 	if w.s != nil {
-		signParams, err = w.queryPrivateKey(header, signParams)
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
 		if err != nil {
 			return
 		}
@@ -141,28 +146,29 @@ func (w *WalletClient) UpdatePOE(header http.Header, body *wallet.POEBody, signP
 	}
 
 	// Build http request
-	r := w.c.NewRequest("PUT", "/v1/poe/update")
-	r.SetHeaders(header)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "UpdatePOE",
+		Method:  "PUT",
+		Path:    "/v1/poe/update",
+		Header:  header,
+	}
 
 	// Build request body
 	reqBody := &wallet.WalletRequest{
 		Payload:   string(reqPayload),
 		Signature: sign,
 	}
-	r.SetBody(reqBody)
+	call.Body = reqBody
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -183,21 +189,25 @@ func (w *WalletClient) UpdatePOE(header http.Header, body *wallet.POEBody, signP
 // QueryPOE is used to query POE digital asset.
 //
 func (w *WalletClient) QueryPOE(header http.Header, id did.Identifier) (result *wallet.POEPayload, err error) {
-	r := w.c.NewRequest("GET", "/v1/poe")
-	r.SetHeaders(header)
-	r.SetParam("id", string(id))
+	op := w.begin(w.parentContext(), "QueryPOE", AttrDID.String(string(id)))
+	defer func() { op.end(err) }()
 
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	call := &Call{
+		Context: op.ctx,
+		Name:    "QueryPOE",
+		Method:  "GET",
+		Path:    "/v1/poe",
+		Header:  header,
+	}
+	call.SetParam("id", string(id))
+
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -222,6 +232,9 @@ func (w *WalletClient) QueryPOE(header http.Header, id did.Identifier) (result *
 // poeFile parameter is the path to file to be uploaded.
 //
 func (w *WalletClient) UploadPOEFile(header http.Header, poeID string, poeFile string, readOnly bool) (result *wallet.UploadResponse, err error) {
+	op := w.begin(w.parentContext(), "UploadPOEFile", AttrDID.String(poeID))
+	defer func() { op.end(err) }()
+
 	log.Println("Call UploadPOEFile...")
 
 	if poeID == "" {
@@ -273,7 +286,7 @@ func (w *WalletClient) UploadPOEFile(header http.Header, poeID string, poeFile s
 
 	log.Printf("Open %s file succ", poeFile)
 
-	_, err = io.Copy(formFile, srcFile)
+	size, err := io.Copy(formFile, srcFile)
 	if err != nil {
 		log.Printf("Write file contents to form fail: %v", err)
 		return
@@ -288,29 +301,28 @@ func (w *WalletClient) UploadPOEFile(header http.Header, poeID string, poeFile s
 	writer.Close()
 
 	// New request
-	r := w.c.NewRequest("POST", "/v1/poe/upload")
-	r.SetHeaders(header)
-	r.SetHeader("Content-Type", contentType)
-	r.SetBody(buf.Bytes())
+	call := &Call{
+		Context:     op.ctx,
+		Name:        "UploadPOEFile",
+		Method:      "POST",
+		Path:        "/v1/poe/upload",
+		Header:      header,
+		ContentType: contentType,
+		Body:        buf.Bytes(),
+	}
 
 	// Do upload
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		log.Printf("Request to upload file fail: %v", err)
 		return
 	}
-	defer resp.Body.Close()
 
 	log.Printf("Request to upload file succ")
+	w.telemetry.recordUpload(op.ctx, size)
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		log.Printf("Parse the http response fail: %v", err)
-		return
-	}
-
-	log.Printf("Parse the http response succ")
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -0,0 +1,454 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// DefaultSweepPageSize is the number of UTXOs listed per request when
+// sweeping a wallet.
+//
+const DefaultSweepPageSize = 100
+
+// ctypeDigitalAsset is the CType of the UTXOs of digital assets, the
+// other UTXOs hold colored tokens.
+const ctypeDigitalAsset = 1
+
+// SweepBody describes the transfer of every colored token and digital
+// asset of a wallet to another.
+//
+type SweepBody struct {
+	From string
+	To   string
+	// PageSize is the number of UTXOs listed per request, it defaults
+	// to DefaultSweepPageSize.
+	PageSize int32
+}
+
+// SweepResponse reports what a sweep transferred.
+//
+type SweepResponse struct {
+	Tokens []*wallet.TokenAmount
+	Assets []string
+	// Skipped are the colored tokens left in From because From pays
+	// the fees with them.
+	Skipped        []*wallet.TokenAmount
+	TransactionIds []string
+}
+
+// SweepWallet is used to transfer every colored token and digital asset
+// of body.From to body.To, as listed by QueryTransactionUTXO, with
+// TransferAsset and TransferCToken signed with signParams.
+//
+// Fees are paid as for any transfer, see FeePayer. When From pays the
+// fees, the fee TX would spend the UTXOs being swept, so the colored
+// tokens the fees are paid with are skipped and reported in Skipped.
+//
+// On error, the returned response holds what was transferred before the
+// failure, and the sweep can be run again.
+//
+func (w *WalletClient) SweepWallet(header http.Header, body *SweepBody, signParams *pki.SignatureParam) (result *SweepResponse, err error) {
+	return w.sweepWallet(w.parentContext(), header, body, signParams)
+}
+
+func (w *WalletClient) sweepWallet(ctx context.Context, header http.Header, body *SweepBody, signParams *pki.SignatureParam) (result *SweepResponse, err error) {
+	op := w.begin(ctx, "SweepWallet")
+	defer func() { op.end(err) }()
+
+	if body == nil || body.From == "" || body.To == "" || body.From == body.To {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	if signParams == nil {
+		err = fmt.Errorf("request signature params invalid")
+		return
+	}
+	op.setAttributes(AttrFrom.String(body.From), AttrTo.String(body.To))
+
+	pageSize := body.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultSweepPageSize
+	}
+
+	// 1 list the UTXOs of the wallet
+	amounts := make(map[string]int64)
+	var assets []string
+	seen := make(map[string]bool)
+	for page := int32(1); ; page++ {
+		utxos, err := w.queryTransactionUTXO(op.ctx, header, did.Identifier(body.From), pageSize, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, utxo := range utxos {
+			if utxo.CType == ctypeDigitalAsset {
+				if !seen[utxo.CTokenId] {
+					seen[utxo.CTokenId] = true
+					assets = append(assets, utxo.CTokenId)
+				}
+				continue
+			}
+			if utxo.Value > 0 {
+				amounts[utxo.CTokenId] += utxo.Value
+			}
+		}
+		if int32(len(utxos)) < pageSize {
+			break
+		}
+	}
+	sort.Strings(assets)
+
+	if w.s != nil {
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 2 transfer the digital assets, then the colored tokens
+	result = &SweepResponse{}
+	if len(assets) != 0 {
+		resp, err := w.transferAsset(op.ctx, header, &wallet.TransferAssetBody{
+			From:   body.From,
+			To:     body.To,
+			Assets: assets,
+		}, signParams)
+		if err != nil {
+			return result, err
+		}
+		result.Assets = assets
+		if resp != nil {
+			result.TransactionIds = append(result.TransactionIds, resp.TransactionIds...)
+		}
+	}
+	if len(amounts) != 0 {
+		transfer := &wallet.TransferCTokenBody{
+			From:   body.From,
+			To:     body.To,
+			Tokens: tokenAmounts(amounts),
+		}
+		txs, err := w.sendTransferCTokenProposal(op.ctx, header, transfer)
+		if err != nil {
+			return result, err
+		}
+
+		// 3 skip the tokens From pays the fees with
+		feeTokens, err := w.feeTokensPaidBy(op.ctx, header, body.From, body.To, txs)
+		if err != nil {
+			return result, err
+		}
+		for _, tokenID := range sortedKeys(amounts) {
+			if feeTokens[tokenID] {
+				result.Skipped = append(result.Skipped, &wallet.TokenAmount{TokenId: tokenID, Amount: amounts[tokenID]})
+				delete(amounts, tokenID)
+			}
+		}
+		if len(amounts) == 0 {
+			return result, nil
+		}
+		if len(result.Skipped) != 0 {
+			transfer.Tokens = tokenAmounts(amounts)
+			txs, err = w.sendTransferCTokenProposal(op.ctx, header, transfer)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		resp, err := w.transferCTokenTxs(op.ctx, header, transfer, txs, signParams)
+		if err != nil {
+			return result, err
+		}
+		result.Tokens = transfer.Tokens
+		if resp != nil {
+			result.TransactionIds = append(result.TransactionIds, resp.TransactionIds...)
+		}
+	}
+	return result, nil
+}
+
+// tokenAmounts returns amounts by token id as token amounts sorted by
+// token id.
+func tokenAmounts(amounts map[string]int64) (tokens []*wallet.TokenAmount) {
+	for _, tokenID := range sortedKeys(amounts) {
+		tokens = append(tokens, &wallet.TokenAmount{TokenId: tokenID, Amount: amounts[tokenID]})
+	}
+	return
+}
+
+// feeTokensPaidBy returns the colored tokens from pays fees with in the
+// proposed txs of a transfer to to: the tokens of the outputs of TXs
+// founded by from, paid neither back to from nor to to.
+func (w *WalletClient) feeTokensPaidBy(ctx context.Context, header http.Header, from, to string, txs []*pw.TX) (tokens map[string]bool, err error) {
+	var founded []*pw.TX
+	for _, tx := range txs {
+		if tx.Founder == from {
+			founded = append(founded, tx)
+		}
+	}
+	// The transfer TX alone, from pays no fee TX
+	if len(founded) < 2 {
+		return nil, nil
+	}
+
+	endpoint := w.endpointResolver(ctx, header, w.intentCheck())
+	fromAddr, err := endpoint(from)
+	if err != nil {
+		return nil, err
+	}
+	toAddr, err := endpoint(to)
+	if err != nil {
+		return nil, err
+	}
+	tokens = make(map[string]bool)
+	for _, tx := range founded {
+		for _, txout := range tx.Txout {
+			if txout.Addr != fromAddr && txout.Addr != toAddr {
+				tokens[txout.CTokenId] = true
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// KeyRotation maps a wallet whose key was rotated to the wallet
+// replacing it.
+//
+type KeyRotation struct {
+	OldId did.Identifier `json:"old_id"`
+	NewId did.Identifier `json:"new_id"`
+	// Rotated is the unix time of the rotation.
+	Rotated int64 `json:"rotated"`
+	// Swept reports whether the tokens and assets of OldId were all
+	// transferred to NewId, none being skipped, see SweepWallet.
+	Swept bool `json:"swept"`
+}
+
+// KeyRotations records the key rotations of a client, see
+// WithKeyRotations. It is safe for concurrent use.
+//
+type KeyRotations struct {
+	mu        sync.Mutex
+	rotations map[did.Identifier]*KeyRotation
+}
+
+// WithKeyRotations records the key rotations of the client in r.
+//
+func WithKeyRotations(r *KeyRotations) Option {
+	return func(w *WalletClient) {
+		w.rotations = r
+	}
+}
+
+// Add records rotation, replacing the rotation of the same old wallet.
+// It is used to restore persisted rotations.
+//
+func (r *KeyRotations) Add(rotation *KeyRotation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rotations == nil {
+		r.rotations = make(map[did.Identifier]*KeyRotation)
+	}
+	copied := *rotation
+	r.rotations[rotation.OldId] = &copied
+}
+
+// Lookup returns the rotation of the old wallet id, or nil.
+//
+func (r *KeyRotations) Lookup(id did.Identifier) *KeyRotation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rotation, ok := r.rotations[id]; ok {
+		copied := *rotation
+		return &copied
+	}
+	return nil
+}
+
+// Resolve returns the current wallet of id, following the rotations of
+// id and of the wallets replacing it. It returns id if it was never
+// rotated.
+//
+func (r *KeyRotations) Resolve(id did.Identifier) did.Identifier {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[did.Identifier]bool)
+	for !seen[id] {
+		seen[id] = true
+		rotation, ok := r.rotations[id]
+		if !ok {
+			break
+		}
+		id = rotation.NewId
+	}
+	return id
+}
+
+// Rotations returns the recorded rotations in time order.
+//
+func (r *KeyRotations) Rotations() []*KeyRotation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rotations := make([]*KeyRotation, 0, len(r.rotations))
+	for _, rotation := range r.rotations {
+		copied := *rotation
+		rotations = append(rotations, &copied)
+	}
+	sort.Slice(rotations, func(i, j int) bool {
+		if rotations[i].Rotated != rotations[j].Rotated {
+			return rotations[i].Rotated < rotations[j].Rotated
+		}
+		return rotations[i].OldId < rotations[j].OldId
+	})
+	return rotations
+}
+
+// RotateKeyBody describes the rotation of the key of a wallet.
+//
+type RotateKeyBody struct {
+	// Id is the wallet whose key is rotated.
+	Id did.Identifier
+	// Register is the registration of the wallet replacing it.
+	Register *wallet.RegisterWalletBody
+	// KeyPair is the new key pair, it is generated if not set.
+	KeyPair *KeyPair
+	// PageSize is the number of UTXOs listed per request, see
+	// SweepBody.
+	PageSize int32
+}
+
+// RotateKeyResponse reports a key rotation.
+//
+type RotateKeyResponse struct {
+	Rotation *KeyRotation
+	// Wallet is the registration of the new wallet.
+	Wallet  *wallet.WalletResponse
+	KeyPair *KeyPair
+	Sweep   *SweepResponse
+}
+
+// RotateKey is used to rotate the key of a wallet, such as after it was
+// compromised.
+//
+// The platform binds a DID to its key pair and provides no key update:
+// a new wallet is registered with the public key of body.KeyPair, see
+// RegisterWithPublicKey, every colored token and digital asset of the
+// old wallet is swept to it with the old key signParams, see
+// SweepWallet, and the old to new mapping is recorded in the
+// KeyRotations of the client, if any. Once swept, the old key is
+// purged from the KeyCache of the client, if any.
+//
+// When the client trustees key pairs to a safebox, the new key pair is
+// handed over to it before the sweep, see TrusteeKeyPair: the response
+// wallet then carries the security code to sign for the new wallet
+// with.
+//
+// If the trusteeship or the sweep fails, or if tokens the old wallet
+// pays fees with are skipped, the response still holds the new wallet
+// and its key pair, the rotation is recorded as not swept, and the
+// sweep should be completed with SweepWallet.
+//
+func (w *WalletClient) RotateKey(header http.Header, body *RotateKeyBody, signParams *pki.SignatureParam) (result *RotateKeyResponse, err error) {
+	op := w.begin(w.parentContext(), "RotateKey")
+	defer func() { op.end(err) }()
+
+	if body == nil || body.Id == "" {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	if signParams == nil {
+		err = fmt.Errorf("request signature params invalid")
+		return
+	}
+	op.setAttributes(AttrDID.String(string(body.Id)))
+
+	// 1 register the new wallet with the new public key
+	keyPair := body.KeyPair
+	if keyPair == nil {
+		keyPair, err = GenerateKeyPair()
+		if err != nil {
+			return nil, err
+		}
+	}
+	registered, err := w.registerWithPublicKey(op.ctx, header, body.Register, keyPair.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result = &RotateKeyResponse{
+		Rotation: &KeyRotation{
+			OldId:   body.Id,
+			NewId:   registered.Id,
+			Rotated: time.Now().Unix(),
+		},
+		Wallet:  registered,
+		KeyPair: keyPair,
+	}
+
+	// 2 trustee the new key pair, then sweep the old wallet
+	if err = w.trusteeRotatedKey(op.ctx, header, result); err == nil {
+		result.Sweep, err = w.sweepWallet(op.ctx, header, &SweepBody{
+			From:     string(body.Id),
+			To:       string(registered.Id),
+			PageSize: body.PageSize,
+		}, signParams)
+	}
+	result.Rotation.Swept = err == nil && len(result.Sweep.Skipped) == 0
+	if result.Rotation.Swept && w.keyCache != nil {
+		w.keyCache.PurgeDID(body.Id)
+	}
+
+	// 3 record the mapping, even if the sweep failed
+	if w.rotations != nil {
+		w.rotations.Add(result.Rotation)
+	}
+	return result, err
+}
+
+// trusteeRotatedKey hands the new key pair of a rotation over to the
+// safebox of the client, if any, and sets the security code of the new
+// wallet.
+func (w *WalletClient) trusteeRotatedKey(ctx context.Context, header http.Header, rotated *RotateKeyResponse) error {
+	if w.s == nil {
+		return nil
+	}
+	trusteed, err := w.trusteeKeyPair(ctx, header, &wallet.WalletResponse{
+		Id: rotated.Wallet.Id,
+		KeyPair: &wallet.KeyPair{
+			PrivateKey: rotated.KeyPair.EncodedPrivateKey(),
+			PublicKey:  rotated.KeyPair.EncodedPublicKey(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	rotated.Wallet.SecurityCode = trusteed.SecurityCode
+	return nil
+}
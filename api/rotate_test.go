@@ -0,0 +1,325 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func mockSweep(t *testing.T, id string) {
+	//two full pages of UTXOs holding tokens and assets, then an empty one
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		MatchParam("id", id).
+		MatchParam("num", "2").
+		MatchParam("page", "1").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.UTXO{
+			{CTokenId: "token-a", Value: 100},
+			{CTokenId: "asset-002", CType: 1, Value: 1},
+		}))
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		MatchParam("id", id).
+		MatchParam("page", "2").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.UTXO{
+			{CTokenId: "token-a", Value: 50},
+			{CTokenId: "asset-001", CType: 1, Value: 1},
+		}))
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		MatchParam("id", id).
+		MatchParam("page", "3").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.UTXO{}))
+}
+
+func TestSweepWalletSucc(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "nonce",
+		PrivateKey: testPrivateKey,
+	}
+	mockSweep(t, "did:axn:001")
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/assets/transfer/prepare").
+		AddMatcher(matchBodyContains(`"assets":["asset-001","asset-002"]`)).
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 1, 1)}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{"did:axn:001": sign})).
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{TransactionIds: []string{"tx-assets"}}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		AddMatcher(matchBodyContains(`"tokens":[{"token_id":"token-a","amount":150}]`)).
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 150)}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{"did:axn:001": sign})).
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{TransactionIds: []string{"tx-tokens"}}))
+
+	resp, err := walletClient.SweepWallet(http.Header{}, &SweepBody{
+		From:     "did:axn:001",
+		To:       "did:axn:002",
+		PageSize: 2,
+	}, sign)
+	if err != nil {
+		t.Fatalf("sweep wallet fail: %v", err)
+	}
+	if len(resp.Assets) != 2 || len(resp.Tokens) != 1 || resp.Tokens[0].Amount != 150 {
+		t.Fatalf("sweep response invalid: %+v", resp)
+	}
+	if len(resp.TransactionIds) != 2 || resp.TransactionIds[0] != "tx-assets" || resp.TransactionIds[1] != "tx-tokens" {
+		t.Fatalf("transaction ids invalid: %v", resp.TransactionIds)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending mocks: %v", gock.Pending())
+	}
+}
+
+func TestSweepWalletFail(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	sign := &pki.SignatureParam{Creator: "did:axn:001", Nonce: "nonce", PrivateKey: testPrivateKey}
+	for _, body := range []*SweepBody{nil, {From: "did:axn:001"}, {From: "did:axn:001", To: "did:axn:001"}} {
+		if _, err := walletClient.SweepWallet(http.Header{}, body, sign); err == nil {
+			t.Fatalf("sweep with invalid body %+v should fail", body)
+		}
+	}
+
+	//the token transfer fails after the assets were transferred
+	mockSweep(t, "did:axn:001")
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/assets/transfer/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 1, 1)}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{TransactionIds: []string{"tx-assets"}}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 3008, ErrMessage: "UTXOSpent"})
+
+	resp, err := walletClient.SweepWallet(http.Header{}, &SweepBody{From: "did:axn:001", To: "did:axn:002", PageSize: 2}, sign)
+	if err == nil {
+		t.Fatalf("sweep wallet should fail")
+	}
+	if resp == nil || len(resp.Assets) != 2 || len(resp.Tokens) != 0 {
+		t.Fatalf("response should report the transferred assets only: %+v", resp)
+	}
+}
+
+func TestSweepWalletFeePayer(t *testing.T) {
+	initWalletClient(t)
+	defer gock.Off()
+
+	sign := &pki.SignatureParam{Creator: "did:axn:001", Nonce: "nonce", PrivateKey: testPrivateKey}
+	feeTx := spending(intentTx(t, "did:axn:001", out("fee-token", 1, "endpoint-fee"), out("fee-token", 4, "endpoint-001")), "utxo-fee:0")
+
+	//did:axn:001 pays the fees with fee-token, which is left in the wallet
+	mockWalletInfo(t, "did:axn:001", "endpoint-001")
+	mockWalletInfo(t, "did:axn:002", "endpoint-002")
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		MatchParam("id", "did:axn:001").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.UTXO{
+			{SourceTxDataHash: "utxo-a", Ix: "0", CTokenId: "token-a", Value: 10},
+			{SourceTxDataHash: "utxo-fee", Ix: "0", CTokenId: "fee-token", Value: 5},
+		}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		AddMatcher(matchBodyContains(`"tokens":[{"token_id":"fee-token","amount":5},{"token_id":"token-a","amount":10}]`)).
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{
+			spending(intentTx(t, "did:axn:001", out("fee-token", 5, "endpoint-002"), out("token-a", 10, "endpoint-002")), "utxo-fee:0", "utxo-a:0"),
+			feeTx,
+		}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		AddMatcher(matchBodyContains(`"tokens":[{"token_id":"token-a","amount":10}]`)).
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{
+			spending(intentTx(t, "did:axn:001", out("token-a", 10, "endpoint-002")), "utxo-a:0"),
+			feeTx,
+		}))
+	process := gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{"did:axn:001": sign})).
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{TransactionIds: []string{"tx-tokens"}}))
+
+	resp, err := walletClient.SweepWallet(http.Header{}, &SweepBody{From: "did:axn:001", To: "did:axn:002"}, sign)
+	if err != nil {
+		t.Fatalf("sweep wallet fail: %v", err)
+	}
+	if len(resp.Tokens) != 1 || resp.Tokens[0].TokenId != "token-a" || resp.Tokens[0].Amount != 10 {
+		t.Fatalf("only token-a should be swept: %+v", resp.Tokens)
+	}
+	if len(resp.Skipped) != 1 || resp.Skipped[0].TokenId != "fee-token" || resp.Skipped[0].Amount != 5 {
+		t.Fatalf("fee-token should be skipped: %+v", resp.Skipped)
+	}
+	if !process.Mock.Done() {
+		t.Fatalf("the transfer without the fee token should be processed")
+	}
+}
+
+func TestRotateKeyTrustee(t *testing.T) {
+	rotations := &KeyRotations{}
+	w := newTrusteeWalletClient(t, WithKeyRotations(rotations))
+	defer gock.Off()
+
+	sign := &pki.SignatureParam{Creator: "did:axn:001", Nonce: "nonce", PrivateKey: testPrivateKey}
+	key, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		Reply(200).
+		Map(echoPublicKey(t, "did:axn:002"))
+	trustee := gock.New("http://127.0.0.1:8006").
+		Post("/").
+		AddMatcher(matchBodyContains(`"user_did":"did:axn:002"`)).
+		AddMatcher(matchBodyContains(key.EncodedPublicKey())).
+		Reply(200).
+		JSON(map[string]string{"code": "security-code-002"})
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		MatchParam("id", "did:axn:001").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.UTXO{}))
+
+	resp, err := w.RotateKey(http.Header{}, &RotateKeyBody{
+		Id:       "did:axn:001",
+		Register: &wallet.RegisterWalletBody{Access: "alice-rotated", Secret: "secret"},
+		KeyPair:  key,
+	}, sign)
+	if err != nil {
+		t.Fatalf("rotate key fail: %v", err)
+	}
+	if !trustee.Mock.Done() {
+		t.Fatalf("the new key pair should be trusteed")
+	}
+	if resp.Wallet.SecurityCode == "" || resp.KeyPair != key {
+		t.Fatalf("new wallet should carry the security code: %+v", resp.Wallet)
+	}
+	if rotation := rotations.Lookup("did:axn:001"); rotation == nil || !rotation.Swept {
+		t.Fatalf("rotation should be recorded as swept: %+v", rotation)
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	rotations := &KeyRotations{}
+	client := newTestWalletClient(t, WithKeyRotations(rotations))
+	defer gock.Off()
+
+	sign := &pki.SignatureParam{Creator: "did:axn:001", Nonce: "nonce", PrivateKey: testPrivateKey}
+	key, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		AddMatcher(matchBodyContains(`"public_key":"` + key.EncodedPublicKey() + `"`)).
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{
+			Id:      "did:axn:002",
+			KeyPair: &wallet.KeyPair{PublicKey: key.EncodedPublicKey()},
+		}))
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		MatchParam("id", "did:axn:001").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.UTXO{{CTokenId: "token-a", Value: 10}}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		AddMatcher(matchBodyContains(`"to":"did:axn:002"`)).
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 10)}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{TransactionIds: []string{"tx-tokens"}}))
+
+	resp, err := client.RotateKey(http.Header{}, &RotateKeyBody{
+		Id:       "did:axn:001",
+		Register: &wallet.RegisterWalletBody{Access: "alice-rotated", Secret: "secret"},
+		KeyPair:  key,
+	}, sign)
+	if err != nil {
+		t.Fatalf("rotate key fail: %v", err)
+	}
+	if resp.KeyPair != key || resp.Wallet.Id != "did:axn:002" || len(resp.Sweep.Tokens) != 1 {
+		t.Fatalf("rotate key response invalid: %+v", resp)
+	}
+	rotation := rotations.Lookup("did:axn:001")
+	if rotation == nil || rotation.NewId != "did:axn:002" || !rotation.Swept || rotation.Rotated == 0 {
+		t.Fatalf("rotation should be recorded: %+v", rotation)
+	}
+
+	//rotating again, the sweep of an unknown wallet fails
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		Reply(200).
+		Map(echoPublicKey(t, "did:axn:003"))
+	gock.New("http://127.0.0.1:8006").
+		Get("/v2/transaction/utxo").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 8005, ErrMessage: "wallet not found"})
+	resp, err = client.RotateKey(http.Header{}, &RotateKeyBody{
+		Id:       "did:axn:002",
+		Register: &wallet.RegisterWalletBody{Access: "alice-rotated-2", Secret: "secret"},
+	}, sign)
+	if err == nil {
+		t.Fatalf("rotate key should fail")
+	}
+	if resp == nil || resp.KeyPair == nil || resp.Wallet.Id != "did:axn:003" {
+		t.Fatalf("response should hold the new wallet after a failed sweep: %+v", resp)
+	}
+	if rotation = rotations.Lookup("did:axn:002"); rotation == nil || rotation.Swept {
+		t.Fatalf("rotation should be recorded as not swept: %+v", rotation)
+	}
+	if id := rotations.Resolve("did:axn:001"); id != "did:axn:003" {
+		t.Fatalf("did:axn:001 should resolve to did:axn:003 not %s", id)
+	}
+	if n := len(rotations.Rotations()); n != 2 {
+		t.Fatalf("2 rotations should be recorded not %d", n)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/arxanchain/sdk-go-common/errors"
+	"github.com/arxanchain/sdk-go-common/rest"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/utils"
+)
+
+// SignatureEncoding is the encoding of a signature stored in a string,
+// such as the SignatureValue of pki.SignatureBody.
+//
+type SignatureEncoding int
+
+const (
+	// SignatureRaw keeps the signature bytes as is. Signatures are
+	// arbitrary bytes, usually not valid UTF-8, which JSON marshalling
+	// replaces with U+FFFD: raw strings must never be sent as JSON.
+	SignatureRaw SignatureEncoding = iota
+	// SignatureBase64 is the standard base64 encoding, used by the
+	// signature bodies of wallet requests.
+	SignatureBase64
+	// SignatureHex is the lower case hexadecimal encoding.
+	SignatureHex
+)
+
+// String returns the name of the encoding.
+//
+func (e SignatureEncoding) String() string {
+	switch e {
+	case SignatureRaw:
+		return "raw"
+	case SignatureBase64:
+		return "base64"
+	case SignatureHex:
+		return "hex"
+	}
+	return fmt.Sprintf("SignatureEncoding(%d)", int(e))
+}
+
+// Signature is the value of an ed25519 signature.
+//
+// Signature marshals to JSON as base64, like any byte slice. Use Encode
+// and DecodeSignature to convert it from and to the string encodings
+// found in signature bodies.
+//
+type Signature []byte
+
+// Encode returns the signature encoded with enc.
+//
+// Encoding a signature which is not valid UTF-8 with SignatureRaw fails,
+// since the result would be corrupted once marshalled to JSON.
+//
+func (s Signature) Encode(enc SignatureEncoding) (string, error) {
+	switch enc {
+	case SignatureRaw:
+		if !utf8.Valid(s) {
+			return "", fmt.Errorf("raw signature is not valid UTF-8 and would be corrupted in JSON, use %v or %v encoding", SignatureBase64, SignatureHex)
+		}
+		return string(s), nil
+	case SignatureBase64:
+		return utils.EncodeBase64(s), nil
+	case SignatureHex:
+		return hex.EncodeToString(s), nil
+	}
+	return "", fmt.Errorf("signature encoding %v invalid", enc)
+}
+
+// DecodeSignature returns the signature encoded with enc in value.
+//
+func DecodeSignature(value string, enc SignatureEncoding) (Signature, error) {
+	switch enc {
+	case SignatureRaw:
+		return Signature(value), nil
+	case SignatureBase64:
+		sign, err := utils.DecodeBase64(value)
+		if err != nil {
+			return nil, rest.CodedError(errors.SDKInvalidBase64Data, err.Error())
+		}
+		return Signature(sign), nil
+	case SignatureHex:
+		sign, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("signature hex value invalid: %v", err)
+		}
+		return Signature(sign), nil
+	}
+	return nil, fmt.Errorf("signature encoding %v invalid", enc)
+}
+
+// DecodeSignatureBody returns the signature of a signature body whose
+// value is encoded with enc.
+//
+func DecodeSignatureBody(body *pki.SignatureBody, enc SignatureEncoding) (Signature, error) {
+	if body == nil {
+		return nil, fmt.Errorf("signature body invalid")
+	}
+	return DecodeSignature(body.SignatureValue, enc)
+}
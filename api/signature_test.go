@@ -0,0 +1,158 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+)
+
+func TestSignatureRoundTrip(t *testing.T) {
+	signature, err := buildSignature(&pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "nonce",
+		PrivateKey: testPrivateKey,
+	}, []byte("payload"))
+	if err != nil {
+		t.Fatalf("build signature fail: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		sign Signature
+		encs []SignatureEncoding
+	}{
+		{"Ed25519", Signature(signature.Sign), []SignatureEncoding{SignatureBase64, SignatureHex}},
+		{"UTF8", Signature("数字签名"), []SignatureEncoding{SignatureRaw, SignatureBase64, SignatureHex}},
+		{"Empty", Signature{}, []SignatureEncoding{SignatureRaw, SignatureBase64, SignatureHex}},
+	}
+	for _, c := range cases {
+		for _, enc := range c.encs {
+			value, err := c.sign.Encode(enc)
+			if err != nil {
+				t.Fatalf("%s: encode %v fail: %v", c.name, enc, err)
+			}
+
+			// The encoded value must survive being sent as JSON
+			data, err := json.Marshal(&pki.SignatureBody{SignatureValue: value})
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			var body pki.SignatureBody
+			if err = json.Unmarshal(data, &body); err != nil {
+				t.Fatalf("%v", err)
+			}
+
+			decoded, err := DecodeSignatureBody(&body, enc)
+			if err != nil {
+				t.Fatalf("%s: decode %v fail: %v", c.name, enc, err)
+			}
+			if !bytes.Equal(decoded, c.sign) {
+				t.Fatalf("%s: %v round trip should return %x not %x", c.name, enc, []byte(c.sign), []byte(decoded))
+			}
+		}
+	}
+}
+
+func TestSignatureRawNotUTF8(t *testing.T) {
+	sign := Signature{0xff, 0xfe, 0x00, 0x80}
+
+	// This is how raw signatures get corrupted
+	data, err := json.Marshal(string(sign))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var corrupted string
+	if err = json.Unmarshal(data, &corrupted); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if corrupted == string(sign) {
+		t.Fatalf("invalid UTF-8 should not survive JSON marshalling")
+	}
+
+	if _, err = sign.Encode(SignatureRaw); err == nil {
+		t.Fatalf("raw encoding of invalid UTF-8 should fail")
+	}
+
+	// Raw decoding is lossless
+	decoded, err := DecodeSignature(string(sign), SignatureRaw)
+	if err != nil || !bytes.Equal(decoded, sign) {
+		t.Fatalf("raw decoding should keep the bytes as is")
+	}
+}
+
+func TestSignatureDecodeInvalid(t *testing.T) {
+	if _, err := DecodeSignature("not base64!", SignatureBase64); err == nil {
+		t.Fatalf("decoding invalid base64 should fail")
+	}
+	if _, err := DecodeSignature("zz", SignatureHex); err == nil {
+		t.Fatalf("decoding invalid hex should fail")
+	}
+	if _, err := DecodeSignature("", SignatureEncoding(42)); err == nil {
+		t.Fatalf("decoding with unknown encoding should fail")
+	}
+	if _, err := DecodeSignatureBody(nil, SignatureBase64); err == nil {
+		t.Fatalf("decoding nil body should fail")
+	}
+	if s := SignatureEncoding(42).String(); !strings.Contains(s, "42") {
+		t.Fatalf("unknown encoding name should contain its value, got %s", s)
+	}
+}
+
+func TestSignTxKeepsRawSignature(t *testing.T) {
+	signParams := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "nonce",
+		PrivateKey: testPrivateKey,
+	}
+	tx := newTestTx(t, "did:axn:001", 1)
+	var unsigned pw.UTXOSignature
+	if err := json.Unmarshal(tx.Txout[0].Script, &unsigned); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if err := signTx(tx, signParams); err != nil {
+		t.Fatalf("sign tx fail: %v", err)
+	}
+
+	// The script goes through JSON when sent to the platform
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var sent pw.TX
+	if err = json.Unmarshal(data, &sent); err != nil {
+		t.Fatalf("%v", err)
+	}
+	var signed pw.UTXOSignature
+	if err = json.Unmarshal(sent.Txout[0].Script, &signed); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	expected, err := buildSignature(signParams, unsigned.PublicKey)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if !bytes.Equal(signed.Signature, expected.Sign) {
+		t.Fatalf("utxo signature should be the raw signature bytes")
+	}
+}
@@ -0,0 +1,345 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// Legs of a swap.
+const (
+	// SwapLegTokens is the transfer of colored tokens from the token
+	// owner to the asset owner.
+	SwapLegTokens = "tokens"
+	// SwapLegAssets is the transfer of digital assets from the asset
+	// owner to the token owner.
+	SwapLegAssets = "assets"
+)
+
+// SwapBody describes the exchange of colored tokens for digital assets
+// between two wallets.
+//
+type SwapBody struct {
+	// TokenOwner pays Tokens to AssetOwner.
+	TokenOwner string
+	AssetId    string
+	Tokens     []*wallet.TokenAmount
+
+	// AssetOwner transfers Assets to TokenOwner.
+	AssetOwner string
+	Assets     []string
+}
+
+// legs returns the transfers of both legs of the swap.
+func (b *SwapBody) legs() (tokens *wallet.TransferCTokenBody, assets *wallet.TransferAssetBody) {
+	tokens = &wallet.TransferCTokenBody{
+		From:    b.TokenOwner,
+		To:      b.AssetOwner,
+		AssetId: b.AssetId,
+		Tokens:  b.Tokens,
+	}
+	assets = &wallet.TransferAssetBody{
+		From:   b.AssetOwner,
+		To:     b.TokenOwner,
+		Assets: b.Assets,
+	}
+	return
+}
+
+func (b *SwapBody) valid() bool {
+	return b != nil && b.TokenOwner != "" && b.AssetOwner != "" && len(b.Tokens) != 0 && len(b.Assets) != 0
+}
+
+// Swap holds the TXs of both legs of a swap until they are signed by
+// their parties and submitted.
+//
+// A Swap can be JSON encoded and passed to a party signing on its side,
+// see SignSwap.
+//
+type Swap struct {
+	TokenOwner string   `json:"token_owner"`
+	AssetOwner string   `json:"asset_owner"`
+	TokenTxs   []*pw.TX `json:"token_txs"`
+	AssetTxs   []*pw.TX `json:"asset_txs"`
+}
+
+// sign signs the TXs of both legs founded by the signParams creator,
+// who must be a party of the swap.
+func (s *Swap) sign(signParams *pki.SignatureParam) (err error) {
+	if signParams == nil {
+		return fmt.Errorf("request signature params invalid")
+	}
+	creator := string(signParams.Creator)
+	if creator != s.TokenOwner && creator != s.AssetOwner {
+		return fmt.Errorf("%s is not a party of the swap", creator)
+	}
+	for _, tx := range s.txs() {
+		if tx.Founder != creator {
+			continue
+		}
+		if err = signTx(tx, signParams); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending returns the parties whose TXs are not signed yet.
+//
+func (s *Swap) Pending() (parties []string) {
+	for _, party := range []string{s.TokenOwner, s.AssetOwner} {
+		for _, tx := range s.txs() {
+			if tx.Founder == party && !txSigned(tx) {
+				parties = append(parties, party)
+				break
+			}
+		}
+	}
+	return
+}
+
+func (s *Swap) txs() []*pw.TX {
+	return append(append([]*pw.TX(nil), s.TokenTxs...), s.AssetTxs...)
+}
+
+// SwapError reports a swap that failed on submission.
+//
+type SwapError struct {
+	// Leg is the leg that failed, it is empty when both legs were
+	// submitted together.
+	Leg string
+	// Committed is the response of the leg committed before Leg
+	// failed, the swap is then half done and must be compensated.
+	Committed *wallet.WalletResponse
+	Err       error
+}
+
+func (e *SwapError) Error() string {
+	if e.Committed != nil {
+		return fmt.Sprintf("swap %s leg failed after the other leg was committed: %v", e.Leg, e.Err)
+	}
+	if e.Leg != "" {
+		return fmt.Sprintf("swap %s leg failed: %v", e.Leg, e.Err)
+	}
+	return fmt.Sprintf("swap failed: %v", e.Err)
+}
+
+// PrepareSwap is used to send the transfer proposals of both legs of a
+// swap. The returned Swap must be signed by both parties, see SignSwap,
+// then submitted with SubmitSwap.
+//
+// Both proposals carry a fee TX, the fee payer should hold enough
+// distinct outputs for both of them.
+//
+func (w *WalletClient) PrepareSwap(header http.Header, body *SwapBody) (result *Swap, err error) {
+	op := w.begin(w.parentContext(), "PrepareSwap")
+	defer func() { op.end(err) }()
+
+	if !body.valid() {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	op.setAttributes(AttrFrom.String(body.TokenOwner), AttrTo.String(body.AssetOwner))
+
+	tokenBody, assetBody := body.legs()
+	tokenTxs, err := w.sendTransferCTokenProposal(op.ctx, header, tokenBody)
+	if err != nil {
+		return nil, err
+	}
+	assetTxs, err := w.sendTransferAssetProposal(op.ctx, header, assetBody)
+	if err != nil {
+		return nil, err
+	}
+	if w.intent != nil {
+		if err = w.checkIntent(op.ctx, header, tokenBody, "", tokenTxs); err != nil {
+			return nil, err
+		}
+		if err = w.checkIntent(op.ctx, header, assetBody, "", assetTxs); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Swap{
+		TokenOwner: body.TokenOwner,
+		AssetOwner: body.AssetOwner,
+		TokenTxs:   tokenTxs,
+		AssetTxs:   assetTxs,
+	}, nil
+}
+
+// SignSwap is used to sign the TXs of swap founded by the signParams
+// creator, who must be a party of the swap. Fee TXs are signed on
+// submission.
+//
+// A swap is typically handed over by the counterparty, body is the swap
+// agreed with it. Both legs are verified against body before signing,
+// as CheckIntent does for transfers, with the IntentCheck of the client
+// if any, see WithIntentCheck. A swap whose TXs do not match is not
+// signed and an *IntentError is returned.
+//
+func (w *WalletClient) SignSwap(header http.Header, swap *Swap, body *SwapBody, signParams *pki.SignatureParam) (err error) {
+	op := w.begin(w.parentContext(), "SignSwap")
+	defer func() { op.end(err) }()
+
+	if swap == nil || !body.valid() {
+		return fmt.Errorf("request payload invalid")
+	}
+	if swap.TokenOwner != body.TokenOwner || swap.AssetOwner != body.AssetOwner {
+		return fmt.Errorf("swap parties do not match the agreed swap")
+	}
+	op.setAttributes(AttrFrom.String(body.TokenOwner), AttrTo.String(body.AssetOwner))
+
+	tokenBody, assetBody := body.legs()
+	if err = w.checkIntent(op.ctx, header, tokenBody, "", swap.TokenTxs); err != nil {
+		return err
+	}
+	if err = w.checkIntent(op.ctx, header, assetBody, "", swap.AssetTxs); err != nil {
+		return err
+	}
+
+	if w.s != nil {
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
+		if err != nil {
+			return err
+		}
+	}
+	return swap.sign(signParams)
+}
+
+// SubmitSwap is used to sign the fee TXs of a swap signed by both
+// parties, and to process the TXs of both legs in a single ProcessTx
+// call.
+//
+// The platform is expected to commit the TXs of a ProcessTx call
+// atomically, so that neither leg commits without the other. Use
+// SubmitSwapSequential against a platform committing them one by one.
+//
+// A failed submission is reported by a *SwapError.
+//
+func (w *WalletClient) SubmitSwap(header http.Header, swap *Swap) (result *wallet.WalletResponse, err error) {
+	op := w.begin(w.parentContext(), "SubmitSwap")
+	defer func() { op.end(err) }()
+
+	if err = w.signSwapFees(op.ctx, header, swap); err != nil {
+		return nil, err
+	}
+
+	result, err = w.processTx(op.ctx, header, swap.txs())
+	if err != nil {
+		return nil, &SwapError{Err: err}
+	}
+	return result, nil
+}
+
+// SubmitSwapSequential is used to submit a swap like SubmitSwap, but
+// processes the asset leg first, then the token leg.
+//
+// If the token leg fails, the returned *SwapError holds the response
+// of the committed asset leg: the assets were transferred without
+// payment and the swap must be compensated by the caller.
+//
+func (w *WalletClient) SubmitSwapSequential(header http.Header, swap *Swap) (result *wallet.WalletResponse, err error) {
+	op := w.begin(w.parentContext(), "SubmitSwapSequential")
+	defer func() { op.end(err) }()
+
+	if err = w.signSwapFees(op.ctx, header, swap); err != nil {
+		return nil, err
+	}
+
+	assets, err := w.processTx(op.ctx, header, swap.AssetTxs)
+	if err != nil {
+		return nil, &SwapError{Leg: SwapLegAssets, Err: err}
+	}
+	tokens, err := w.processTx(op.ctx, header, swap.TokenTxs)
+	if err != nil {
+		return nil, &SwapError{Leg: SwapLegTokens, Committed: assets, Err: err}
+	}
+
+	result = &wallet.WalletResponse{}
+	if assets != nil {
+		result.TransactionIds = append(result.TransactionIds, assets.TransactionIds...)
+	}
+	if tokens != nil {
+		result.TransactionIds = append(result.TransactionIds, tokens.TransactionIds...)
+	}
+	return result, nil
+}
+
+// signSwapFees checks that both parties signed their TXs, and signs the
+// remaining fee TXs with the fee payer.
+func (w *WalletClient) signSwapFees(ctx context.Context, header http.Header, swap *Swap) error {
+	if swap == nil || len(swap.TokenTxs) == 0 || len(swap.AssetTxs) == 0 {
+		return fmt.Errorf("request payload invalid")
+	}
+	if pending := swap.Pending(); len(pending) != 0 {
+		return fmt.Errorf("swap TXs of %v are not signed", pending)
+	}
+
+	legs := []struct {
+		creator string
+		txs     []*pw.TX
+	}{
+		{swap.TokenOwner, swap.TokenTxs},
+		{swap.AssetOwner, swap.AssetTxs},
+	}
+	for _, leg := range legs {
+		for _, tx := range leg.txs {
+			if tx.Founder == swap.TokenOwner || tx.Founder == swap.AssetOwner {
+				continue
+			}
+			feeSignParams, err := w.payer().FeeSignParam(&FeeTx{
+				Context: ctx,
+				Header:  header,
+				Creator: did.Identifier(leg.creator),
+				Tx:      tx,
+			})
+			if err != nil {
+				return err
+			}
+			if feeSignParams == nil {
+				return fmt.Errorf("no fee payer for tx founded by %s", tx.Founder)
+			}
+			if err = signTx(tx, feeSignParams); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// txSigned reports whether every output script of tx carrying a public
+// key is signed.
+func txSigned(tx *pw.TX) bool {
+	for _, txout := range tx.Txout {
+		var sig pw.UTXOSignature
+		if json.Unmarshal(txout.Script, &sig) != nil {
+			return false
+		}
+		if sig.PublicKey != nil && len(sig.Signature) == 0 {
+			return false
+		}
+	}
+	return true
+}
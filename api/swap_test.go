@@ -0,0 +1,382 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/rest/api"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+const (
+	swapTokenOwner = "did:axn:001"
+	swapAssetOwner = "did:axn:002"
+)
+
+func swapSignParam(creator string) *pki.SignatureParam {
+	return &pki.SignatureParam{
+		Creator:    did.Identifier(creator),
+		Nonce:      "nonce-" + creator,
+		PrivateKey: testPrivateKey,
+	}
+}
+
+// newTestSwapBody returns the swap agreed by the parties of newTestSwap.
+func newTestSwapBody() *SwapBody {
+	return &SwapBody{
+		TokenOwner: swapTokenOwner,
+		Tokens:     []*sw.TokenAmount{{TokenId: "token-a", Amount: 50}},
+		AssetOwner: swapAssetOwner,
+		Assets:     []string{"asset-id-001"},
+	}
+}
+
+// newTestSwap returns a swap of one TX per leg plus their fee TXs.
+func newTestSwap(t *testing.T) *Swap {
+	return &Swap{
+		TokenOwner: swapTokenOwner,
+		AssetOwner: swapAssetOwner,
+		TokenTxs: []*pw.TX{
+			spending(intentTx(t, swapTokenOwner, out("token-a", 50, "endpoint-002"), out("token-a", 950, "endpoint-001")), "utxo-a:0"),
+			spending(intentTx(t, testPlatformDID, out("fee-token", 1, "endpoint-fee"), out("fee-token", 99, "endpoint-platform")), "utxo-fee:0"),
+		},
+		AssetTxs: []*pw.TX{
+			spending(intentTx(t, swapAssetOwner, out("asset-id-001", 1, "endpoint-001")), "utxo-asset:0"),
+			spending(intentTx(t, testPlatformDID, out("fee-token", 1, "endpoint-fee"), out("fee-token", 99, "endpoint-platform")), "utxo-fee:1"),
+		},
+	}
+}
+
+// initSwapClient inits a wallet client checking intents, and mocks the
+// wallet info and unspent outputs of the parties of newTestSwap.
+func initSwapClient(t *testing.T) *WalletClient {
+	return initSwapClientWithConfig(t, enterpriseConfig())
+}
+
+func initSwapClientWithConfig(t *testing.T, config *api.Config) *WalletClient {
+	w := newTestWalletClientWithConfig(t, config, WithIntentCheck(&IntentCheck{
+		FeeAddrs: []string{"endpoint-fee"},
+	}))
+	mockWalletInfo(t, swapTokenOwner, "endpoint-001")
+	mockWalletInfo(t, swapAssetOwner, "endpoint-002")
+	mockWalletInfo(t, testPlatformDID, "endpoint-platform")
+	mockUTXOs(t, swapTokenOwner,
+		&pw.UTXO{SourceTxDataHash: "utxo-a", Ix: "0", CTokenId: "token-a", Value: 1000, Addr: "endpoint-001"},
+	)
+	mockUTXOs(t, swapAssetOwner,
+		&pw.UTXO{SourceTxDataHash: "utxo-asset", Ix: "0", CTokenId: "asset-id-001", CType: 1, Value: 1, Addr: "endpoint-002"},
+	)
+	mockUTXOs(t, testPlatformDID,
+		&pw.UTXO{SourceTxDataHash: "utxo-fee", Ix: "0", CTokenId: "fee-token", Value: 100, Addr: "endpoint-platform"},
+		&pw.UTXO{SourceTxDataHash: "utxo-fee", Ix: "1", CTokenId: "fee-token", Value: 100, Addr: "endpoint-platform"},
+	)
+	return w
+}
+
+func TestSwapSucc(t *testing.T) {
+	//init gock & walletclient
+	defer gock.Off()
+	w := initSwapClient(t)
+
+	const token = "user-token-001"
+
+	prepared := newTestSwap(t)
+	body := newTestSwapBody()
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchBodyContains(`"from":"` + swapTokenOwner + `"`)).
+		Reply(200).
+		JSON(jsonPayload(t, prepared.TokenTxs))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/assets/transfer/prepare").
+		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchBodyContains(`"from":"` + swapAssetOwner + `"`)).
+		Reply(200).
+		JSON(jsonPayload(t, prepared.AssetTxs))
+	process := gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			swapTokenOwner:  swapSignParam(swapTokenOwner),
+			swapAssetOwner:  swapSignParam(swapAssetOwner),
+			testPlatformDID: platformSignParam(),
+		})).
+		AddMatcher(matchBodyContains(swapAssetOwner)).
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"tx-1", "tx-2", "tx-3", "tx-4"}}))
+
+	header := http.Header{}
+	header.Set("X-Auth-Token", token)
+
+	swap, err := w.PrepareSwap(header, body)
+	if err != nil {
+		t.Fatalf("prepare swap fail: %v", err)
+	}
+	if len(swap.TokenTxs) != 2 || len(swap.AssetTxs) != 2 {
+		t.Fatalf("swap should hold the TXs of both proposals")
+	}
+	if pending := swap.Pending(); len(pending) != 2 {
+		t.Fatalf("both parties should have to sign, got %v", pending)
+	}
+
+	//the token owner signs, then the asset owner signs offline
+	if err = w.SignSwap(header, swap, body, swapSignParam(swapTokenOwner)); err != nil {
+		t.Fatalf("token owner sign fail: %v", err)
+	}
+	data, err := json.Marshal(swap)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var offline Swap
+	if err = json.Unmarshal(data, &offline); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = w.SignSwap(header, &offline, newTestSwapBody(), swapSignParam(swapAssetOwner)); err != nil {
+		t.Fatalf("asset owner sign fail: %v", err)
+	}
+	if pending := offline.Pending(); len(pending) != 0 {
+		t.Fatalf("no party should have to sign, got %v", pending)
+	}
+
+	resp, err := w.SubmitSwap(header, &offline)
+	if err != nil {
+		t.Fatalf("submit swap fail: %v", err)
+	}
+	if len(resp.TransactionIds) != 4 {
+		t.Fatalf("both legs should be committed together")
+	}
+	if !process.Mock.Done() {
+		t.Fatalf("proposals and a single process request should be sent")
+	}
+}
+
+func TestSignSwapTampered(t *testing.T) {
+	//init gock & walletclient
+	defer gock.Off()
+	w := initSwapClient(t)
+
+	cases := []struct {
+		name   string
+		tamper func(swap *Swap, body *SwapBody)
+		kinds  []MismatchKind
+	}{
+		{"token leg redirected", func(swap *Swap, body *SwapBody) {
+			swap.TokenTxs[0].Txout[0].Addr = "endpoint-intruder"
+		}, []MismatchKind{MismatchRecipient, MismatchAmount}},
+		{"token leg underpaid", func(swap *Swap, body *SwapBody) {
+			swap.TokenTxs[0].Txout[0].Value = 5
+			swap.TokenTxs[0].Txout[1].Value = 995
+		}, []MismatchKind{MismatchAmount}},
+		{"other asset", func(swap *Swap, body *SwapBody) {
+			body.Assets = []string{"asset-id-002"}
+		}, []MismatchKind{MismatchToken, MismatchAmount}},
+		{"fee redirected", func(swap *Swap, body *SwapBody) {
+			swap.AssetTxs[1].Txout[0].Addr = "endpoint-intruder"
+		}, []MismatchKind{MismatchRecipient}},
+	}
+	for _, c := range cases {
+		swap, body := newTestSwap(t), newTestSwapBody()
+		c.tamper(swap, body)
+		err := w.SignSwap(nil, swap, body, swapSignParam(swapAssetOwner))
+		if kinds := mismatchKinds(t, err); !reflect.DeepEqual(kinds, c.kinds) {
+			t.Fatalf("%s: mismatches should be %v not %v", c.name, c.kinds, kinds)
+		}
+		if pending := swap.Pending(); len(pending) != 2 {
+			t.Fatalf("%s: tampered swap should not be signed, pending %v", c.name, pending)
+		}
+	}
+
+	swap := newTestSwap(t)
+	swap.AssetOwner = "did:axn:003"
+	if err := w.SignSwap(nil, swap, newTestSwapBody(), swapSignParam(swapTokenOwner)); err == nil {
+		t.Fatalf("err should not be nil when the swap parties differ from the agreed ones")
+	}
+	if err := w.SignSwap(nil, newTestSwap(t), nil, swapSignParam(swapTokenOwner)); err == nil {
+		t.Fatalf("err should not be nil when the agreed swap is missing")
+	}
+}
+
+func TestSignSwapSafeboxKey(t *testing.T) {
+	//init gock & walletclient
+	defer gock.Off()
+	config := enterpriseConfig()
+	config.TrusteeKeyPairEnable = true
+	w := initSwapClientWithConfig(t, config)
+
+	const securityCode = "security-code-001"
+
+	//the private key is looked up in safebox with the security code
+	query := gock.New("http://127.0.0.1:8006").
+		Post("/").
+		AddMatcher(matchBodyContains(securityCode)).
+		Reply(200).
+		JSON(jsonPayload(t, map[string]string{"private_key": testPrivateKey}))
+
+	swap := newTestSwap(t)
+	err := w.SignSwap(http.Header{}, swap, newTestSwapBody(), &pki.SignatureParam{
+		Creator:      swapTokenOwner,
+		Nonce:        "nonce-" + swapTokenOwner,
+		SecurityCode: securityCode,
+	})
+	if err != nil {
+		t.Fatalf("token owner sign with safebox key fail: %v", err)
+	}
+	if !query.Mock.Done() {
+		t.Fatalf("private key should be looked up in safebox")
+	}
+	if pending := swap.Pending(); !reflect.DeepEqual(pending, []string{swapAssetOwner}) {
+		t.Fatalf("only the asset owner should have to sign, got %v", pending)
+	}
+}
+
+func TestSwapInvalid(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	bodies := []*SwapBody{
+		nil,
+		{TokenOwner: swapTokenOwner, AssetOwner: swapAssetOwner, Assets: []string{"asset-id-001"}},
+		{TokenOwner: swapTokenOwner, AssetOwner: swapAssetOwner, Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}}},
+	}
+	for i, body := range bodies {
+		if _, err := walletClient.PrepareSwap(nil, body); err == nil {
+			t.Fatalf("body %d: err should not be nil when request payload invalid", i)
+		}
+	}
+
+	swap := newTestSwap(t)
+	if err := swap.sign(swapSignParam("did:axn:003")); err == nil {
+		t.Fatalf("err should not be nil when signer is not a party")
+	}
+	if err := swap.sign(swapSignParam(swapTokenOwner)); err != nil {
+		t.Fatalf("token owner sign fail: %v", err)
+	}
+	if _, err := walletClient.SubmitSwap(nil, swap); err == nil {
+		t.Fatalf("err should not be nil when the asset owner did not sign")
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("no request should be sent")
+	}
+}
+
+func TestSubmitSwapFail(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 3008, ErrMessage: "UTXOSpent"})
+
+	swap := newTestSwap(t)
+	swap.sign(swapSignParam(swapTokenOwner))
+	swap.sign(swapSignParam(swapAssetOwner))
+
+	resp, err := walletClient.SubmitSwap(nil, swap)
+	if resp != nil {
+		t.Fatalf("response should be nil when swap fail")
+	}
+	swapErr, ok := err.(*SwapError)
+	if !ok {
+		t.Fatalf("error should be a *SwapError not %v", err)
+	}
+	if swapErr.Leg != "" || swapErr.Committed != nil {
+		t.Fatalf("no leg should be reported committed")
+	}
+}
+
+func TestSubmitSwapSequential(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	//mock http request, the asset leg is processed first
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchBodyContains(swapAssetOwner)).
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"asset-tx"}}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchBodyContains(swapTokenOwner)).
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"token-tx"}}))
+
+	swap := newTestSwap(t)
+	swap.sign(swapSignParam(swapTokenOwner))
+	swap.sign(swapSignParam(swapAssetOwner))
+
+	resp, err := walletClient.SubmitSwapSequential(nil, swap)
+	if err != nil {
+		t.Fatalf("submit swap fail: %v", err)
+	}
+	if len(resp.TransactionIds) != 2 || resp.TransactionIds[0] != "asset-tx" {
+		t.Fatalf("response should list the asset then the token transactions, got %v", resp.TransactionIds)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("both legs should be processed")
+	}
+}
+
+func TestSubmitSwapSequentialFail(t *testing.T) {
+	//init gock & walletclient
+	initWalletClientWithEnterprise(t)
+	defer gock.Off()
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchBodyContains(swapAssetOwner)).
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"asset-tx"}}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchBodyContains(swapTokenOwner)).
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 5015, ErrMessage: "BalancesNotSufficient"})
+
+	swap := newTestSwap(t)
+	swap.sign(swapSignParam(swapTokenOwner))
+	swap.sign(swapSignParam(swapAssetOwner))
+
+	_, err := walletClient.SubmitSwapSequential(nil, swap)
+	swapErr, ok := err.(*SwapError)
+	if !ok {
+		t.Fatalf("error should be a *SwapError not %v", err)
+	}
+	if swapErr.Leg != SwapLegTokens {
+		t.Fatalf("failed leg should be %s not %s", SwapLegTokens, swapErr.Leg)
+	}
+	if swapErr.Committed == nil || swapErr.Committed.TransactionIds[0] != "asset-tx" {
+		t.Fatalf("committed asset leg should be reported")
+	}
+}
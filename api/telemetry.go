@@ -0,0 +1,205 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/arxanchain/sdk-go-common/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName is the name of the OpenTelemetry tracer and meter
+// used by WalletClient.
+const InstrumentationName = "github.com/arxanchain/wallet-sdk-go/api"
+
+// Attribute keys recorded on WalletClient spans and metrics.
+const (
+	AttrMethod   = attribute.Key("wallet.method")
+	AttrStatus   = attribute.Key("wallet.status")
+	AttrEndpoint = attribute.Key("wallet.endpoint")
+	AttrErrCode  = attribute.Key("wallet.errcode")
+	AttrDID      = attribute.Key("wallet.did")
+	AttrCreator  = attribute.Key("wallet.creator")
+	AttrFrom     = attribute.Key("wallet.from")
+	AttrTo       = attribute.Key("wallet.to")
+	AttrIssuer   = attribute.Key("wallet.issuer")
+	AttrOwner    = attribute.Key("wallet.owner")
+	AttrTokenID  = attribute.Key("wallet.token_id")
+	AttrTxIDs    = attribute.Key("wallet.tx_ids")
+	AttrTxCount  = attribute.Key("wallet.tx_count")
+)
+
+// Metric names recorded by WalletClient.
+const (
+	MetricDuration       = "wallet.client.duration"
+	MetricPlatformErrors = "wallet.client.platform_errors"
+	MetricUploadedBytes  = "wallet.client.uploaded_bytes"
+)
+
+// WithTracerProvider enables tracing, every WalletClient method will
+// start a span from a tracer of tp. Composite methods such as
+// IssueCToken or TransferAsset start child spans for each of their
+// steps (proposal, safebox key lookup, signing and processing).
+//
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(w *WalletClient) {
+		w.tracerProvider = tp
+	}
+}
+
+// WithMeterProvider enables metrics, WalletClient will record method
+// latencies, platform error codes and uploaded POE file sizes using a
+// meter of mp.
+//
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(w *WalletClient) {
+		w.meterProvider = mp
+	}
+}
+
+// WithContext returns a copy of the client whose methods start their
+// spans as children of ctx, to trace a single call within the trace of
+// the caller:
+//
+//	client.WithContext(ctx).TransferCToken(header, body, signParams)
+//
+// The copy shares the configuration of the client, it should not be
+// used to add middlewares or hooks.
+//
+func (w *WalletClient) WithContext(ctx context.Context) *WalletClient {
+	c := *w
+	c.ctx = ctx
+	return &c
+}
+
+// parentContext returns the context the operations of the client are
+// started from.
+func (w *WalletClient) parentContext() context.Context {
+	if w.ctx == nil {
+		return context.Background()
+	}
+	return w.ctx
+}
+
+// telemetry holds the tracer and metric instruments of a WalletClient.
+// Both default to no-op implementations.
+type telemetry struct {
+	tracer         trace.Tracer
+	duration       metric.Float64Histogram
+	platformErrors metric.Int64Counter
+	uploadedBytes  metric.Int64Counter
+}
+
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (t *telemetry, err error) {
+	if tp == nil {
+		tp = tracenoop.NewTracerProvider()
+	}
+	if mp == nil {
+		mp = metricnoop.NewMeterProvider()
+	}
+
+	t = &telemetry{
+		tracer: tp.Tracer(InstrumentationName),
+	}
+	meter := mp.Meter(InstrumentationName)
+	t.duration, err = meter.Float64Histogram(MetricDuration,
+		metric.WithDescription("Duration of wallet client methods"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	t.platformErrors, err = meter.Int64Counter(MetricPlatformErrors,
+		metric.WithDescription("Number of error codes returned by the wallet platform"))
+	if err != nil {
+		return nil, err
+	}
+	t.uploadedBytes, err = meter.Int64Counter(MetricUploadedBytes,
+		metric.WithDescription("Number of bytes uploaded as POE files"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// operation tracks the span and latency of a single WalletClient
+// method or step.
+type operation struct {
+	ctx   context.Context
+	span  trace.Span
+	name  string
+	start time.Time
+	t     *telemetry
+}
+
+// begin starts an operation named name as a child of ctx.
+func (w *WalletClient) begin(ctx context.Context, name string, attrs ...attribute.KeyValue) *operation {
+	ctx, span := w.telemetry.tracer.Start(ctx, "WalletClient."+name,
+		trace.WithAttributes(attrs...))
+	return &operation{
+		ctx:   ctx,
+		span:  span,
+		name:  name,
+		start: time.Now(),
+		t:     w.telemetry,
+	}
+}
+
+// setAttributes records attrs on the operation span.
+func (op *operation) setAttributes(attrs ...attribute.KeyValue) {
+	op.span.SetAttributes(attrs...)
+}
+
+// end finishes the operation, recording err on the span and latency.
+func (op *operation) end(err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+		op.span.RecordError(err)
+		op.span.SetStatus(codes.Error, err.Error())
+	}
+	op.t.duration.Record(op.ctx, time.Since(op.start).Seconds(),
+		metric.WithAttributes(AttrMethod.String(op.name), AttrStatus.String(status)))
+	op.span.End()
+}
+
+// recordResponse records the platform error code of a finished call.
+func (t *telemetry) recordResponse(call *Call) {
+	if call.Response == nil || call.Response.ErrCode == errors.SuccCode {
+		return
+	}
+	ctx := call.Context
+	trace.SpanFromContext(ctx).SetAttributes(AttrErrCode.Int(call.Response.ErrCode))
+	t.platformErrors.Add(ctx, 1, metric.WithAttributes(
+		AttrMethod.String(call.Name),
+		AttrEndpoint.String(call.Path),
+		AttrErrCode.Int(call.Response.ErrCode),
+	))
+}
+
+// recordUpload records the size of an uploaded POE file.
+func (t *telemetry) recordUpload(ctx context.Context, size int64) {
+	t.uploadedBytes.Add(ctx, size)
+}
@@ -0,0 +1,301 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func newTelemetryWalletClient(t *testing.T) (*WalletClient, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	c := newTestWalletClient(t, WithTracerProvider(tp), WithMeterProvider(mp))
+	return c, exporter, reader
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func hasAttribute(attrs []attribute.KeyValue, kv attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr.Key == kv.Key && attr.Value.Emit() == kv.Value.Emit() {
+			return true
+		}
+	}
+	return false
+}
+
+func collectMetric(t *testing.T, reader *sdkmetric.ManualReader, name string) *metricdata.Metrics {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collect metrics fail: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for i := range sm.Metrics {
+			if sm.Metrics[i].Name == name {
+				return &sm.Metrics[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestTelemetryTransferCTokenSpans(t *testing.T) {
+	//init gock & walletclient
+	client, exporter, reader := newTelemetryWalletClient(t)
+	defer gock.Off()
+
+	const (
+		token   = "user-token-001"
+		from    = "did:axn:001"
+		to      = "did:axn:002"
+		transID = "trans-id-001"
+	)
+
+	script, err := json.Marshal(&pw.UTXOSignature{PublicKey: []byte("public-key-001")})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	txs := []*pw.TX{
+		{
+			Founder: from,
+			Txout:   []*pw.TX_TXOUT{{Script: script}},
+		},
+	}
+	byTxs, err := json.Marshal(txs)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	byResult, err := json.Marshal(&wallet.WalletResponse{TransactionIds: []string{transID}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		MatchHeader("X-Auth-Token", token).
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 0, Payload: string(byTxs)})
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		MatchHeader("X-Auth-Token", token).
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 0, Payload: string(byResult)})
+
+	//set http header
+	header := http.Header{}
+	header.Set("X-Auth-Token", token)
+
+	reqBody := &wallet.TransferCTokenBody{
+		From: from,
+		To:   to,
+		Tokens: []*wallet.TokenAmount{
+			{
+				TokenId: "colored-token-id-001",
+				Amount:  500,
+			},
+		},
+	}
+	signParam := &pki.SignatureParam{
+		Creator:    from,
+		Nonce:      "helloalice",
+		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+	}
+	_, err = client.TransferCToken(header, reqBody, signParam)
+	if err != nil {
+		t.Fatalf("transfer colored token fail: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	root := findSpan(spans, "WalletClient.TransferCToken")
+	if root == nil {
+		t.Fatalf("TransferCToken span should be recorded")
+	}
+	if root.Parent.IsValid() {
+		t.Fatalf("TransferCToken span should be a root span")
+	}
+	if !hasAttribute(root.Attributes, AttrFrom.String(from)) || !hasAttribute(root.Attributes, AttrTo.String(to)) {
+		t.Fatalf("TransferCToken span should record from and to DIDs: %v", root.Attributes)
+	}
+	for _, name := range []string{"WalletClient.SendTransferCTokenProposal", "WalletClient.SignTxs", "WalletClient.ProcessTx"} {
+		step := findSpan(spans, name)
+		if step == nil {
+			t.Fatalf("%s span should be recorded", name)
+		}
+		if step.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Fatalf("%s span should be a child of TransferCToken", name)
+		}
+	}
+	process := findSpan(spans, "WalletClient.ProcessTx")
+	if !hasAttribute(process.Attributes, AttrTxIDs.StringSlice([]string{transID})) {
+		t.Fatalf("ProcessTx span should record tx ids: %v", process.Attributes)
+	}
+
+	m := collectMetric(t, reader, MetricDuration)
+	if m == nil {
+		t.Fatalf("%s metric should be recorded", MetricDuration)
+	}
+	hist, ok := m.Data.(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("%s metric should be a histogram", MetricDuration)
+	}
+	found := false
+	for _, dp := range hist.DataPoints {
+		if v, ok := dp.Attributes.Value(AttrMethod); ok && v.AsString() == "TransferCToken" {
+			found = dp.Count == 1
+		}
+	}
+	if !found {
+		t.Fatalf("TransferCToken latency should be recorded once")
+	}
+}
+
+func TestTelemetryPlatformErrCode(t *testing.T) {
+	//init gock & walletclient
+	client, exporter, reader := newTelemetryWalletClient(t)
+	defer gock.Off()
+
+	const (
+		errCode = 8005
+		errMsg  = "query wallet info fail"
+	)
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/info").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: errCode, ErrMessage: errMsg})
+
+	_, err := client.GetWalletInfo(http.Header{}, "did:axn:001")
+	if err == nil {
+		t.Fatalf("get wallet info should fail")
+	}
+
+	span := findSpan(exporter.GetSpans(), "WalletClient.GetWalletInfo")
+	if span == nil {
+		t.Fatalf("GetWalletInfo span should be recorded")
+	}
+	if span.Status.Code != codes.Error {
+		t.Fatalf("GetWalletInfo span status should be error")
+	}
+	if !hasAttribute(span.Attributes, AttrErrCode.Int(errCode)) {
+		t.Fatalf("GetWalletInfo span should record error code: %v", span.Attributes)
+	}
+
+	m := collectMetric(t, reader, MetricPlatformErrors)
+	if m == nil {
+		t.Fatalf("%s metric should be recorded", MetricPlatformErrors)
+	}
+	sum := m.Data.(metricdata.Sum[int64])
+	if len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 1 {
+		t.Fatalf("one platform error should be counted")
+	}
+	if v, _ := sum.DataPoints[0].Attributes.Value(AttrErrCode); v.AsInt64() != errCode {
+		t.Fatalf("platform error code should be %d", errCode)
+	}
+}
+
+func TestTelemetryParentContext(t *testing.T) {
+	//init gock & walletclient
+	client, exporter, _ := newTelemetryWalletClient(t)
+	defer gock.Off()
+
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/info").
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletInfo{Id: "did:axn:001"}))
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, parent := tp.Tracer("caller").Start(context.Background(), "caller")
+	if _, err := client.WithContext(ctx).GetWalletInfo(http.Header{}, "did:axn:001"); err != nil {
+		t.Fatalf("get wallet info fail: %v", err)
+	}
+	parent.End()
+
+	span := findSpan(exporter.GetSpans(), "WalletClient.GetWalletInfo")
+	if span == nil {
+		t.Fatalf("GetWalletInfo span should be recorded")
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() || span.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+		t.Fatalf("GetWalletInfo span should be a child of the caller span")
+	}
+}
+
+func TestTelemetryUploadedBytes(t *testing.T) {
+	//init gock & walletclient
+	client, _, reader := newTelemetryWalletClient(t)
+	defer gock.Off()
+
+	poeFile, err := createFile()
+	if err != nil {
+		t.Fatalf("create tmp file fail: %v", err)
+	}
+	defer os.Remove(poeFile) // clean up
+	info, err := os.Stat(poeFile)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	byPayload, err := json.Marshal(&wallet.UploadResponse{Id: "did:axn:poe-id-001"})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/poe/upload").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 0, Payload: string(byPayload)})
+
+	_, err = client.UploadPOEFile(http.Header{}, "did:axn:poe-id-001", poeFile, false)
+	if err != nil {
+		t.Fatalf("upload poe file fail: %v", err)
+	}
+
+	m := collectMetric(t, reader, MetricUploadedBytes)
+	if m == nil {
+		t.Fatalf("%s metric should be recorded", MetricUploadedBytes)
+	}
+	sum := m.Data.(metricdata.Sum[int64])
+	if len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != info.Size() {
+		t.Fatalf("uploaded bytes should be %d", info.Size())
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -26,8 +27,6 @@ import (
 	"github.com/arxanchain/sdk-go-common/errors"
 	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
 	"github.com/arxanchain/sdk-go-common/rest"
-	restapi "github.com/arxanchain/sdk-go-common/rest/api"
-	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
 	"github.com/arxanchain/sdk-go-common/structs/did"
 	"github.com/arxanchain/sdk-go-common/structs/pki"
 	"github.com/arxanchain/sdk-go-common/structs/wallet"
@@ -49,38 +48,47 @@ import (
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) IssueCToken(header http.Header, body *wallet.IssueBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "IssueCToken")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
+	op.setAttributes(AttrIssuer.String(body.Issuer), AttrOwner.String(body.Owner))
 
 	if w.s != nil {
-		signParams, err = w.queryPrivateKey(header, signParams)
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
 		if err != nil {
 			return
 		}
 	}
 
 	// 1 send transfer proposal to get wallet.Tx
-	issuePreRsp, err := w.SendIssueCTokenProposal(header, body)
+	issuePreRsp, err := w.sendIssueCTokenProposal(op.ctx, header, body)
 	if err != nil {
 		return nil, err
 	}
 	txs := issuePreRsp.Txs
+	if w.intent != nil {
+		if err = w.checkIntent(op.ctx, header, body, issuePreRsp.TokenId, txs); err != nil {
+			return nil, err
+		}
+	}
 
 	// 2 sign public key as signature
-	err = w.SignTxs(txs, signParams)
+	err = w.signTxs(op.ctx, header, txs, signParams)
 	if err != nil {
 		err = fmt.Errorf("sign Txs error: %v", err)
 		return nil, err
 	}
 
 	// 3 call ProcessTx to transfer formally
-	result, err = w.ProcessTx(header, txs)
+	result, err = w.processTx(op.ctx, header, txs)
 	if err != nil {
 		return nil, err
 	}
 	result.TokenId = issuePreRsp.TokenId
+	op.setAttributes(AttrTokenID.String(result.TokenId))
 	return result, nil
 }
 
@@ -97,28 +105,35 @@ func (w *WalletClient) IssueCToken(header http.Header, body *wallet.IssueBody, s
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) SendIssueCTokenProposal(header http.Header, body *wallet.IssueBody) (issueRsp *wallet.IssueCTokenPrepareResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	return w.sendIssueCTokenProposal(w.parentContext(), header, body)
+}
+
+func (w *WalletClient) sendIssueCTokenProposal(ctx context.Context, header http.Header, body *wallet.IssueBody) (issueRsp *wallet.IssueCTokenPrepareResponse, err error) {
+	op := w.begin(ctx, "SendIssueCTokenProposal")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return nil, err
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v2/transaction/tokens/issue/prepare")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "SendIssueCTokenProposal",
+		Method:  "POST",
+		Path:    "/v2/transaction/tokens/issue/prepare",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return nil, err
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -154,33 +169,41 @@ func (w *WalletClient) SendIssueCTokenProposal(header http.Header, body *wallet.
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) IssueAsset(header http.Header, body *wallet.IssueAssetBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "IssueAsset")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
+	op.setAttributes(AttrIssuer.String(body.Issuer), AttrOwner.String(body.Owner))
 
 	if w.s != nil {
-		signParams, err = w.queryPrivateKey(header, signParams)
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
 		if err != nil {
 			return
 		}
 	}
 
 	// 1 send proposal to get wallet.Tx
-	txs, err := w.SendIssueAssetProposal(header, body)
+	txs, err := w.sendIssueAssetProposal(op.ctx, header, body)
 	if err != nil {
 		return nil, err
 	}
+	if w.intent != nil {
+		if err = w.checkIntent(op.ctx, header, body, "", txs); err != nil {
+			return nil, err
+		}
+	}
 
 	// 2 sign public key as signature
-	err = w.SignTxs(txs, signParams)
+	err = w.signTxs(op.ctx, header, txs, signParams)
 	if err != nil {
 		err = fmt.Errorf("sign Txs error: %v", err)
 		return nil, err
 	}
 
 	// 3 call ProcessTx to transfer formally
-	return w.ProcessTx(header, txs)
+	return w.processTx(op.ctx, header, txs)
 }
 
 // SendIssueAssetProposal is used to send issue asset proposal to get wallet.Tx to be signed.
@@ -196,28 +219,35 @@ func (w *WalletClient) IssueAsset(header http.Header, body *wallet.IssueAssetBod
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) SendIssueAssetProposal(header http.Header, body *wallet.IssueAssetBody) (result []*pw.TX, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	return w.sendIssueAssetProposal(w.parentContext(), header, body)
+}
+
+func (w *WalletClient) sendIssueAssetProposal(ctx context.Context, header http.Header, body *wallet.IssueAssetBody) (result []*pw.TX, err error) {
+	op := w.begin(ctx, "SendIssueAssetProposal")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return nil, err
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v2/transaction/assets/issue/prepare")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "SendIssueAssetProposal",
+		Method:  "POST",
+		Path:    "/v2/transaction/assets/issue/prepare",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return nil, err
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -252,33 +282,52 @@ func (w *WalletClient) SendIssueAssetProposal(header http.Header, body *wallet.I
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) TransferCToken(header http.Header, body *wallet.TransferCTokenBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "TransferCToken")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
+	op.setAttributes(AttrFrom.String(body.From), AttrTo.String(body.To))
 
 	if w.s != nil {
-		signParams, err = w.queryPrivateKey(header, signParams)
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
 		if err != nil {
 			return
 		}
 	}
 
+	return w.transferCToken(op.ctx, header, body, signParams)
+}
+
+// transferCToken proposes, signs and processes the transfer of body.
+func (w *WalletClient) transferCToken(ctx context.Context, header http.Header, body *wallet.TransferCTokenBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
 	// 1 send transfer proposal to get wallet.Tx
-	txs, err := w.SendTransferCTokenProposal(header, body)
+	txs, err := w.sendTransferCTokenProposal(ctx, header, body)
 	if err != nil {
 		return nil, err
 	}
+	return w.transferCTokenTxs(ctx, header, body, txs, signParams)
+}
+
+// transferCTokenTxs checks, signs and processes the proposed txs of a
+// transfer colored tokens.
+func (w *WalletClient) transferCTokenTxs(ctx context.Context, header http.Header, body *wallet.TransferCTokenBody, txs []*pw.TX, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
+	if w.intent != nil {
+		if err = w.checkIntent(ctx, header, body, "", txs); err != nil {
+			return nil, err
+		}
+	}
 
 	// 2 sign public key as signature
-	err = w.SignTxs(txs, signParams)
+	err = w.signTxs(ctx, header, txs, signParams)
 	if err != nil {
 		err = fmt.Errorf("sign Txs error: %v", err)
 		return nil, err
 	}
 
 	// 3 call ProcessTx to transfer formally
-	return w.ProcessTx(header, txs)
+	return w.processTx(ctx, header, txs)
 }
 
 // SendTransferCTokenProposal is used to send transfer colored tokens proposal to get wallet.Tx to be signed.
@@ -294,28 +343,35 @@ func (w *WalletClient) TransferCToken(header http.Header, body *wallet.TransferC
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) SendTransferCTokenProposal(header http.Header, body *wallet.TransferCTokenBody) (result []*pw.TX, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	return w.sendTransferCTokenProposal(w.parentContext(), header, body)
+}
+
+func (w *WalletClient) sendTransferCTokenProposal(ctx context.Context, header http.Header, body *wallet.TransferCTokenBody) (result []*pw.TX, err error) {
+	op := w.begin(ctx, "SendTransferCTokenProposal")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return nil, err
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v2/transaction/tokens/transfer/prepare")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "SendTransferCTokenProposal",
+		Method:  "POST",
+		Path:    "/v2/transaction/tokens/transfer/prepare",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return nil, err
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -350,33 +406,46 @@ func (w *WalletClient) SendTransferCTokenProposal(header http.Header, body *wall
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) TransferAsset(header http.Header, body *wallet.TransferAssetBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "TransferAsset")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
+	op.setAttributes(AttrFrom.String(body.From), AttrTo.String(body.To))
 
 	if w.s != nil {
-		signParams, err = w.queryPrivateKey(header, signParams)
+		signParams, err = w.queryPrivateKey(op.ctx, header, signParams)
 		if err != nil {
 			return
 		}
 	}
 
+	return w.transferAsset(op.ctx, header, body, signParams)
+}
+
+// transferAsset proposes, signs and processes the transfer of body.
+func (w *WalletClient) transferAsset(ctx context.Context, header http.Header, body *wallet.TransferAssetBody, signParams *pki.SignatureParam) (result *wallet.WalletResponse, err error) {
 	// 1 send transfer proposal to get wallet.Tx
-	txs, err := w.SendTransferAssetProposal(header, body)
+	txs, err := w.sendTransferAssetProposal(ctx, header, body)
 	if err != nil {
 		return nil, err
 	}
+	if w.intent != nil {
+		if err = w.checkIntent(ctx, header, body, "", txs); err != nil {
+			return nil, err
+		}
+	}
 
 	// 2 sign public key as signature
-	err = w.SignTxs(txs, signParams)
+	err = w.signTxs(ctx, header, txs, signParams)
 	if err != nil {
 		err = fmt.Errorf("sign Txs error: %v", err)
 		return nil, err
 	}
 
 	// 3 call ProcessTx to transfer formally
-	return w.ProcessTx(header, txs)
+	return w.processTx(ctx, header, txs)
 }
 
 // SendTransferAssetProposal is used to send transfer asset proposal to get wallet.Tx to be signed.
@@ -392,28 +461,35 @@ func (w *WalletClient) TransferAsset(header http.Header, body *wallet.TransferAs
 // If you had trust the key pair, it will required security code.
 //
 func (w *WalletClient) SendTransferAssetProposal(header http.Header, body *wallet.TransferAssetBody) (result []*pw.TX, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	return w.sendTransferAssetProposal(w.parentContext(), header, body)
+}
+
+func (w *WalletClient) sendTransferAssetProposal(ctx context.Context, header http.Header, body *wallet.TransferAssetBody) (result []*pw.TX, err error) {
+	op := w.begin(ctx, "SendTransferAssetProposal")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return nil, err
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v2/transaction/assets/transfer/prepare")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "SendTransferAssetProposal",
+		Method:  "POST",
+		Path:    "/v2/transaction/assets/transfer/prepare",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return nil, err
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -435,18 +511,21 @@ func (w *WalletClient) SendTransferAssetProposal(header http.Header, body *walle
 // SignTxs is used to sign multiple UTXOs
 //
 func (w *WalletClient) SignTxs(txs []*pw.TX, signParams *pki.SignatureParam) (err error) {
-	signCreator := string(signParams.Creator)
-	for _, tx := range txs {
-		if tx.Founder != signCreator {
-			// sign fee by platform private key
-			platformSignParams, err := w.c.GetEnterpriseSignParam()
-			if err != nil {
-				return err
-			}
-
-			w.SignTx(tx, platformSignParams)
-		} else {
-			w.SignTx(tx, signParams)
+	return w.signTxs(w.parentContext(), nil, txs, signParams)
+}
+
+func (w *WalletClient) signTxs(ctx context.Context, header http.Header, txs []*pw.TX, signParams *pki.SignatureParam) (err error) {
+	op := w.begin(ctx, "SignTxs", AttrTxCount.Int(len(txs)))
+	defer func() { op.end(err) }()
+
+	plan, err := w.planSignTxs(op.ctx, header, txs, signParams)
+	if err != nil {
+		return err
+	}
+	for _, signer := range plan {
+		err = signTx(signer.Tx, signer.signParams)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -455,6 +534,13 @@ func (w *WalletClient) SignTxs(txs []*pw.TX, signParams *pki.SignatureParam) (er
 // SignTx is used to sign single UTXO
 //
 func (w *WalletClient) SignTx(tx *pw.TX, signParams *pki.SignatureParam) (err error) {
+	op := w.begin(w.parentContext(), "SignTx")
+	defer func() { op.end(err) }()
+
+	return signTx(tx, signParams)
+}
+
+func signTx(tx *pw.TX, signParams *pki.SignatureParam) (err error) {
 	for _, txout := range tx.Txout {
 		if txout.Script == nil {
 			err = fmt.Errorf("script is nil, no need to sign")
@@ -469,12 +555,13 @@ func (w *WalletClient) SignTx(tx *pw.TX, signParams *pki.SignatureParam) (err er
 		if utxoSignature.PublicKey == nil {
 			continue
 		}
-		signatureBody, err := buildSignatureBodyBase(signParams, utxoSignature.PublicKey)
+		signature, err := buildSignature(signParams, utxoSignature.PublicKey)
 		if err != nil {
 			err = fmt.Errorf("sign error: %v", err)
 			return err
 		}
-		utxoSignature.Signature = []byte(signatureBody.SignatureValue)
+		// Keep the raw signature bytes, they are base64 encoded in JSON
+		utxoSignature.Signature = signature.Sign
 		utxoSignature.Nonce = signParams.Nonce
 		utxoSignature.Creator = string(signParams.Creator)
 		signData, err := json.Marshal(utxoSignature)
@@ -488,6 +575,12 @@ func (w *WalletClient) SignTx(tx *pw.TX, signParams *pki.SignatureParam) (err er
 
 // ProcessTx is used to transfer formally with signature TX
 func (w *WalletClient) ProcessTx(header http.Header, txs []*pw.TX) (result *wallet.WalletResponse, err error) {
+	return w.processTx(w.parentContext(), header, txs)
+}
+
+func (w *WalletClient) processTx(ctx context.Context, header http.Header, txs []*pw.TX) (result *wallet.WalletResponse, err error) {
+	op := w.begin(ctx, "ProcessTx", AttrTxCount.Int(len(txs)))
+	defer func() { op.end(err) }()
 
 	if txs == nil {
 		err = fmt.Errorf("request payload invalid")
@@ -495,27 +588,28 @@ func (w *WalletClient) ProcessTx(header http.Header, txs []*pw.TX) (result *wall
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v2/transaction/process")
-	r.SetHeaders(header)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "ProcessTx",
+		Method:  "POST",
+		Path:    "/v2/transaction/process",
+		Header:  header,
+	}
 
 	// Build request payload
 	txBody := &wallet.ProcessTxBody{
 		Txs: txs,
 	}
-	r.SetBody(txBody)
+	call.Body = txBody
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return nil, err
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -531,6 +625,9 @@ func (w *WalletClient) ProcessTx(header http.Header, txs []*pw.TX) (result *wall
 	if err != nil {
 		return nil, err
 	}
+	if result != nil {
+		op.setAttributes(AttrTxIDs.StringSlice(result.TransactionIds))
+	}
 	return result, nil
 }
 
@@ -543,6 +640,9 @@ func (w *WalletClient) ProcessTx(header http.Header, txs []*pw.TX) (result *wall
 // num, page: count and page to be returned
 //
 func (w *WalletClient) QueryTransactionLogs(header http.Header, id did.Identifier, txType string, num, page int32) (result []*pw.UTXO, err error) {
+	op := w.begin(w.parentContext(), "QueryTransactionLogs", AttrDID.String(string(id)))
+	defer func() { op.end(err) }()
+
 	fmt.Printf("*****in wallet-sdk-go id: %v, txType: %v, num: %v, page: %v\n", id, txType, num, page)
 	if id == "" {
 		err = fmt.Errorf("request id invalid")
@@ -558,25 +658,26 @@ func (w *WalletClient) QueryTransactionLogs(header http.Header, id did.Identifie
 	numStr := strconv.Itoa(int(num))
 	pageStr := strconv.Itoa(int(page))
 	// Build http request
-	r := w.c.NewRequest("GET", "/v2/transaction/logs")
-	r.SetHeaders(header)
-	r.SetParam("id", string(id))
-	r.SetParam("type", txType)
-	r.SetParam("num", numStr)
-	r.SetParam("page", pageStr)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "QueryTransactionLogs",
+		Method:  "GET",
+		Path:    "/v2/transaction/logs",
+		Header:  header,
+	}
+	call.SetParam("id", string(id))
+	call.SetParam("type", txType)
+	call.SetParam("num", numStr)
+	call.SetParam("page", pageStr)
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -599,6 +700,13 @@ func (w *WalletClient) QueryTransactionLogs(header http.Header, id did.Identifie
 // num, page: count and page to be returned
 //
 func (w *WalletClient) QueryTransactionUTXO(header http.Header, id did.Identifier, num, page int32) (result []*pw.UTXO, err error) {
+	return w.queryTransactionUTXO(w.parentContext(), header, id, num, page)
+}
+
+func (w *WalletClient) queryTransactionUTXO(ctx context.Context, header http.Header, id did.Identifier, num, page int32) (result []*pw.UTXO, err error) {
+	op := w.begin(ctx, "QueryTransactionUTXO", AttrDID.String(string(id)))
+	defer func() { op.end(err) }()
+
 	if id == "" {
 		err = fmt.Errorf("request id invalid")
 		return
@@ -613,24 +721,25 @@ func (w *WalletClient) QueryTransactionUTXO(header http.Header, id did.Identifie
 	numStr := strconv.Itoa(int(num))
 	pageStr := strconv.Itoa(int(page))
 	// Build http request
-	r := w.c.NewRequest("GET", "/v2/transaction/utxo")
-	r.SetHeaders(header)
-	r.SetParam("id", string(id))
-	r.SetParam("num", numStr)
-	r.SetParam("page", pageStr)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "QueryTransactionUTXO",
+		Method:  "GET",
+		Path:    "/v2/transaction/utxo",
+		Header:  header,
+	}
+	call.SetParam("id", string(id))
+	call.SetParam("num", numStr)
+	call.SetParam("page", pageStr)
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -653,6 +762,9 @@ func (w *WalletClient) QueryTransactionUTXO(header http.Header, id did.Identifie
 // num, page: count and page to be returned
 //
 func (w *WalletClient) QueryTransactionSTXO(header http.Header, id did.Identifier, num, page int32) (result []*pw.UTXO, err error) {
+	op := w.begin(w.parentContext(), "QueryTransactionSTXO", AttrDID.String(string(id)))
+	defer func() { op.end(err) }()
+
 	if id == "" {
 		err = fmt.Errorf("request id invalid")
 		return
@@ -667,24 +779,25 @@ func (w *WalletClient) QueryTransactionSTXO(header http.Header, id did.Identifie
 	numStr := strconv.Itoa(int(num))
 	pageStr := strconv.Itoa(int(page))
 	// Build http request
-	r := w.c.NewRequest("GET", "/v2/transaction/stxo")
-	r.SetHeaders(header)
-	r.SetParam("id", string(id))
-	r.SetParam("num", numStr)
-	r.SetParam("page", pageStr)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "QueryTransactionSTXO",
+		Method:  "GET",
+		Path:    "/v2/transaction/stxo",
+		Header:  header,
+	}
+	call.SetParam("id", string(id))
+	call.SetParam("num", numStr)
+	call.SetParam("page", pageStr)
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
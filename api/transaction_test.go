@@ -17,7 +17,10 @@ limitations under the License.
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"reflect"
 	"strings"
@@ -25,21 +28,153 @@ import (
 
 	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
 	"github.com/arxanchain/sdk-go-common/rest"
+	"github.com/arxanchain/sdk-go-common/rest/api"
 	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
 	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
 	gock "gopkg.in/h2non/gock.v1"
 )
 
-/*
+const (
+	testPrivateKey    = "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg=="
+	testPlatformDID   = "did:axn:platform"
+	testPlatformNonce = "platform-nonce"
+)
+
+// initWalletClientWithEnterprise inits the wallet client with the
+// enterprise sign params used to sign platform fee TXs.
+func initWalletClientWithEnterprise(t *testing.T) {
+	walletClient = newTestWalletClientWithConfig(t, enterpriseConfig())
+}
+
+// enterpriseConfig returns a client config with the enterprise sign
+// params used to sign platform fee TXs.
+func enterpriseConfig() *api.Config {
+	return &api.Config{
+		EnterpriseSignParam: &api.EnterpriseSignParam{
+			Creator:    testPlatformDID,
+			Nonce:      testPlatformNonce,
+			PrivateKey: testPrivateKey,
+		},
+	}
+}
+
+// platformSignParam returns the sign params of platform fee TXs.
+func platformSignParam() *pki.SignatureParam {
+	return &pki.SignatureParam{
+		Creator:    testPlatformDID,
+		Nonce:      testPlatformNonce,
+		PrivateKey: testPrivateKey,
+	}
+}
+
+// newTestTx returns a TX founded by founder, spending one UTXO, with one
+// output per value whose script carries the public key to be signed.
+func newTestTx(t *testing.T, founder string, values ...int64) *pw.TX {
+	tx := &pw.TX{
+		Founder: founder,
+		Txin: []*pw.TX_TXIN{
+			&pw.TX_TXIN{
+				SourceTxDataHash: "source-tx-data-hash-" + founder,
+				Ix:               0,
+			},
+		},
+	}
+	for i, value := range values {
+		script, err := json.Marshal(&pw.UTXOSignature{
+			PublicKey: []byte(fmt.Sprintf("public-key-%s-%d", founder, i)),
+		})
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		tx.Txout = append(tx.Txout, &pw.TX_TXOUT{
+			CTokenId: "ctoken-id-001",
+			CType:    0,
+			Value:    value,
+			Addr:     fmt.Sprintf("endpoint-%d", i),
+			Until:    -1,
+			Script:   script,
+		})
+	}
+	return tx
+}
+
+// jsonPayload returns a success response carrying payload.
+func jsonPayload(t *testing.T, payload interface{}) *rtstructs.Response {
+	byPayload, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return &rtstructs.Response{
+		ErrCode: 0,
+		Payload: string(byPayload),
+	}
+}
+
+// matchSignedTxs is a gock matcher checking that every output script of
+// the processed TXs is signed with the sign params of the TX founder.
+func matchSignedTxs(signers map[string]*pki.SignatureParam) gock.MatchFunc {
+	return func(req *http.Request, ereq *gock.Request) (bool, error) {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return false, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+		var body sw.ProcessTxBody
+		if err = json.Unmarshal(data, &body); err != nil {
+			return false, err
+		}
+		for _, tx := range body.Txs {
+			signParams, ok := signers[tx.Founder]
+			if !ok {
+				return false, fmt.Errorf("unexpected tx founder %s", tx.Founder)
+			}
+			for _, txout := range tx.Txout {
+				var sig pw.UTXOSignature
+				if err = json.Unmarshal(txout.Script, &sig); err != nil {
+					return false, err
+				}
+				expected, err := buildSignature(signParams, sig.PublicKey)
+				if err != nil {
+					return false, err
+				}
+				if sig.Creator != string(signParams.Creator) || sig.Nonce != signParams.Nonce {
+					return false, fmt.Errorf("tx of %s signed by %s with nonce %s", tx.Founder, sig.Creator, sig.Nonce)
+				}
+				if !bytes.Equal(sig.Signature, expected.Sign) {
+					return false, fmt.Errorf("tx of %s signature invalid", tx.Founder)
+				}
+			}
+		}
+		return true, nil
+	}
+}
+
+// matchBodyContains is a gock matcher checking that the request body
+// contains s.
+func matchBodyContains(s string) gock.MatchFunc {
+	return func(req *http.Request, ereq *gock.Request) (bool, error) {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return false, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+		return strings.Contains(string(data), s), nil
+	}
+}
+
 func TestIssueCTokenSucc(t *testing.T) {
 	//init gock & walletclient
-	initWalletClient(t)
+	initWalletClientWithEnterprise(t)
 	defer gock.Off()
 
 	const (
 		token    = "user-token-001"
 		ctokenID = "colored-token-id-001"
 		transID  = "trans-id-001"
+		feeID    = "trans-id-002"
 	)
 
 	//request body & response body
@@ -50,21 +185,19 @@ func TestIssueCTokenSucc(t *testing.T) {
 		Amount:  1000,
 	}
 	signParam := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
-	payload := &sw.WalletResponse{
-		TokenId:        ctokenID,
-		TransactionIds: []string{transID},
-	}
-	byPayload, err := json.Marshal(payload)
-	if err != nil {
-		t.Fatalf("%v", err)
+	prepared := &sw.IssueCTokenPrepareResponse{
+		TokenId: ctokenID,
+		Txs: []*pw.TX{
+			newTestTx(t, "did:axn:001", 1000),
+			newTestTx(t, testPlatformDID, 1, 99),
+		},
 	}
-	respBody := &rtstructs.Response{
-		ErrCode: 0,
-		Payload: string(byPayload),
+	payload := &sw.WalletResponse{
+		TransactionIds: []string{transID, feeID},
 	}
 
 	//mock http request
@@ -72,12 +205,16 @@ func TestIssueCTokenSucc(t *testing.T) {
 		Post("/v2/transaction/tokens/issue/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
-		JSON(respBody)
+		JSON(jsonPayload(t, prepared))
 	gock.New("http://127.0.0.1:8006").
 		Post("/v2/transaction/process").
 		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001":   signParam,
+			testPlatformDID: platformSignParam(),
+		})).
 		Reply(200).
-		JSON(respBody)
+		JSON(jsonPayload(t, payload))
 
 	//set http header
 	header := http.Header{}
@@ -91,8 +228,8 @@ func TestIssueCTokenSucc(t *testing.T) {
 	if resp == nil {
 		t.Fatalf("response should not be nil")
 	}
-	if len(resp.TransactionIds) == 0 {
-		t.Fatalf("response transaction list should not be empty")
+	if len(resp.TransactionIds) != 2 {
+		t.Fatalf("response transaction list should contain issue and fee transactions")
 	}
 	if resp.TransactionIds[0] != transID {
 		t.Fatalf("response transaction id should be %v", transID)
@@ -100,6 +237,9 @@ func TestIssueCTokenSucc(t *testing.T) {
 	if resp.TokenId != ctokenID {
 		t.Fatalf("response colored token id should be %v", ctokenID)
 	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal and process requests should be sent")
+	}
 }
 
 func TestIssueCTokenFail(t *testing.T) {
@@ -121,9 +261,9 @@ func TestIssueCTokenFail(t *testing.T) {
 		Amount:  1000,
 	}
 	signParam := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -132,7 +272,7 @@ func TestIssueCTokenFail(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/tokens/issue").
+		Post("/v2/transaction/tokens/issue/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(errCode).
 		JSON(respBody)
@@ -173,9 +313,9 @@ func TestIssueCTokenFailErrCode(t *testing.T) {
 		Amount:  1000,
 	}
 	signParam := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -184,7 +324,7 @@ func TestIssueCTokenFailErrCode(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/tokens/issue").
+		Post("/v2/transaction/tokens/issue/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
 		JSON(respBody)
@@ -216,13 +356,12 @@ func TestIssueCTokenFailErrCode(t *testing.T) {
 
 func TestIssueAssetSucc(t *testing.T) {
 	//init gock & walletclient
-	initWalletClient(t)
+	initWalletClientWithEnterprise(t)
 	defer gock.Off()
 
 	const (
-		token    = "user-token-001"
-		ctokenID = "colored-token-id-001"
-		transID  = "trans-id-001"
+		token   = "user-token-001"
+		transID = "trans-id-001"
 	)
 
 	//request body & response body
@@ -232,29 +371,33 @@ func TestIssueAssetSucc(t *testing.T) {
 		AssetId: "asset-id-001",
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
+	}
+	prepared := []*pw.TX{
+		newTestTx(t, "did:axn:001", 1),
+		newTestTx(t, testPlatformDID, 1, 99),
 	}
 	payload := &sw.WalletResponse{
-		TokenId:        ctokenID,
 		TransactionIds: []string{transID},
 	}
-	byPayload, err := json.Marshal(payload)
-	if err != nil {
-		t.Fatalf("%v", err)
-	}
-	respBody := &rtstructs.Response{
-		ErrCode: 0,
-		Payload: string(byPayload),
-	}
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/assets/issue").
+		Post("/v2/transaction/assets/issue/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
-		JSON(respBody)
+		JSON(jsonPayload(t, prepared))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001":   sign,
+			testPlatformDID: platformSignParam(),
+		})).
+		Reply(200).
+		JSON(jsonPayload(t, payload))
 
 	//set http header
 	header := http.Header{}
@@ -263,7 +406,7 @@ func TestIssueAssetSucc(t *testing.T) {
 	//do issue digital asset
 	resp, err := walletClient.IssueAsset(header, reqBody, sign)
 	if err != nil {
-		t.Fatalf("issue colored token fail: %v", err)
+		t.Fatalf("issue digital asset fail: %v", err)
 	}
 	if resp == nil {
 		t.Fatalf("response should not be nil")
@@ -274,8 +417,8 @@ func TestIssueAssetSucc(t *testing.T) {
 	if resp.TransactionIds[0] != transID {
 		t.Fatalf("response transaction id should be %v", transID)
 	}
-	if resp.TokenId != ctokenID {
-		t.Fatalf("response colored token id should be %v", ctokenID)
+	if !gock.IsDone() {
+		t.Fatalf("proposal and process requests should be sent")
 	}
 }
 
@@ -297,9 +440,9 @@ func TestIssueAssetFail(t *testing.T) {
 		AssetId: "asset-id-001",
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -308,7 +451,7 @@ func TestIssueAssetFail(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/assets/issue").
+		Post("/v2/transaction/assets/issue/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(errCode).
 		JSON(respBody)
@@ -317,7 +460,7 @@ func TestIssueAssetFail(t *testing.T) {
 	header := http.Header{}
 	header.Set("X-Auth-Token", token)
 
-	// do issue digital asset
+	//do issue digital asset
 	resp, err := walletClient.IssueAsset(header, reqBody, sign)
 	if err == nil {
 		t.Fatalf("err should not be nil when issue digital asset fail")
@@ -348,9 +491,9 @@ func TestIssueAssetFailErrCode(t *testing.T) {
 		AssetId: "asset-id-001",
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -359,7 +502,7 @@ func TestIssueAssetFailErrCode(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/assets/issue").
+		Post("/v2/transaction/assets/issue/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
 		JSON(respBody)
@@ -368,7 +511,7 @@ func TestIssueAssetFailErrCode(t *testing.T) {
 	header := http.Header{}
 	header.Set("X-Auth-Token", token)
 
-	// do issue digital asset
+	//do issue digital asset
 	resp, err := walletClient.IssueAsset(header, reqBody, sign)
 	if err == nil {
 		t.Fatalf("err should not be nil when issue digital asset fail")
@@ -383,7 +526,6 @@ func TestIssueAssetFailErrCode(t *testing.T) {
 	if errWitherrCode.Error() != errMsg {
 		t.Fatalf("Error message should be %s", errMsg)
 	}
-
 	if resp != nil {
 		t.Fatalf("response object should be nil when issue digital asset fail")
 	}
@@ -391,7 +533,7 @@ func TestIssueAssetFailErrCode(t *testing.T) {
 
 func TestTransferCTokenSucc(t *testing.T) {
 	//init gock & walletclient
-	initWalletClient(t)
+	initWalletClientWithEnterprise(t)
 	defer gock.Off()
 
 	const (
@@ -405,35 +547,40 @@ func TestTransferCTokenSucc(t *testing.T) {
 		To:      "did:axn:002",
 		AssetId: "asset-id-001",
 		Tokens: []*sw.TokenAmount{
-			{
-				TokenId: "colored-token-id-001",
-				Amount:  500,
+			&sw.TokenAmount{
+				TokenId: "token-id-001",
+				Amount:  50,
 			},
 		},
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
+	}
+	prepared := []*pw.TX{
+		newTestTx(t, "did:axn:001", 50, 950),
+		newTestTx(t, testPlatformDID, 1, 99),
 	}
 	payload := &sw.WalletResponse{
 		TransactionIds: []string{transID},
 	}
-	byPayload, err := json.Marshal(payload)
-	if err != nil {
-		t.Fatalf("%v", err)
-	}
-	respBody := &rtstructs.Response{
-		ErrCode: 0,
-		Payload: string(byPayload),
-	}
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/tokens/transfer").
+		Post("/v2/transaction/tokens/transfer/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
-		JSON(respBody)
+		JSON(jsonPayload(t, prepared))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001":   sign,
+			testPlatformDID: platformSignParam(),
+		})).
+		Reply(200).
+		JSON(jsonPayload(t, payload))
 
 	//set http header
 	header := http.Header{}
@@ -453,6 +600,9 @@ func TestTransferCTokenSucc(t *testing.T) {
 	if resp.TransactionIds[0] != transID {
 		t.Fatalf("response transaction id should be %v", transID)
 	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal and process requests should be sent")
+	}
 }
 
 func TestTransferCTokenFail(t *testing.T) {
@@ -472,16 +622,16 @@ func TestTransferCTokenFail(t *testing.T) {
 		To:      "did:axn:002",
 		AssetId: "asset-id-001",
 		Tokens: []*sw.TokenAmount{
-			{
-				TokenId: "colored-token-id-001",
-				Amount:  500,
+			&sw.TokenAmount{
+				TokenId: "token-id-001",
+				Amount:  50,
 			},
 		},
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -490,7 +640,7 @@ func TestTransferCTokenFail(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/tokens/transfer").
+		Post("/v2/transaction/tokens/transfer/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(errCode).
 		JSON(respBody)
@@ -499,7 +649,7 @@ func TestTransferCTokenFail(t *testing.T) {
 	header := http.Header{}
 	header.Set("X-Auth-Token", token)
 
-	// do transfer colored token
+	//do transfer colored token
 	resp, err := walletClient.TransferCToken(header, reqBody, sign)
 	if err == nil {
 		t.Fatalf("err should not be nil when transfer colored token fail")
@@ -529,16 +679,16 @@ func TestTransferCTokenFailErrCode(t *testing.T) {
 		To:      "did:axn:002",
 		AssetId: "asset-id-001",
 		Tokens: []*sw.TokenAmount{
-			{
-				TokenId: "colored-token-id-001",
-				Amount:  500,
+			&sw.TokenAmount{
+				TokenId: "token-id-001",
+				Amount:  50,
 			},
 		},
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -547,7 +697,7 @@ func TestTransferCTokenFailErrCode(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/tokens/transfer").
+		Post("/v2/transaction/tokens/transfer/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
 		JSON(respBody)
@@ -556,7 +706,7 @@ func TestTransferCTokenFailErrCode(t *testing.T) {
 	header := http.Header{}
 	header.Set("X-Auth-Token", token)
 
-	// do transfer colored token
+	//do transfer colored token
 	resp, err := walletClient.TransferCToken(header, reqBody, sign)
 	if err == nil {
 		t.Fatalf("err should not be nil when transfer colored token fail")
@@ -571,7 +721,6 @@ func TestTransferCTokenFailErrCode(t *testing.T) {
 	if errWitherrCode.Error() != errMsg {
 		t.Fatalf("Error message should be %s", errMsg)
 	}
-
 	if resp != nil {
 		t.Fatalf("response object should be nil when transfer colored token fail")
 	}
@@ -579,7 +728,7 @@ func TestTransferCTokenFailErrCode(t *testing.T) {
 
 func TestTransferAssetSucc(t *testing.T) {
 	//init gock & walletclient
-	initWalletClient(t)
+	initWalletClientWithEnterprise(t)
 	defer gock.Off()
 
 	const (
@@ -594,37 +743,42 @@ func TestTransferAssetSucc(t *testing.T) {
 		Assets: []string{"asset-id-001"},
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
+	}
+	prepared := []*pw.TX{
+		newTestTx(t, "did:axn:001", 1),
+		newTestTx(t, testPlatformDID, 1, 99),
 	}
 	payload := &sw.WalletResponse{
 		TransactionIds: []string{transID},
 	}
-	byPayload, err := json.Marshal(payload)
-	if err != nil {
-		t.Fatalf("%v", err)
-	}
-	respBody := &rtstructs.Response{
-		ErrCode: 0,
-		Payload: string(byPayload),
-	}
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/assets/transfer").
+		Post("/v2/transaction/assets/transfer/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
-		JSON(respBody)
+		JSON(jsonPayload(t, prepared))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		MatchHeader("X-Auth-Token", token).
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001":   sign,
+			testPlatformDID: platformSignParam(),
+		})).
+		Reply(200).
+		JSON(jsonPayload(t, payload))
 
-	// set http header
+	//set http header
 	header := http.Header{}
 	header.Set("X-Auth-Token", token)
 
-	// do transfer asset
+	//do transfer digital asset
 	resp, err := walletClient.TransferAsset(header, reqBody, sign)
 	if err != nil {
-		t.Fatalf("transfer asset fail: %v", err)
+		t.Fatalf("transfer digital asset fail: %v", err)
 	}
 	if resp == nil {
 		t.Fatalf("response should not be nil")
@@ -635,6 +789,9 @@ func TestTransferAssetSucc(t *testing.T) {
 	if resp.TransactionIds[0] != transID {
 		t.Fatalf("response transaction id should be %v", transID)
 	}
+	if !gock.IsDone() {
+		t.Fatalf("proposal and process requests should be sent")
+	}
 }
 
 func TestTransferAssetFail(t *testing.T) {
@@ -644,8 +801,8 @@ func TestTransferAssetFail(t *testing.T) {
 
 	const (
 		token   = "user-token-001"
-		errCode = 5021
-		errMsg  = "AssetNotFound"
+		errCode = 5015
+		errMsg  = "BalancesNotSufficient"
 	)
 
 	//request body & response body
@@ -655,9 +812,9 @@ func TestTransferAssetFail(t *testing.T) {
 		Assets: []string{"asset-id-001"},
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -666,7 +823,7 @@ func TestTransferAssetFail(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/assets/transfer").
+		Post("/v2/transaction/assets/transfer/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(errCode).
 		JSON(respBody)
@@ -675,16 +832,16 @@ func TestTransferAssetFail(t *testing.T) {
 	header := http.Header{}
 	header.Set("X-Auth-Token", token)
 
-	// do transfer asset
+	//do transfer digital asset
 	resp, err := walletClient.TransferAsset(header, reqBody, sign)
 	if err == nil {
-		t.Fatalf("err should not be nil when transfer asset fail")
+		t.Fatalf("err should not be nil when transfer digital asset fail")
 	}
 	if !strings.Contains(err.Error(), errMsg) {
 		t.Fatalf("err message should contains [%v]", errMsg)
 	}
 	if resp != nil {
-		t.Fatalf("response object should be nil when transfer asset fail")
+		t.Fatalf("response object should be nil when transfer digital asset fail")
 	}
 }
 
@@ -695,8 +852,8 @@ func TestTransferAssetFailErrCode(t *testing.T) {
 
 	const (
 		token   = "user-token-001"
-		errCode = 5021
-		errMsg  = "AssetNotFound"
+		errCode = 5015
+		errMsg  = "BalancesNotSufficient"
 	)
 
 	//request body & response body
@@ -706,9 +863,9 @@ func TestTransferAssetFailErrCode(t *testing.T) {
 		Assets: []string{"asset-id-001"},
 	}
 	sign := &pki.SignatureParam{
-		Creator:    "did:axn:arxan-provider",
+		Creator:    "did:axn:001",
 		Nonce:      "helloalice",
-		PrivateKey: "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg==",
+		PrivateKey: testPrivateKey,
 	}
 	respBody := &rtstructs.Response{
 		ErrCode:    errCode,
@@ -717,7 +874,7 @@ func TestTransferAssetFailErrCode(t *testing.T) {
 
 	//mock http request
 	gock.New("http://127.0.0.1:8006").
-		Post("/v2/transaction/assets/transfer").
+		Post("/v2/transaction/assets/transfer/prepare").
 		MatchHeader("X-Auth-Token", token).
 		Reply(200).
 		JSON(respBody)
@@ -726,10 +883,10 @@ func TestTransferAssetFailErrCode(t *testing.T) {
 	header := http.Header{}
 	header.Set("X-Auth-Token", token)
 
-	// do transfer asset
+	//do transfer digital asset
 	resp, err := walletClient.TransferAsset(header, reqBody, sign)
 	if err == nil {
-		t.Fatalf("err should not be nil when transfer asset fail")
+		t.Fatalf("err should not be nil when transfer digital asset fail")
 	}
 	errWitherrCode, ok := err.(rest.HTTPCodedError)
 	if !ok {
@@ -742,10 +899,270 @@ func TestTransferAssetFailErrCode(t *testing.T) {
 		t.Fatalf("Error message should be %s", errMsg)
 	}
 	if resp != nil {
-		t.Fatalf("response object should be nil when transfer asset fail")
+		t.Fatalf("response object should be nil when transfer digital asset fail")
+	}
+}
+
+// txFlow is one of the proposal, SignTxs and ProcessTx flows.
+type txFlow struct {
+	name        string
+	preparePath string
+	prepared    func(txs []*pw.TX) interface{}
+	do          func(header http.Header, signParams *pki.SignatureParam) (*sw.WalletResponse, error)
+}
+
+func txFlows() []txFlow {
+	txList := func(txs []*pw.TX) interface{} { return txs }
+	return []txFlow{
+		{
+			name:        "IssueCToken",
+			preparePath: "/v2/transaction/tokens/issue/prepare",
+			prepared: func(txs []*pw.TX) interface{} {
+				return &sw.IssueCTokenPrepareResponse{TokenId: "colored-token-id-001", Txs: txs}
+			},
+			do: func(header http.Header, signParams *pki.SignatureParam) (*sw.WalletResponse, error) {
+				return walletClient.IssueCToken(header, &sw.IssueBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "asset-id-001", Amount: 1000}, signParams)
+			},
+		},
+		{
+			name:        "IssueAsset",
+			preparePath: "/v2/transaction/assets/issue/prepare",
+			prepared:    txList,
+			do: func(header http.Header, signParams *pki.SignatureParam) (*sw.WalletResponse, error) {
+				return walletClient.IssueAsset(header, &sw.IssueAssetBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "asset-id-001"}, signParams)
+			},
+		},
+		{
+			name:        "TransferCToken",
+			preparePath: "/v2/transaction/tokens/transfer/prepare",
+			prepared:    txList,
+			do: func(header http.Header, signParams *pki.SignatureParam) (*sw.WalletResponse, error) {
+				return walletClient.TransferCToken(header, &sw.TransferCTokenBody{From: "did:axn:001", To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}}}, signParams)
+			},
+		},
+		{
+			name:        "TransferAsset",
+			preparePath: "/v2/transaction/assets/transfer/prepare",
+			prepared:    txList,
+			do: func(header http.Header, signParams *pki.SignatureParam) (*sw.WalletResponse, error) {
+				return walletClient.TransferAsset(header, &sw.TransferAssetBody{From: "did:axn:001", To: "did:axn:002", Assets: []string{"asset-id-001"}}, signParams)
+			},
+		},
+	}
+}
+
+func TestTxFlowsSafeboxKey(t *testing.T) {
+	const securityCode = "security-code-001"
+
+	for _, flow := range txFlows() {
+		t.Run(flow.name, func(t *testing.T) {
+			initWalletClientWithTrustKeypair(t)
+			defer gock.Off()
+
+			//the private key is looked up in safebox with the security code
+			gock.New("http://127.0.0.1:8006").
+				Post("/").
+				AddMatcher(matchBodyContains(securityCode)).
+				Reply(200).
+				JSON(jsonPayload(t, map[string]string{"private_key": testPrivateKey}))
+			gock.New("http://127.0.0.1:8006").
+				Post(flow.preparePath).
+				Reply(200).
+				JSON(jsonPayload(t, flow.prepared([]*pw.TX{newTestTx(t, "did:axn:001", 1)})))
+			gock.New("http://127.0.0.1:8006").
+				Post("/v2/transaction/process").
+				AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+					"did:axn:001": &pki.SignatureParam{Creator: "did:axn:001", Nonce: "helloalice", PrivateKey: testPrivateKey},
+				})).
+				Reply(200).
+				JSON(jsonPayload(t, &sw.WalletResponse{TransactionIds: []string{"trans-id-001"}}))
+
+			resp, err := flow.do(http.Header{}, &pki.SignatureParam{
+				Creator:      "did:axn:001",
+				Nonce:        "helloalice",
+				SecurityCode: securityCode,
+			})
+			if err != nil {
+				t.Fatalf("%s with safebox key fail: %v", flow.name, err)
+			}
+			if resp == nil || len(resp.TransactionIds) != 1 {
+				t.Fatalf("response transaction list should contain one transaction")
+			}
+			if !gock.IsDone() {
+				t.Fatalf("safebox, proposal and process requests should be sent")
+			}
+		})
+	}
+}
+
+func TestTxFlowsSafeboxFail(t *testing.T) {
+	for _, flow := range txFlows() {
+		t.Run(flow.name, func(t *testing.T) {
+			initWalletClientWithTrustKeypair(t)
+			defer gock.Off()
+
+			gock.New("http://127.0.0.1:8006").
+				Post("/").
+				Reply(500).
+				BodyString("safebox unavailable")
+
+			resp, err := flow.do(http.Header{}, &pki.SignatureParam{
+				Creator:      "did:axn:001",
+				Nonce:        "helloalice",
+				SecurityCode: "security-code-001",
+			})
+			if err == nil {
+				t.Fatalf("err should not be nil when safebox lookup fail")
+			}
+			if resp != nil {
+				t.Fatalf("response object should be nil when safebox lookup fail")
+			}
+			if gock.HasUnmatchedRequest() {
+				t.Fatalf("no proposal should be sent when safebox lookup fail")
+			}
+		})
+	}
+}
+
+func TestTxFlowsSignFail(t *testing.T) {
+	cases := []struct {
+		name string
+		txs  func(t *testing.T) []*pw.TX
+	}{
+		{
+			// the client has no enterprise sign params to sign the fee
+			name: "NoEnterpriseSignParam",
+			txs: func(t *testing.T) []*pw.TX {
+				return []*pw.TX{newTestTx(t, "did:axn:001", 1), newTestTx(t, testPlatformDID, 1)}
+			},
+		},
+		{
+			name: "NilScript",
+			txs: func(t *testing.T) []*pw.TX {
+				tx := newTestTx(t, "did:axn:001", 1)
+				tx.Txout[0].Script = nil
+				return []*pw.TX{tx}
+			},
+		},
+		{
+			name: "InvalidScript",
+			txs: func(t *testing.T) []*pw.TX {
+				tx := newTestTx(t, "did:axn:001", 1)
+				tx.Txout[0].Script = []byte("not a utxo signature")
+				return []*pw.TX{tx}
+			},
+		},
+	}
+
+	for _, flow := range txFlows() {
+		for _, c := range cases {
+			t.Run(flow.name+"/"+c.name, func(t *testing.T) {
+				initWalletClient(t)
+				defer gock.Off()
+
+				gock.New("http://127.0.0.1:8006").
+					Post(flow.preparePath).
+					Reply(200).
+					JSON(jsonPayload(t, flow.prepared(c.txs(t))))
+
+				resp, err := flow.do(http.Header{}, &pki.SignatureParam{
+					Creator:    "did:axn:001",
+					Nonce:      "helloalice",
+					PrivateKey: testPrivateKey,
+				})
+				if err == nil {
+					t.Fatalf("err should not be nil when signing fail")
+				}
+				if !strings.Contains(err.Error(), "sign Txs error") {
+					t.Fatalf("err message should contains [sign Txs error], got %v", err)
+				}
+				if resp != nil {
+					t.Fatalf("response object should be nil when signing fail")
+				}
+				if gock.HasUnmatchedRequest() {
+					t.Fatalf("no tx should be processed when signing fail")
+				}
+			})
+		}
+	}
+}
+
+func TestTxFlowsProcessFail(t *testing.T) {
+	const (
+		errCode = 5015
+		errMsg  = "BalancesNotSufficient"
+	)
+
+	for _, flow := range txFlows() {
+		t.Run(flow.name, func(t *testing.T) {
+			initWalletClient(t)
+			defer gock.Off()
+
+			gock.New("http://127.0.0.1:8006").
+				Post(flow.preparePath).
+				Reply(200).
+				JSON(jsonPayload(t, flow.prepared([]*pw.TX{newTestTx(t, "did:axn:001", 1)})))
+			gock.New("http://127.0.0.1:8006").
+				Post("/v2/transaction/process").
+				Reply(errCode).
+				JSON(&rtstructs.Response{ErrCode: errCode, ErrMessage: errMsg})
+
+			resp, err := flow.do(http.Header{}, &pki.SignatureParam{
+				Creator:    "did:axn:001",
+				Nonce:      "helloalice",
+				PrivateKey: testPrivateKey,
+			})
+			if err == nil {
+				t.Fatalf("err should not be nil when process fail")
+			}
+			if !strings.Contains(err.Error(), errMsg) {
+				t.Fatalf("err message should contains [%v]", errMsg)
+			}
+			if resp != nil {
+				t.Fatalf("response object should be nil when process fail")
+			}
+		})
+	}
+}
+
+func TestTxFlowsProcessFailErrCode(t *testing.T) {
+	const (
+		errCode = 5015
+		errMsg  = "BalancesNotSufficient"
+	)
+
+	for _, flow := range txFlows() {
+		t.Run(flow.name, func(t *testing.T) {
+			initWalletClient(t)
+			defer gock.Off()
+
+			gock.New("http://127.0.0.1:8006").
+				Post(flow.preparePath).
+				Reply(200).
+				JSON(jsonPayload(t, flow.prepared([]*pw.TX{newTestTx(t, "did:axn:001", 1)})))
+			gock.New("http://127.0.0.1:8006").
+				Post("/v2/transaction/process").
+				Reply(200).
+				JSON(&rtstructs.Response{ErrCode: errCode, ErrMessage: errMsg})
+
+			resp, err := flow.do(http.Header{}, &pki.SignatureParam{
+				Creator:    "did:axn:001",
+				Nonce:      "helloalice",
+				PrivateKey: testPrivateKey,
+			})
+			errWitherrCode, ok := err.(rest.HTTPCodedError)
+			if !ok {
+				t.Fatalf("error type should be HTTPCodedError not %v", reflect.TypeOf(err))
+			}
+			if errWitherrCode.Code() != errCode {
+				t.Fatalf("Error code should be %d", errCode)
+			}
+			if resp != nil {
+				t.Fatalf("response object should be nil when process fail")
+			}
+		})
 	}
 }
-*/
 
 func TestQueryTransactionLogsSucc(t *testing.T) {
 	//init gock & edkeyclient
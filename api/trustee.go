@@ -0,0 +1,390 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/safebox"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/internal/keyfile"
+)
+
+// DefaultTrusteeRetry is the retry policy of the safebox trusteeship of
+// registered key pairs, used unless WithTrusteeRetry is given.
+//
+var DefaultTrusteeRetry = TrusteeRetry{
+	Attempts:   3,
+	Backoff:    200 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+// TrusteeRetry configures how often the key pair of a registered wallet
+// is handed over to the safebox before giving up.
+//
+// Only transport errors and 5xx responses of the safebox are retried,
+// see TrusteeRetryable. Other errors, such as an invalid request or a
+// key pair already in trust, fail at once: the handover is not
+// idempotent, and resending it could leave the safebox holding the key
+// pair under a security code the caller never received.
+//
+type TrusteeRetry struct {
+	// Attempts is the number of trusteeship attempts, at least one.
+	Attempts int
+	// Backoff is the delay before the first retry, it doubles after
+	// each failed retry up to MaxBackoff, if set.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// WithTrusteeRetry sets the retry policy of the safebox trusteeship of
+// registered key pairs.
+//
+func WithTrusteeRetry(r TrusteeRetry) Option {
+	return func(w *WalletClient) {
+		w.trusteeRetry = &r
+	}
+}
+
+// KeyStore persists the key pairs the safebox could not take in trust,
+// so that they are not lost with the process.
+//
+type KeyStore interface {
+	SaveKeyPair(id did.Identifier, keyPair *wallet.KeyPair) error
+}
+
+// WithKeyFallback saves the key pair of a registered wallet to s when
+// its trusteeship fails after every retry.
+//
+func WithKeyFallback(s KeyStore) Option {
+	return func(w *WalletClient) {
+		w.keyFallback = s
+	}
+}
+
+// TrusteeError reports a wallet registered on chain whose key pair the
+// safebox failed to take in trust. The key pair is returned to the
+// caller, who must keep it or retry with TrusteeKeyPair, as the wallet
+// cannot be used without it.
+//
+type TrusteeError struct {
+	Id       did.Identifier
+	KeyPair  *wallet.KeyPair
+	Attempts int
+	// Stored reports whether the key pair was saved to the fallback
+	// KeyStore, StoreErr is the error saving it otherwise.
+	Stored   bool
+	StoreErr error
+	Err      error
+}
+
+func (e *TrusteeError) Error() string {
+	msg := fmt.Sprintf("wallet %s registered but key pair trusteeship failed after %d attempts: %v", e.Id, e.Attempts, e.Err)
+	if e.Stored {
+		msg += ", key pair saved to fallback store"
+	} else if e.StoreErr != nil {
+		msg += fmt.Sprintf(", save key pair to fallback store fail: %v", e.StoreErr)
+	}
+	return msg
+}
+
+func (e *TrusteeError) Unwrap() error {
+	return e.Err
+}
+
+// TrusteeKeyPair is used to hand the key pair of a registered wallet
+// over to the safebox, typically the one of a TrusteeError. The result
+// carries the security code in place of the private key.
+//
+func (w *WalletClient) TrusteeKeyPair(header http.Header, id did.Identifier, keyPair *wallet.KeyPair) (result *wallet.WalletResponse, err error) {
+	if w.s == nil {
+		err = fmt.Errorf("key pair trusteeship not enabled")
+		return
+	}
+	if id == "" || keyPair == nil || keyPair.PrivateKey == "" {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	copied := *keyPair
+	return w.trusteeKeyPair(w.parentContext(), header, &wallet.WalletResponse{Id: id, KeyPair: &copied})
+}
+
+// trusteeKeyPair hands the key pair of req over to the safebox with
+// retries. It returns a *TrusteeError carrying the key pair if every
+// attempt fails.
+func (w *WalletClient) trusteeKeyPair(ctx context.Context, header http.Header, req *wallet.WalletResponse) (result *wallet.WalletResponse, err error) {
+	result = req
+	if w.s == nil || w.dryRun != nil || req.KeyPair == nil || req.KeyPair.PrivateKey == "" {
+		return
+	}
+
+	retry := DefaultTrusteeRetry
+	if w.trusteeRetry != nil {
+		retry = *w.trusteeRetry
+	}
+	if retry.Attempts < 1 {
+		retry.Attempts = 1
+	}
+
+	var response *safebox.SecurityCode
+	backoff := retry.Backoff
+	attempts := 0
+	for attempts < retry.Attempts {
+		if attempts > 0 {
+			if err = sleep(ctx, backoff); err != nil {
+				break
+			}
+			backoff *= 2
+			if retry.MaxBackoff > 0 && backoff > retry.MaxBackoff {
+				backoff = retry.MaxBackoff
+			}
+		}
+		attempts++
+		response, err = w.trusteeOnce(ctx, header, req)
+		if err == nil {
+			result.KeyPair.PrivateKey = ""
+			result.SecurityCode = response.Code
+			return
+		}
+		if !TrusteeRetryable(err) {
+			break
+		}
+	}
+
+	trusteeErr := &TrusteeError{
+		Id:       req.Id,
+		KeyPair:  req.KeyPair,
+		Attempts: attempts,
+		Err:      err,
+	}
+	if w.keyFallback != nil {
+		trusteeErr.StoreErr = w.keyFallback.SaveKeyPair(req.Id, req.KeyPair)
+		trusteeErr.Stored = trusteeErr.StoreErr == nil
+	}
+	result = nil
+	err = trusteeErr
+	return
+}
+
+// SafeboxStatusError reports a response of the safebox service with an
+// unexpected HTTP status. Safebox clients given with WithSafebox may
+// return it so that TrusteeRetryable retries their server errors.
+//
+type SafeboxStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *SafeboxStatusError) Error() string {
+	return fmt.Sprintf("Unexpected response code: %d (%s)", e.StatusCode, e.Body)
+}
+
+// TrusteeRetryable reports whether a failed safebox trusteeship may be
+// retried: err is a transport error, or a *SafeboxStatusError with a
+// 5xx status.
+//
+func TrusteeRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *SafeboxStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// safeboxTransport fails the requests to the safebox service answered
+// with an unexpected status with a *SafeboxStatusError.
+type safeboxTransport struct {
+	base http.RoundTripper
+}
+
+func (t *safeboxTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode == http.StatusOK {
+		return resp, err
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	return nil, &SafeboxStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+}
+
+// safeboxHTTPClient returns a copy of client, or of the default client
+// if nil, whose unexpected responses fail with a *SafeboxStatusError.
+func safeboxHTTPClient(client *http.Client) *http.Client {
+	var c http.Client
+	if client != nil {
+		c = *client
+	}
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.Transport = &safeboxTransport{base: base}
+	return &c
+}
+
+func (w *WalletClient) trusteeOnce(ctx context.Context, header http.Header, req *wallet.WalletResponse) (response *safebox.SecurityCode, err error) {
+	op := w.begin(ctx, "safebox.TrusteeKeyPair", AttrDID.String(string(req.Id)))
+	defer func() {
+		w.notifySafebox(op.ctx, "TrusteeKeyPair", req.Id, err)
+		op.end(err)
+	}()
+
+	return w.s.TrusteeKeyPair(w.safeboxHeader(header), &safebox.SaveKeyPairRequetBody{
+		UserDid:    string(req.Id),
+		PrivateKey: req.KeyPair.PrivateKey,
+		PublicKey:  req.KeyPair.PublicKey,
+	})
+}
+
+// sleep waits for d unless ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FileKeyStore is a KeyStore saving each key pair in a file only
+// readable by its owner, in a directory. The private keys are sealed
+// with AES-256-GCM under the key of the store, which should be kept
+// apart from the directory, such as in a KMS.
+//
+type FileKeyStore struct {
+	Dir string
+	// Key is the FileKeySize bytes key sealing the private keys.
+	Key []byte
+}
+
+// FileKeySize is the size of the key of a FileKeyStore.
+//
+const FileKeySize = 32
+
+// sealedKeyPair is the file content of a key pair.
+type sealedKeyPair struct {
+	PublicKey string `json:"public_key"`
+	Nonce     []byte `json:"nonce"`
+	Sealed    []byte `json:"sealed"`
+}
+
+// NewFileKeyStore returns a key store saving key pairs in dir, which is
+// created on the first save if needed, sealed with the FileKeySize
+// bytes key.
+//
+func NewFileKeyStore(dir string, key []byte) (*FileKeyStore, error) {
+	if len(key) != FileKeySize {
+		return nil, fmt.Errorf("key size should be %d bytes, not %d", FileKeySize, len(key))
+	}
+	return &FileKeyStore{Dir: dir, Key: append([]byte(nil), key...)}, nil
+}
+
+func (s *FileKeyStore) path(id did.Identifier) string {
+	return filepath.Join(s.Dir, keyfile.Name(string(id)))
+}
+
+func (s *FileKeyStore) aead() (cipher.AEAD, error) {
+	if len(s.Key) != FileKeySize {
+		return nil, fmt.Errorf("key size should be %d bytes, not %d", FileKeySize, len(s.Key))
+	}
+	return keyfile.NewAEAD(s.Key)
+}
+
+// SaveKeyPair seals and saves the key pair of wallet id, replacing any
+// previous one.
+//
+func (s *FileKeyStore) SaveKeyPair(id did.Identifier, keyPair *wallet.KeyPair) (err error) {
+	if id == "" || keyPair == nil {
+		return fmt.Errorf("request payload invalid")
+	}
+	aead, err := s.aead()
+	if err != nil {
+		return
+	}
+	sealed := &sealedKeyPair{PublicKey: keyPair.PublicKey}
+	sealed.Nonce, sealed.Sealed, err = keyfile.Seal(aead, []byte(keyPair.PrivateKey), keyfile.AdditionalData(string(id), keyPair.PublicKey))
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(s.Dir, 0700); err != nil {
+		return
+	}
+	return keyfile.WriteFile(s.Dir, keyfile.Name(string(id)), data)
+}
+
+// LoadKeyPair returns the key pair saved for wallet id, unsealed.
+//
+func (s *FileKeyStore) LoadKeyPair(id did.Identifier) (keyPair *wallet.KeyPair, err error) {
+	aead, err := s.aead()
+	if err != nil {
+		return
+	}
+	data, err := ioutil.ReadFile(s.path(id))
+	if err != nil {
+		return
+	}
+	var sealed sealedKeyPair
+	if err = json.Unmarshal(data, &sealed); err != nil {
+		return
+	}
+	privateKey, err := keyfile.Open(aead, sealed.Nonce, sealed.Sealed, keyfile.AdditionalData(string(id), sealed.PublicKey))
+	if err == keyfile.ErrCorrupted {
+		return nil, fmt.Errorf("key pair of %s corrupted", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key pair of %s cannot be unsealed with the store key", id)
+	}
+	keyPair = &wallet.KeyPair{
+		PrivateKey: string(privateKey),
+		PublicKey:  sealed.PublicKey,
+	}
+	return
+}
+
+// DeleteKeyPair removes the key pair saved for wallet id, once it is
+// taken in trust by the safebox.
+//
+func (s *FileKeyStore) DeleteKeyPair(id did.Identifier) error {
+	return os.Remove(s.path(id))
+}
@@ -0,0 +1,286 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/rest/api"
+	"github.com/arxanchain/sdk-go-common/structs/safebox"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+var testStoreKey = bytes.Repeat([]byte{7}, FileKeySize)
+
+func newTrusteeWalletClient(t *testing.T, opts ...Option) *WalletClient {
+	return newTestWalletClientWithConfig(t, &api.Config{TrusteeKeyPairEnable: true}, opts...)
+}
+
+func mockRegister(t *testing.T, keyPair *sw.KeyPair) {
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		Reply(200).
+		JSON(jsonPayload(t, &sw.WalletResponse{Id: "did:axn:001", KeyPair: keyPair}))
+}
+
+func mockTrustee(times, status int) {
+	gock.New("http://127.0.0.1:8006").
+		Post("/").
+		AddMatcher(matchBodyContains(`"user_did":"did:axn:001"`)).
+		Times(times).
+		Reply(status).
+		JSON(map[string]string{"code": "security-code-001"})
+}
+
+var registerBody = &sw.RegisterWalletBody{
+	Type:   pw.DidType_ORGANIZATION,
+	Access: "alice",
+	Secret: "123456",
+}
+
+func TestRegisterTrusteeRetry(t *testing.T) {
+	//init gock & walletclient
+	w := newTrusteeWalletClient(t, WithTrusteeRetry(TrusteeRetry{Attempts: 3}))
+	defer gock.Off()
+
+	//the safebox fails once then takes the key pair in trust
+	mockRegister(t, &sw.KeyPair{PrivateKey: testPrivateKey, PublicKey: "public-key"})
+	mockTrustee(1, 500)
+	mockTrustee(1, 200)
+
+	resp, err := w.Register(http.Header{}, registerBody)
+	if err != nil {
+		t.Fatalf("register wallet fail: %v", err)
+	}
+	if resp.KeyPair.PrivateKey != "" || resp.SecurityCode == "" {
+		t.Fatalf("private key should be replaced by the security code: %+v", resp)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending mocks: %v", gock.Pending())
+	}
+}
+
+func TestRegisterTrusteeFailure(t *testing.T) {
+	//init gock & walletclient
+	store, err := NewFileKeyStore(t.TempDir()+"/keys", testStoreKey)
+	if err != nil {
+		t.Fatalf("new file key store fail: %v", err)
+	}
+	w := newTrusteeWalletClient(t, WithTrusteeRetry(TrusteeRetry{Attempts: 2}), WithKeyFallback(store))
+	defer gock.Off()
+
+	keyPair := &sw.KeyPair{PrivateKey: testPrivateKey, PublicKey: "public-key"}
+	mockRegister(t, keyPair)
+	mockTrustee(2, 500)
+
+	resp, err := w.Register(http.Header{}, registerBody)
+	if resp != nil {
+		t.Fatalf("response should be nil")
+	}
+	var trusteeErr *TrusteeError
+	if !errors.As(err, &trusteeErr) {
+		t.Fatalf("error should be a trustee error, not %v", err)
+	}
+	if trusteeErr.Id != "did:axn:001" || *trusteeErr.KeyPair != *keyPair {
+		t.Fatalf("error should carry the wallet key pair: %+v", trusteeErr)
+	}
+	if trusteeErr.Attempts != 2 || !trusteeErr.Stored || trusteeErr.Err == nil {
+		t.Fatalf("trusteeship should be attempted twice and the key pair stored: %v", trusteeErr)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending mocks: %v", gock.Pending())
+	}
+
+	//the key pair is kept by the fallback store
+	stored, err := store.LoadKeyPair("did:axn:001")
+	if err != nil {
+		t.Fatalf("load key pair fail: %v", err)
+	}
+	if *stored != *keyPair {
+		t.Fatalf("stored key pair should be %+v, not %+v", keyPair, stored)
+	}
+	info, err := os.Stat(store.path("did:axn:001"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("key pair file should only be readable by its owner, mode %v", info.Mode())
+	}
+	data, err := ioutil.ReadFile(store.path("did:axn:001"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if strings.Contains(string(data), testPrivateKey) {
+		t.Fatalf("private key should be sealed: %s", data)
+	}
+	other, err := NewFileKeyStore(store.Dir, make([]byte, FileKeySize))
+	if err != nil {
+		t.Fatalf("new file key store fail: %v", err)
+	}
+	if _, err = other.LoadKeyPair("did:axn:001"); err == nil {
+		t.Fatalf("key pair should not be unsealed with another key")
+	}
+
+	//the trusteeship is completed later
+	mockTrustee(1, 200)
+	resp, err = w.TrusteeKeyPair(http.Header{}, trusteeErr.Id, stored)
+	if err != nil {
+		t.Fatalf("trustee key pair fail: %v", err)
+	}
+	if resp.Id != "did:axn:001" || resp.KeyPair.PrivateKey != "" || resp.SecurityCode == "" {
+		t.Fatalf("private key should be replaced by the security code: %+v", resp)
+	}
+	if stored.PrivateKey != testPrivateKey {
+		t.Fatalf("caller key pair should not be changed")
+	}
+	if err = store.DeleteKeyPair("did:axn:001"); err != nil {
+		t.Fatalf("delete key pair fail: %v", err)
+	}
+	if _, err = store.LoadKeyPair("did:axn:001"); !os.IsNotExist(err) {
+		t.Fatalf("key pair should be deleted: %v", err)
+	}
+}
+
+func TestRegisterTrusteePermanentError(t *testing.T) {
+	//init gock & walletclient
+	w := newTrusteeWalletClient(t, WithTrusteeRetry(TrusteeRetry{Attempts: 3}))
+	defer gock.Off()
+
+	//the safebox rejects the key pair, it is not sent again
+	mockRegister(t, &sw.KeyPair{PrivateKey: testPrivateKey, PublicKey: "public-key"})
+	mockTrustee(1, 409)
+	retried := gock.New("http://127.0.0.1:8006").
+		Post("/").
+		Reply(200).
+		JSON(map[string]string{"code": "security-code-001"})
+
+	_, err := w.Register(http.Header{}, registerBody)
+	var trusteeErr *TrusteeError
+	if !errors.As(err, &trusteeErr) {
+		t.Fatalf("error should be a trustee error, not %v", err)
+	}
+	if trusteeErr.Attempts != 1 {
+		t.Fatalf("trusteeship should be attempted once, not %d times", trusteeErr.Attempts)
+	}
+	if retried.Mock.Done() {
+		t.Fatalf("key pair should not be sent again")
+	}
+}
+
+// flakySafebox fails the trusteeship with errs, then succeeds.
+type flakySafebox struct {
+	safebox.ISafeboxClient
+	errs  []error
+	calls int
+}
+
+func (s *flakySafebox) TrusteeKeyPair(header http.Header, body *safebox.SaveKeyPairRequetBody) (*safebox.SecurityCode, error) {
+	s.calls++
+	if len(s.errs) != 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	return &safebox.SecurityCode{Code: "security-code-001"}, nil
+}
+
+func TestRegisterTrusteeTransportError(t *testing.T) {
+	//init gock & walletclient
+	box := &flakySafebox{errs: []error{
+		&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+		&SafeboxStatusError{StatusCode: 503, Body: "unavailable"},
+	}}
+	w := newTestWalletClient(t, WithSafebox(box), WithTrusteeRetry(TrusteeRetry{Attempts: 3}))
+	defer gock.Off()
+
+	mockRegister(t, &sw.KeyPair{PrivateKey: testPrivateKey, PublicKey: "public-key"})
+	resp, err := w.Register(http.Header{}, registerBody)
+	if err != nil {
+		t.Fatalf("register wallet fail: %v", err)
+	}
+	if box.calls != 3 || resp.SecurityCode != "security-code-001" {
+		t.Fatalf("trusteeship should succeed at the third attempt, %d calls: %+v", box.calls, resp)
+	}
+}
+
+func TestTrusteeRetryable(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{&url.Error{Op: "Post", URL: "http://safebox", Err: &net.OpError{Op: "read", Err: errors.New("reset")}}, true},
+		{fmt.Errorf("safebox: %w", &net.DNSError{Err: "no such host"}), true},
+		{&SafeboxStatusError{StatusCode: 500, Body: "internal error"}, true},
+		{&url.Error{Op: "Post", URL: "http://safebox", Err: &SafeboxStatusError{StatusCode: 502}}, true},
+		{&SafeboxStatusError{StatusCode: 400, Body: "bad request"}, false},
+		{&url.Error{Op: "Post", URL: "http://safebox", Err: &SafeboxStatusError{StatusCode: 409}}, false},
+		{errors.New("Unexpected response code: 503 (unavailable)"), false},
+		{errors.New("request payload invalid"), false},
+		{context.Canceled, false},
+		{fmt.Errorf("safebox: %w", context.DeadlineExceeded), false},
+	}
+	for _, c := range cases {
+		if retryable := TrusteeRetryable(c.err); retryable != c.retryable {
+			t.Fatalf("%v should be retryable %v, not %v", c.err, c.retryable, retryable)
+		}
+	}
+}
+
+func TestFileKeyStoreInvalidKey(t *testing.T) {
+	if _, err := NewFileKeyStore(t.TempDir(), []byte("short")); err == nil {
+		t.Fatalf("new file key store with a short key should fail")
+	}
+	store := &FileKeyStore{Dir: t.TempDir()}
+	if err := store.SaveKeyPair("did:axn:001", &sw.KeyPair{PrivateKey: testPrivateKey}); err == nil {
+		t.Fatalf("save key pair without key should fail")
+	}
+}
+
+func TestTrusteeKeyPairInvalid(t *testing.T) {
+	initWalletClient(t)
+	if _, err := walletClient.TrusteeKeyPair(http.Header{}, "did:axn:001", &sw.KeyPair{PrivateKey: testPrivateKey}); err == nil {
+		t.Fatalf("trusteeship should fail when not enabled")
+	}
+
+	w := newTrusteeWalletClient(t)
+	if _, err := w.TrusteeKeyPair(http.Header{}, "did:axn:001", &sw.KeyPair{}); err == nil {
+		t.Fatalf("trusteeship of an empty key pair should fail")
+	}
+}
+
+func TestTrusteeSleepCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleep(ctx, time.Hour); err != context.Canceled {
+		t.Fatalf("sleep should return when the context is done, not %v", err)
+	}
+}
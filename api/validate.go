@@ -0,0 +1,204 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// didPattern matches DIDs such as "did:axn:8ed49c38aa011f06".
+var didPattern = regexp.MustCompile(`^did:[a-z0-9]+:[A-Za-z0-9._%-]+(:[A-Za-z0-9._%-]+)*$`)
+
+// FieldError reports an invalid field of a request body. Field is the
+// JSON name of the field, e.g. "owner" or "tokens[1].amount".
+//
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Reason
+}
+
+// ValidationError lists the invalid fields of a request body.
+//
+type ValidationError []*FieldError
+
+func (e ValidationError) Error() string {
+	reasons := make([]string, len(e))
+	for i, field := range e {
+		reasons[i] = field.Error()
+	}
+	return "request payload invalid: " + strings.Join(reasons, "; ")
+}
+
+// validator collects the field errors of a request body.
+type validator struct {
+	errs ValidationError
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(field, value string) {
+	if value == "" {
+		v.fail(field, "must be set")
+	}
+}
+
+// did checks that value is a well-formed DID, if set or required.
+func (v *validator) did(field string, value did.Identifier, required bool) {
+	switch {
+	case value == "" && required:
+		v.fail(field, "must be set")
+	case value != "" && !didPattern.MatchString(string(value)):
+		v.fail(field, "is not a valid DID: %q", value)
+	}
+}
+
+func (v *validator) tokens(field string, tokens []*wallet.TokenAmount) {
+	if len(tokens) == 0 {
+		v.fail(field, "must not be empty")
+		return
+	}
+	for i, token := range tokens {
+		name := fmt.Sprintf("%s[%d]", field, i)
+		if token == nil {
+			v.fail(name, "must be set")
+			continue
+		}
+		v.required(name+".token_id", token.TokenId)
+		if token.Amount <= 0 {
+			v.fail(name+".amount", "must be positive, got %d", token.Amount)
+		}
+	}
+}
+
+func (v *validator) indexes(field string, tags *wallet.IndexTags, required bool) {
+	if tags == nil {
+		if required {
+			v.fail(field, "must be set")
+		}
+		return
+	}
+	if required && len(tags.CombinedIndex) == 0 && len(tags.IndividualIndex) == 0 {
+		v.fail(field, "must not be empty")
+	}
+	for i, index := range tags.CombinedIndex {
+		v.required(fmt.Sprintf("%s.combined_index[%d]", field, i), index)
+	}
+	for i, index := range tags.IndividualIndex {
+		v.required(fmt.Sprintf("%s.individual_index[%d]", field, i), index)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Validate checks a request body before it is sent to the wallet
+// platform. body is one of *wallet.RegisterWalletBody,
+// *wallet.RegisterSubWalletBody, *wallet.POEBody, *wallet.IssueBody,
+// *wallet.IssueAssetBody, *wallet.TransferCTokenBody,
+// *wallet.TransferAssetBody or *wallet.IndexSetPayload.
+//
+// Invalid fields are reported by a ValidationError. The WalletClient
+// methods validate their request body before sending any request.
+//
+func Validate(body interface{}) error {
+	v := &validator{}
+	switch body := body.(type) {
+	case *wallet.RegisterWalletBody:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("id", body.Id, false)
+		v.required("access", body.Access)
+		v.required("secret", body.Secret)
+		v.indexes("indexes", body.Indexes, false)
+	case *wallet.RegisterSubWalletBody:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("id", body.Id, true)
+		v.indexes("indexes", body.Indexes, false)
+	case *wallet.POEBody:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("id", body.Id, false)
+		v.required("name", body.Name)
+		v.did("parent_id", body.ParentId, false)
+		v.did("owner", body.Owner, true)
+		v.indexes("indexes", body.Indexes, false)
+	case *wallet.IssueBody:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("issuer", did.Identifier(body.Issuer), true)
+		v.did("owner", did.Identifier(body.Owner), true)
+		v.required("asset_id", body.AssetId)
+		if body.Amount <= 0 {
+			v.fail("amount", "must be positive, got %d", body.Amount)
+		}
+	case *wallet.IssueAssetBody:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("issuer", did.Identifier(body.Issuer), true)
+		v.did("owner", did.Identifier(body.Owner), true)
+		v.required("asset_id", body.AssetId)
+	case *wallet.TransferCTokenBody:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("from", did.Identifier(body.From), true)
+		v.did("to", did.Identifier(body.To), true)
+		v.tokens("tokens", body.Tokens)
+	case *wallet.TransferAssetBody:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("from", did.Identifier(body.From), true)
+		v.did("to", did.Identifier(body.To), true)
+		if len(body.Assets) == 0 {
+			v.fail("assets", "must not be empty")
+		}
+		for i, asset := range body.Assets {
+			v.required(fmt.Sprintf("assets[%d]", i), asset)
+		}
+	case *wallet.IndexSetPayload:
+		if body == nil {
+			return fmt.Errorf("request payload invalid")
+		}
+		v.did("id", body.Id, true)
+		v.indexes("indexs", body.Indexs, true)
+	default:
+		return fmt.Errorf("request payload type invalid: %T", body)
+	}
+	return v.err()
+}
@@ -0,0 +1,128 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	sw "github.com/arxanchain/sdk-go-common/structs/wallet"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   interface{}
+		fields []string
+	}{
+		{"register", &sw.RegisterWalletBody{Type: pw.DidType_ORGANIZATION, Access: "alice", Secret: "123456"}, nil},
+		{"register missing access", &sw.RegisterWalletBody{Id: "alice", Secret: "123456"}, []string{"id", "access"}},
+		{"register sub wallet", &sw.RegisterSubWalletBody{Id: "did:axn:001"}, nil},
+		{"register sub wallet missing id", &sw.RegisterSubWalletBody{Indexes: &sw.IndexTags{CombinedIndex: []string{""}}}, []string{"id", "indexes.combined_index[0]"}},
+		{"poe", &sw.POEBody{Name: "piaoju001", Owner: "did:axn:001", ParentId: "did:axn:poe-id-001"}, nil},
+		{"poe missing name", &sw.POEBody{Owner: "did:axn:001"}, []string{"name"}},
+		{"poe malformed owner", &sw.POEBody{Name: "piaoju001", Owner: "did:axn"}, []string{"owner"}},
+		{"issue", &sw.IssueBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "asset-id-001", Amount: 1}, nil},
+		{"issue zero amount", &sw.IssueBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "asset-id-001"}, []string{"amount"}},
+		{"issue asset", &sw.IssueAssetBody{Issuer: "did:axn:001", Owner: "did:axn:002", AssetId: "asset-id-001"}, nil},
+		{"issue asset missing owner", &sw.IssueAssetBody{Issuer: "did:axn:001"}, []string{"owner", "asset_id"}},
+		{"transfer ctoken", &sw.TransferCTokenBody{From: "did:axn:001", To: "did:axn:002", Tokens: []*sw.TokenAmount{{TokenId: "token-id-001", Amount: 50}}}, nil},
+		{"transfer ctoken empty tokens", &sw.TransferCTokenBody{From: "did:axn:001", To: "did:axn:002"}, []string{"tokens"}},
+		{"transfer ctoken invalid tokens", &sw.TransferCTokenBody{From: "did:axn:001", To: "did:axn:002", Tokens: []*sw.TokenAmount{nil, {Amount: -1}}}, []string{"tokens[0]", "tokens[1].token_id", "tokens[1].amount"}},
+		{"transfer asset", &sw.TransferAssetBody{From: "did:axn:001", To: "did:axn:002", Assets: []string{"asset-id-001"}}, nil},
+		{"transfer asset malformed to", &sw.TransferAssetBody{From: "did:axn:001", To: "did:axn: 002", Assets: []string{""}}, []string{"to", "assets[0]"}},
+		{"index set", &sw.IndexSetPayload{Id: "did:axn:001", Indexs: &sw.IndexTags{IndividualIndex: []string{"keyword"}}}, nil},
+		{"index set empty indexs", &sw.IndexSetPayload{Id: "did:axn:001", Indexs: &sw.IndexTags{}}, []string{"indexs"}},
+	}
+	for _, c := range cases {
+		err := Validate(c.body)
+		if c.fields == nil {
+			if err != nil {
+				t.Fatalf("%s: body should be valid: %v", c.name, err)
+			}
+			continue
+		}
+		verr, ok := err.(ValidationError)
+		if !ok {
+			t.Fatalf("%s: error should be a ValidationError not %v", c.name, reflect.TypeOf(err))
+		}
+		var fields []string
+		for _, field := range verr {
+			fields = append(fields, field.Field)
+		}
+		if !reflect.DeepEqual(fields, c.fields) {
+			t.Fatalf("%s: invalid fields should be %v not %v", c.name, c.fields, fields)
+		}
+	}
+}
+
+func TestValidateNil(t *testing.T) {
+	bodies := []interface{}{
+		(*sw.RegisterWalletBody)(nil),
+		(*sw.POEBody)(nil),
+		(*sw.TransferCTokenBody)(nil),
+		(*sw.IndexSetPayload)(nil),
+		"not a body",
+	}
+	for _, body := range bodies {
+		if err := Validate(body); err == nil {
+			t.Fatalf("%T: err should not be nil", body)
+		}
+	}
+}
+
+func TestTransferCTokenInvalidBody(t *testing.T) {
+	//init gock & walletclient
+	initWalletClient(t)
+	defer gock.Off()
+
+	//no mock, the request must not be sent
+	reqBody := &sw.TransferCTokenBody{
+		From: "did:axn:001",
+		To:   "did:axn:002",
+		Tokens: []*sw.TokenAmount{
+			&sw.TokenAmount{
+				TokenId: "token-id-001",
+				Amount:  0,
+			},
+		},
+	}
+	sign := &pki.SignatureParam{
+		Creator:    "did:axn:001",
+		Nonce:      "helloalice",
+		PrivateKey: testPrivateKey,
+	}
+
+	resp, err := walletClient.TransferCToken(http.Header{}, reqBody, sign)
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("error should be a ValidationError not %v", err)
+	}
+	if len(verr) != 1 || verr[0].Field != "tokens[0].amount" {
+		t.Fatalf("error should report tokens[0].amount: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("response should be nil when request payload invalid")
+	}
+	if gock.HasUnmatchedRequest() {
+		t.Fatalf("no request should be sent")
+	}
+}
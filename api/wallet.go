@@ -17,6 +17,7 @@ limitations under the License.
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -26,12 +27,13 @@ import (
 	"github.com/arxanchain/sdk-go-common/errors"
 	"github.com/arxanchain/sdk-go-common/rest"
 	restapi "github.com/arxanchain/sdk-go-common/rest/api"
-	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
 	"github.com/arxanchain/sdk-go-common/structs"
 	"github.com/arxanchain/sdk-go-common/structs/did"
 	"github.com/arxanchain/sdk-go-common/structs/pki"
 	"github.com/arxanchain/sdk-go-common/structs/safebox"
 	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WalletClient is a http agent to wallet service.
@@ -40,38 +42,77 @@ type WalletClient struct {
 	c   *restapi.Client
 	s   safebox.ISafeboxClient
 	cfg *restapi.Config
+	ctx context.Context
+
+	feePayer       FeePayer
+	dryRun         *DryRunRecorder
+	intent         *IntentCheck
+	rotations      *KeyRotations
+	keyCache       *KeyCache
+	trusteeRetry   *TrusteeRetry
+	keyFallback    KeyStore
+	middlewares    []Middleware
+	safeboxHooks   []SafeboxHook
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	telemetry      *telemetry
 }
 
 // NewWalletClient returns a WalletClient instance.
 //
-func NewWalletClient(config *restapi.Config) (*WalletClient, error) {
+// Optional behaviour such as middlewares or telemetry can be enabled
+// by passing Options.
+func NewWalletClient(config *restapi.Config, opts ...Option) (*WalletClient, error) {
 	if config == nil {
 		return nil, fmt.Errorf("config must be set")
 	}
 
-	var s safebox.ISafeboxClient
-	var err error
-	if config.TrusteeKeyPairEnable {
+	// The caller's config is left unchanged
+	cfg := *config
+	if cfg.RouteTag == "" {
+		cfg.RouteTag = "wallet-ng"
+	}
+
+	c, err := restapi.NewClient(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WalletClient{c: c, cfg: &cfg}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.s == nil && config.TrusteeKeyPairEnable {
 		temConfig := *config
 		if temConfig.RouteTag == "" {
 			temConfig.RouteTag = "safebox"
 		}
-		s, err = safeboxapi.NewSafeboxClient(&temConfig)
+		temConfig.HttpClient = safeboxHTTPClient(temConfig.HttpClient)
+		w.s, err = safeboxapi.NewSafeboxClient(&temConfig)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if config.RouteTag == "" {
-		config.RouteTag = "wallet-ng"
-	}
-
-	c, err := restapi.NewClient(config)
+	w.telemetry, err = newTelemetry(w.tracerProvider, w.meterProvider)
 	if err != nil {
 		return nil, err
 	}
 
-	return &WalletClient{c: c, s: s, cfg: config}, nil
+	return w, nil
+}
+
+// WithSafebox makes the client take key pairs in trust with s, in place
+// of the safebox service built from the config. It enables trusteeship
+// even if TrusteeKeyPairEnable is not set in the config.
+//
+// See the localsafebox package for a software safebox keeping key pairs
+// in encrypted files, for air-gapped and test environments.
+//
+func WithSafebox(s safebox.ISafeboxClient) Option {
+	return func(w *WalletClient) {
+		w.s = s
+	}
 }
 
 // Register is used to register user wallet.
@@ -87,28 +128,31 @@ func NewWalletClient(config *restapi.Config) (*WalletClient, error) {
 // If you want to trust the key pair, it will return the security code.
 //
 func (w *WalletClient) Register(header http.Header, body *wallet.RegisterWalletBody) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "Register")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v1/wallet/register")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "Register",
+		Method:  "POST",
+		Path:    "/v1/wallet/register",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -121,37 +165,31 @@ func (w *WalletClient) Register(header http.Header, body *wallet.RegisterWalletB
 		return
 	}
 
-	err = json.Unmarshal([]byte(payload), &result)
+	if err = json.Unmarshal([]byte(payload), &result); err != nil {
+		return
+	}
 
-	result, err = w.trusteeKeyPair(header, result)
+	result, err = w.trusteeKeyPair(op.ctx, header, result)
 	return
 }
 
-func (w *WalletClient) trusteeKeyPair(header http.Header, req *wallet.WalletResponse) (result *wallet.WalletResponse, err error) {
-	result = req
-	if w.s == nil {
-		return
+// safeboxHeader returns a copy of header carrying the API key of the
+// client, the caller's header is left unchanged.
+func (w *WalletClient) safeboxHeader(header http.Header) http.Header {
+	h := cloneHeader(header)
+	if h == nil {
+		h = http.Header{}
 	}
-
 	if w.cfg.ApiKey != "" {
-		header.Set(structs.APIKeyHeader, w.cfg.ApiKey)
+		h.Set(structs.APIKeyHeader, w.cfg.ApiKey)
 	}
-	response, err := w.s.TrusteeKeyPair(header, &safebox.SaveKeyPairRequetBody{
-		UserDid:    string(req.Id),
-		PrivateKey: req.KeyPair.PrivateKey,
-		PublicKey:  req.KeyPair.PublicKey,
-	})
-	if err != nil {
-		result = nil
-		return
-	}
-	result.KeyPair.PrivateKey = ""
-	result.SecurityCode = response.Code
-
-	return
+	return h
 }
 
-func (w *WalletClient) queryPrivateKey(header http.Header, signParams *pki.SignatureParam) (result *pki.SignatureParam, err error) {
+// queryPrivateKey returns signParams with the private key queried from
+// the safebox with the security code. It returns a copy, the caller's
+// signParams are left unchanged.
+func (w *WalletClient) queryPrivateKey(ctx context.Context, header http.Header, signParams *pki.SignatureParam) (result *pki.SignatureParam, err error) {
 	result = signParams
 	if w.s == nil {
 		return
@@ -159,13 +197,28 @@ func (w *WalletClient) queryPrivateKey(header http.Header, signParams *pki.Signa
 	if result.PrivateKey != "" && result.SecurityCode == "" {
 		return
 	}
-
-	if w.cfg.ApiKey != "" {
-		header.Set(structs.APIKeyHeader, w.cfg.ApiKey)
+	copied := *signParams
+	result = &copied
+
+	creator := result.Creator
+	securityCode := result.SecurityCode
+	if w.keyCache != nil {
+		if privateKey, ok := w.keyCache.get(creator, securityCode); ok {
+			result.SecurityCode = ""
+			result.PrivateKey = privateKey
+			return
+		}
 	}
-	response, err := w.s.QueryPrivateKey(header, &safebox.OperateKeyInfo{
-		UserDid: string(result.Creator),
-		Code:    result.SecurityCode,
+
+	op := w.begin(ctx, "safebox.QueryPrivateKey", AttrCreator.String(string(creator)))
+	defer func() {
+		w.notifySafebox(op.ctx, "QueryPrivateKey", creator, err)
+		op.end(err)
+	}()
+
+	response, err := w.s.QueryPrivateKey(w.safeboxHeader(header), &safebox.OperateKeyInfo{
+		UserDid: string(creator),
+		Code:    securityCode,
 	})
 	if err != nil {
 		result = nil
@@ -173,6 +226,9 @@ func (w *WalletClient) queryPrivateKey(header http.Header, signParams *pki.Signa
 	}
 	result.SecurityCode = ""
 	result.PrivateKey = response.PrivateKey
+	if w.keyCache != nil {
+		w.keyCache.put(creator, securityCode, response.PrivateKey)
+	}
 
 	return
 }
@@ -190,28 +246,31 @@ func (w *WalletClient) queryPrivateKey(header http.Header, signParams *pki.Signa
 // If you want to trust the key pair, it will return the security code.
 //
 func (w *WalletClient) RegisterSubWallet(header http.Header, body *wallet.RegisterSubWalletBody) (result *wallet.WalletResponse, err error) {
-	if body == nil {
-		err = fmt.Errorf("request payload invalid")
+	op := w.begin(w.parentContext(), "RegisterSubWallet")
+	defer func() { op.end(err) }()
+
+	if err = Validate(body); err != nil {
 		return
 	}
 
 	// Build http request
-	r := w.c.NewRequest("POST", "/v1/wallet/register/subwallet")
-	r.SetHeaders(header)
-	r.SetBody(body)
+	call := &Call{
+		Context: op.ctx,
+		Name:    "RegisterSubWallet",
+		Method:  "POST",
+		Path:    "/v1/wallet/register/subwallet",
+		Header:  header,
+		Body:    body,
+	}
 
 	// Do http request
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// Parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -224,9 +283,11 @@ func (w *WalletClient) RegisterSubWallet(header http.Header, body *wallet.Regist
 		return
 	}
 
-	err = json.Unmarshal([]byte(payload), &result)
+	if err = json.Unmarshal([]byte(payload), &result); err != nil {
+		return
+	}
 
-	result, err = w.trusteeKeyPair(header, result)
+	result, err = w.trusteeKeyPair(op.ctx, header, result)
 
 	return
 }
@@ -234,21 +295,25 @@ func (w *WalletClient) RegisterSubWallet(header http.Header, body *wallet.Regist
 // GetWalletBalance is used to get wallet balances.
 //
 func (w *WalletClient) GetWalletBalance(header http.Header, id did.Identifier) (result *wallet.WalletBalance, err error) {
-	r := w.c.NewRequest("GET", "/v1/wallet/balance")
-	r.SetHeaders(header)
-	r.SetParam("id", string(id))
+	op := w.begin(w.parentContext(), "GetWalletBalance", AttrDID.String(string(id)))
+	defer func() { op.end(err) }()
+
+	call := &Call{
+		Context: op.ctx,
+		Name:    "GetWalletBalance",
+		Method:  "GET",
+		Path:    "/v1/wallet/balance",
+		Header:  header,
+	}
+	call.SetParam("id", string(id))
 
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
@@ -269,21 +334,29 @@ func (w *WalletClient) GetWalletBalance(header http.Header, id did.Identifier) (
 // GetWalletInfo is used to get wallet base information.
 //
 func (w *WalletClient) GetWalletInfo(header http.Header, id did.Identifier) (result *wallet.WalletInfo, err error) {
-	r := w.c.NewRequest("GET", "/v1/wallet/info")
-	r.SetHeaders(header)
-	r.SetParam("id", string(id))
+	return w.getWalletInfo(w.parentContext(), header, id)
+}
+
+func (w *WalletClient) getWalletInfo(ctx context.Context, header http.Header, id did.Identifier) (result *wallet.WalletInfo, err error) {
+	op := w.begin(ctx, "GetWalletInfo", AttrDID.String(string(id)))
+	defer func() { op.end(err) }()
+
+	call := &Call{
+		Context: op.ctx,
+		Name:    "GetWalletInfo",
+		Method:  "GET",
+		Path:    "/v1/wallet/info",
+		Header:  header,
+	}
+	call.SetParam("id", string(id))
 
-	_, resp, err := restapi.RequireOK(w.c.DoRequest(r))
+	err = w.invoke(call)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
 
 	// parse http response
-	var respBody rtstructs.Response
-	if err = restapi.DecodeBody(resp, &respBody); err != nil {
-		return
-	}
+	respBody := call.Response
 
 	if respBody.ErrCode != errors.SuccCode {
 		err = rest.CodedError(respBody.ErrCode, respBody.ErrMessage)
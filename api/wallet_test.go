@@ -21,39 +21,31 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
 	"github.com/arxanchain/sdk-go-common/rest"
 	"github.com/arxanchain/sdk-go-common/rest/api"
 	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs"
 	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
 	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/localsafebox"
 	gock "gopkg.in/h2non/gock.v1"
 )
 
 var (
-	walletClient wallet.IWalletClient
+	walletClient IWalletClient
 )
 
 func initWalletClient(t *testing.T) {
-	client := &http.Client{Transport: &http.Transport{}}
-	gock.InterceptClient(client)
-	var err error
-	walletClient, err = NewWalletClient(&api.Config{Address: "http://127.0.0.1:8006", HttpClient: client})
-	if err != nil {
-		t.Fatalf("New walletc client fail: %v", err)
-	}
+	walletClient = newTestWalletClient(t)
 }
 
 func initWalletClientWithTrustKeypair(t *testing.T) {
-	client := &http.Client{Transport: &http.Transport{}}
-	gock.InterceptClient(client)
-	var err error
-	walletClient, err = NewWalletClient(&api.Config{Address: "http://127.0.0.1:8006", HttpClient: client, TrusteeKeyPairEnable: true})
-	if err != nil {
-		t.Fatalf("New walletc client fail: %v", err)
-	}
+	walletClient = newTestWalletClientWithConfig(t, &api.Config{TrusteeKeyPairEnable: true})
 }
 
 func TestRegisterSucc(t *testing.T) {
@@ -732,3 +724,138 @@ func TestGetWalletInfoFailErrCode(t *testing.T) {
 		t.Fatalf("WalletInfo object should be nil when query fail")
 	}
 }
+
+func TestNewWalletClientConfigUnchanged(t *testing.T) {
+	config := &api.Config{Address: "http://127.0.0.1:8006", TrusteeKeyPairEnable: true}
+	if _, err := NewWalletClient(config); err != nil {
+		t.Fatalf("New wallet client fail: %v", err)
+	}
+	if config.RouteTag != "" {
+		t.Fatalf("caller config should not be changed, route tag is %q", config.RouteTag)
+	}
+}
+
+func TestSharedHeaderAndSignParams(t *testing.T) {
+	const (
+		apiKey       = "api-key-001"
+		securityCode = "security-code-001"
+		workers      = 8
+	)
+
+	w := newTestWalletClientWithConfig(t, &api.Config{
+		ApiKey:               apiKey,
+		TrusteeKeyPairEnable: true,
+	})
+	defer gock.Off()
+
+	//mock http requests
+	gock.New("http://127.0.0.1:8006").
+		Post("/").
+		MatchHeader(structs.APIKeyHeader, apiKey).
+		AddMatcher(matchBodyContains(securityCode)).
+		Persist().
+		Reply(200).
+		JSON(jsonPayload(t, map[string]string{"private_key": testPrivateKey}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		Persist().
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 1)}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001": {Creator: "did:axn:001", Nonce: "helloalice", PrivateKey: testPrivateKey},
+		})).
+		Persist().
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{TransactionIds: []string{"trans-id-001"}}))
+
+	//a single header and sign params are shared by every goroutine
+	header := http.Header{}
+	header.Set("Bc-Invoke-Mode", "sync")
+	signParams := &pki.SignatureParam{
+		Creator:      "did:axn:001",
+		Nonce:        "helloalice",
+		SecurityCode: securityCode,
+	}
+	body := &wallet.TransferCTokenBody{From: "did:axn:001", To: "did:axn:002", Tokens: []*wallet.TokenAmount{{TokenId: "token-id-001", Amount: 50}}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := w.TransferCToken(header, body, signParams)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("transfer with shared params fail: %v", err)
+		}
+	}
+
+	if header.Get(structs.APIKeyHeader) != "" || len(header) != 1 {
+		t.Fatalf("caller header should not be changed: %v", header)
+	}
+	if signParams.SecurityCode != securityCode || signParams.PrivateKey != "" {
+		t.Fatalf("caller sign params should not be changed: %+v", signParams)
+	}
+}
+
+func TestWithSafebox(t *testing.T) {
+	box, err := localsafebox.New(t.TempDir(), make([]byte, localsafebox.KeySize))
+	if err != nil {
+		t.Fatalf("new local safebox fail: %v", err)
+	}
+	w := newTestWalletClient(t, WithSafebox(box))
+	defer gock.Off()
+
+	//the key pair is taken in trust by the injected safebox
+	gock.New("http://127.0.0.1:8006").
+		Post("/v1/wallet/register").
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{Id: "did:axn:001", KeyPair: &wallet.KeyPair{PrivateKey: testPrivateKey}}))
+	resp, err := w.Register(http.Header{}, &wallet.RegisterWalletBody{
+		Type:   pw.DidType_ORGANIZATION,
+		Access: "alice",
+		Secret: "123456",
+	})
+	if err != nil {
+		t.Fatalf("register wallet fail: %v", err)
+	}
+	if resp.KeyPair.PrivateKey != "" || resp.SecurityCode == "" {
+		t.Fatalf("private key should be replaced by the security code: %+v", resp)
+	}
+
+	//and unsealed with the security code to sign
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/tokens/transfer/prepare").
+		Reply(200).
+		JSON(jsonPayload(t, []*pw.TX{newTestTx(t, "did:axn:001", 1)}))
+	gock.New("http://127.0.0.1:8006").
+		Post("/v2/transaction/process").
+		AddMatcher(matchSignedTxs(map[string]*pki.SignatureParam{
+			"did:axn:001": {Creator: "did:axn:001", Nonce: "helloalice", PrivateKey: testPrivateKey},
+		})).
+		Reply(200).
+		JSON(jsonPayload(t, &wallet.WalletResponse{TransactionIds: []string{"trans-id-001"}}))
+	_, err = w.TransferCToken(http.Header{}, &wallet.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*wallet.TokenAmount{{TokenId: "token-id-001", Amount: 50}},
+	}, &pki.SignatureParam{
+		Creator:      "did:axn:001",
+		Nonce:        "helloalice",
+		SecurityCode: resp.SecurityCode,
+	})
+	if err != nil {
+		t.Fatalf("transfer with security code fail: %v", err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending mocks: %v", gock.Pending())
+	}
+}
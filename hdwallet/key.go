@@ -0,0 +1,147 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hdwallet derives wallet key pairs from a single master seed,
+// following SLIP-0010 for ed25519 keys, backs the seed up as a BIP-39
+// mnemonic, and keeps the registry of the sub wallets derived from it.
+package hdwallet
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/arxanchain/wallet-sdk-go/api"
+)
+
+// HardenedOffset is added to the index of hardened children. ed25519
+// only supports hardened derivation.
+//
+const HardenedOffset uint32 = 0x80000000
+
+// masterSecret is the HMAC key of the master key derivation.
+var masterSecret = []byte("ed25519 seed")
+
+// Key is an extended private key of a SLIP-0010 ed25519 derivation
+// tree.
+//
+type Key struct {
+	key       []byte
+	chainCode []byte
+}
+
+// NewMasterKey returns the master key derived from seed, which should
+// hold 16 to 64 bytes.
+//
+func NewMasterKey(seed []byte) (*Key, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed length invalid: %d", len(seed))
+	}
+	return newKey(masterSecret, seed), nil
+}
+
+func newKey(secret, data []byte) *Key {
+	mac := hmac.New(sha512.New, secret)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return &Key{key: sum[:32], chainCode: sum[32:]}
+}
+
+// Child returns the hardened child key of the given index. Indexes
+// below HardenedOffset are hardened.
+//
+func (k *Key) Child(index uint32) *Key {
+	data := make([]byte, 37)
+	copy(data[1:], k.key)
+	binary.BigEndian.PutUint32(data[33:], index|HardenedOffset)
+	return newKey(k.chainCode, data)
+}
+
+// Derive returns the key at path, such as "m/44'/0'/1'", relative to
+// the master key k.
+//
+func (k *Key) Derive(path string) (*Key, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := k
+	for _, index := range indexes {
+		key = key.Child(index)
+	}
+	return key, nil
+}
+
+// PrivateKey returns the 32 bytes private key, the seed of the ed25519
+// key pair.
+//
+func (k *Key) PrivateKey() []byte {
+	return append([]byte(nil), k.key...)
+}
+
+// ChainCode returns the 32 bytes chain code.
+//
+func (k *Key) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// KeyPair returns the ed25519 key pair of the key.
+//
+func (k *Key) KeyPair() *api.KeyPair {
+	private := ed25519.NewKeyFromSeed(k.key)
+	return &api.KeyPair{
+		PublicKey:  private.Public().(ed25519.PublicKey),
+		PrivateKey: private,
+	}
+}
+
+// ParsePath parses a derivation path such as "m/44'/0'/1'" into its
+// child indexes. Every index must be hardened, marked with ' or H.
+//
+func ParsePath(path string) (indexes []uint32, err error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path %q invalid: must start with m", path)
+	}
+	for _, part := range parts[1:] {
+		trimmed := strings.TrimRight(part, "'H")
+		if len(trimmed) != len(part)-1 {
+			return nil, fmt.Errorf("derivation path %q invalid: %q is not hardened", path, part)
+		}
+		index, err := strconv.ParseUint(trimmed, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("derivation path %q invalid: %q is not an index", path, part)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// FormatPath formats child indexes as a derivation path, the inverse
+// of ParsePath.
+//
+func FormatPath(indexes ...uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range indexes {
+		fmt.Fprintf(&b, "/%d'", index&^HardenedOffset)
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hdwallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+)
+
+// SLIP-0010 ed25519 test vector 1.
+var slip10Vectors = []struct {
+	path      string
+	chainCode string
+	private   string
+	public    string
+}{
+	{
+		path:      "m",
+		chainCode: "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb",
+		private:   "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+		public:    "a4b2856bfec510abab89753fac1ac0e1112364e7d250545963f135f2a33188ed",
+	},
+	{
+		path:      "m/0'",
+		chainCode: "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69",
+		private:   "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+		public:    "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c",
+	},
+	{
+		path:      "m/0H/1H",
+		chainCode: "a320425f77d1b5c2505a6b1b27382b37368ee640e3557c315416801243552f14",
+		private:   "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+		public:    "1932a5270f335bed617d5b935c80aedb1a35bd9fc1e31acafd5372c30f5c1187",
+	},
+}
+
+func TestDeriveSLIP10Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatalf("new master key fail: %v", err)
+	}
+	for _, v := range slip10Vectors {
+		key, err := master.Derive(v.path)
+		if err != nil {
+			t.Fatalf("derive %s fail: %v", v.path, err)
+		}
+		if got := hex.EncodeToString(key.ChainCode()); got != v.chainCode {
+			t.Fatalf("%s chain code should be %s not %s", v.path, v.chainCode, got)
+		}
+		if got := hex.EncodeToString(key.PrivateKey()); got != v.private {
+			t.Fatalf("%s private key should be %s not %s", v.path, v.private, got)
+		}
+		keyPair := key.KeyPair()
+		if got := hex.EncodeToString(keyPair.PublicKey); got != v.public {
+			t.Fatalf("%s public key should be %s not %s", v.path, v.public, got)
+		}
+		if !bytes.Equal(keyPair.PrivateKey.Public().(ed25519.PublicKey), keyPair.PublicKey) {
+			t.Fatalf("%s key pair inconsistent", v.path)
+		}
+	}
+
+	if _, err = NewMasterKey(seed[:8]); err == nil {
+		t.Fatalf("short seed should fail")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indexes, err := ParsePath("m/44'/9000H/0'")
+	if err != nil {
+		t.Fatalf("parse path fail: %v", err)
+	}
+	if len(indexes) != 3 || indexes[0] != 44 || indexes[1] != 9000 || indexes[2] != 0 {
+		t.Fatalf("indexes invalid: %v", indexes)
+	}
+	if path := FormatPath(indexes...); path != "m/44'/9000'/0'" {
+		t.Fatalf("formatted path invalid: %s", path)
+	}
+
+	for _, path := range []string{"", "44'/0'", "m/0", "m/0''", "m/a'", "m//0'", "m/2147483648'"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Fatalf("path %q should be invalid", path)
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	// BIP-39 test vector
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	seed, err := NewSeed(mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatalf("new seed fail: %v", err)
+	}
+	expected := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if hex.EncodeToString(seed) != expected {
+		t.Fatalf("seed should be %s not %x", expected, seed)
+	}
+	if _, err = NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", ""); err == nil {
+		t.Fatalf("mnemonic with a wrong checksum should fail")
+	}
+
+	mnemonic, err = NewMnemonic(256)
+	if err != nil {
+		t.Fatalf("new mnemonic fail: %v", err)
+	}
+	a, err := NewMasterKeyFromMnemonic(mnemonic, "passphrase")
+	if err != nil {
+		t.Fatalf("new master key fail: %v", err)
+	}
+	b, _ := NewMasterKeyFromMnemonic(mnemonic, "passphrase")
+	c, _ := NewMasterKeyFromMnemonic(mnemonic, "other")
+	if !bytes.Equal(a.PrivateKey(), b.PrivateKey()) || bytes.Equal(a.PrivateKey(), c.PrivateKey()) {
+		t.Fatalf("master key should only depend on the mnemonic and passphrase")
+	}
+	if _, err = NewMnemonic(100); err == nil {
+		t.Fatalf("invalid entropy size should fail")
+	}
+}
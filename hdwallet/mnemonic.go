@@ -0,0 +1,56 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hdwallet
+
+import (
+	"fmt"
+
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+// NewMnemonic returns a new BIP-39 english mnemonic of bits bits of
+// entropy, a multiple of 32 between 128 and 256: 128 bits give 12
+// words, 256 bits give 24 words.
+//
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NewSeed returns the 64 bytes seed of a BIP-39 mnemonic protected by
+// an optional passphrase. The mnemonic checksum is verified.
+//
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, fmt.Errorf("mnemonic invalid")
+	}
+	return bip39.NewSeed(mnemonic, passphrase), nil
+}
+
+// NewMasterKeyFromMnemonic returns the master key of a BIP-39 mnemonic
+// protected by an optional passphrase.
+//
+func NewMasterKeyFromMnemonic(mnemonic, passphrase string) (*Key, error) {
+	seed, err := NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewMasterKey(seed)
+}
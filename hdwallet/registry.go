@@ -0,0 +1,251 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hdwallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/api"
+)
+
+// Entry maps a derivation path to the sub wallet registered with the
+// key derived at that path.
+//
+type Entry struct {
+	Path string         `json:"path"`
+	Id   did.Identifier `json:"id"`
+	// PublicKey is the base64 encoded public key the sub wallet was
+	// registered with, used to check the recovered keys.
+	PublicKey string `json:"public_key"`
+}
+
+// Registry maps derivation paths to sub wallet DIDs. It holds no key
+// and can be stored with the other wallet records, as JSON.
+//
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]*Entry
+}
+
+// NewRegistry returns an empty registry.
+//
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]*Entry)}
+}
+
+// Add adds an entry, its path must not be registered yet.
+//
+func (r *Registry) Add(entry *Entry) error {
+	if _, err := ParsePath(entry.Path); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entries == nil {
+		r.entries = make(map[string]*Entry)
+	}
+	if e, ok := r.entries[entry.Path]; ok {
+		return fmt.Errorf("derivation path %s already registered to %s", entry.Path, e.Id)
+	}
+	r.entries[entry.Path] = entry
+	return nil
+}
+
+// Lookup returns the entry of path, or nil.
+//
+func (r *Registry) Lookup(path string) *Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.entries[path]
+}
+
+// Entries returns the entries in path order.
+//
+func (r *Registry) Entries() []*Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return lessPath(entries[i].Path, entries[j].Path)
+	})
+	return entries
+}
+
+// NextPath returns the first path under base, base/0', base/1' and so
+// on, which is not registered.
+//
+func (r *Registry) NextPath(base string) (string, error) {
+	indexes, err := ParsePath(base)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := uint32(0); i < HardenedOffset; i++ {
+		path := FormatPath(append(indexes, i)...)
+		if _, ok := r.entries[path]; !ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no derivation path left under %s", base)
+}
+
+// MarshalJSON encodes the entries as a JSON array in path order.
+//
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Entries())
+}
+
+// UnmarshalJSON decodes entries encoded by MarshalJSON.
+//
+func (r *Registry) UnmarshalJSON(data []byte) error {
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.entries = make(map[string]*Entry)
+	r.mu.Unlock()
+	for _, entry := range entries {
+		if err := r.Add(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lessPath orders paths by their indexes, so that m/10' comes after
+// m/9'.
+func lessPath(a, b string) bool {
+	ia, erra := ParsePath(a)
+	ib, errb := ParsePath(b)
+	if erra != nil || errb != nil {
+		return strings.Compare(a, b) < 0
+	}
+	for i := 0; i < len(ia) && i < len(ib); i++ {
+		if ia[i] != ib[i] {
+			return ia[i] < ib[i]
+		}
+	}
+	return len(ia) < len(ib)
+}
+
+// SubWallets registers sub wallets with keys derived from a master key,
+// and records them in a registry. It is safe for concurrent use, the
+// registrations are serialized.
+//
+type SubWallets struct {
+	Client   api.Registrar
+	Master   *Key
+	Registry *Registry
+	// BasePath is the path the sub wallet keys are derived under, at
+	// BasePath/0', BasePath/1' and so on.
+	BasePath string
+
+	mu sync.Mutex
+}
+
+// Register derives the key of the next free path under BasePath and
+// registers the sub wallet with its public key, see
+// api.WalletClient.RegisterSubWalletWithPublicKey.
+//
+// It returns the registered sub wallet and its key pair, the private
+// key is never sent. The registry entry is added on success only.
+//
+func (s *SubWallets) Register(header http.Header, body *wallet.RegisterSubWalletBody) (*wallet.WalletResponse, *api.KeyPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.Registry.NextPath(s.BasePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.registerAt(header, body, path)
+}
+
+// RegisterAt registers the sub wallet with the key derived at path.
+//
+func (s *SubWallets) RegisterAt(header http.Header, body *wallet.RegisterSubWalletBody, path string) (*wallet.WalletResponse, *api.KeyPair, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.registerAt(header, body, path)
+}
+
+func (s *SubWallets) registerAt(header http.Header, body *wallet.RegisterSubWalletBody, path string) (*wallet.WalletResponse, *api.KeyPair, error) {
+	if e := s.Registry.Lookup(path); e != nil {
+		return nil, nil, fmt.Errorf("derivation path %s already registered to %s", path, e.Id)
+	}
+	key, err := s.Master.Derive(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPair := key.KeyPair()
+
+	result, err := s.Client.RegisterSubWalletWithPublicKey(header, body, keyPair.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = s.Registry.Add(&Entry{
+		Path:      path,
+		Id:        result.Id,
+		PublicKey: keyPair.EncodedPublicKey(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, keyPair, nil
+}
+
+// Recover re-derives the key pairs of every sub wallet of the registry
+// from the master key, by DID.
+//
+// It fails if a derived public key does not match the one the sub
+// wallet was registered with, such as when the mnemonic or passphrase
+// is wrong.
+//
+func Recover(master *Key, registry *Registry) (map[did.Identifier]*api.KeyPair, error) {
+	keys := make(map[did.Identifier]*api.KeyPair)
+	for _, entry := range registry.Entries() {
+		key, err := master.Derive(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		keyPair := key.KeyPair()
+		if entry.PublicKey != "" && entry.PublicKey != keyPair.EncodedPublicKey() {
+			return nil, fmt.Errorf("key of %s derived at %s does not match its public key", entry.Id, entry.Path)
+		}
+		keys[entry.Id] = keyPair
+	}
+	return keys, nil
+}
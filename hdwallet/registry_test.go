@@ -0,0 +1,156 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hdwallet
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/api"
+	"github.com/arxanchain/wallet-sdk-go/mock"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func newSubWallets(t *testing.T) (*SubWallets, *mock.WalletClient) {
+	master, err := NewMasterKeyFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("new master key fail: %v", err)
+	}
+	client := &mock.WalletClient{}
+	n := 0
+	client.RegisterSubWalletWithPublicKeyFunc = func(header http.Header, body *wallet.RegisterSubWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error) {
+		n++
+		return &wallet.WalletResponse{Id: did.Identifier(fmt.Sprintf("did:axn:sub-%03d", n))}, nil
+	}
+	return &SubWallets{
+		Client:   client,
+		Master:   master,
+		Registry: NewRegistry(),
+		BasePath: "m/44'/9000'/0'",
+	}, client
+}
+
+func TestSubWalletsRegister(t *testing.T) {
+	subWallets, client := newSubWallets(t)
+	body := &wallet.RegisterSubWalletBody{Id: "did:axn:parent"}
+
+	keys := make(map[did.Identifier]*api.KeyPair)
+	for i := 0; i < 3; i++ {
+		resp, keyPair, err := subWallets.Register(http.Header{}, body)
+		if err != nil {
+			t.Fatalf("register sub wallet fail: %v", err)
+		}
+		keys[resp.Id] = keyPair
+	}
+	if _, _, err := subWallets.RegisterAt(http.Header{}, body, "m/44'/9000'/0'/1'"); err == nil {
+		t.Fatalf("register at a registered path should fail")
+	}
+	if n := client.CallCount("RegisterSubWalletWithPublicKey"); n != 3 {
+		t.Fatalf("RegisterSubWalletWithPublicKey should be called 3 times not %d", n)
+	}
+
+	// Only the public key of the derived key is sent
+	call := client.Calls()[1]
+	publicKey := call.Args[2].(ed25519.PublicKey)
+	entry := subWallets.Registry.Lookup("m/44'/9000'/0'/1'")
+	if entry == nil || entry.Id != "did:axn:sub-002" {
+		t.Fatalf("registry entry invalid: %+v", entry)
+	}
+	if !publicKey.Equal(keys[entry.Id].PublicKey) {
+		t.Fatalf("registered public key should be the derived one")
+	}
+
+	// The registry alone and the mnemonic recover every key
+	data, err := json.Marshal(subWallets.Registry)
+	if err != nil {
+		t.Fatalf("marshal registry fail: %v", err)
+	}
+	registry := NewRegistry()
+	if err = json.Unmarshal(data, registry); err != nil {
+		t.Fatalf("unmarshal registry fail: %v", err)
+	}
+	master, err := NewMasterKeyFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	recovered, err := Recover(master, registry)
+	if err != nil {
+		t.Fatalf("recover fail: %v", err)
+	}
+	if len(recovered) != 3 {
+		t.Fatalf("3 keys should be recovered not %d", len(recovered))
+	}
+	for id, keyPair := range keys {
+		if !keyPair.PrivateKey.Equal(recovered[id].PrivateKey) {
+			t.Fatalf("recovered key of %s should be the registered one", id)
+		}
+	}
+
+	// A wrong passphrase is detected
+	wrong, err := NewMasterKeyFromMnemonic(testMnemonic, "wrong")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err = Recover(wrong, registry); err == nil {
+		t.Fatalf("recover with a wrong passphrase should fail")
+	}
+}
+
+func TestSubWalletsRegisterFail(t *testing.T) {
+	subWallets, client := newSubWallets(t)
+	client.RegisterSubWalletWithPublicKeyFunc = func(header http.Header, body *wallet.RegisterSubWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error) {
+		return nil, fmt.Errorf("platform error")
+	}
+	if _, _, err := subWallets.Register(http.Header{}, &wallet.RegisterSubWalletBody{Id: "did:axn:parent"}); err == nil {
+		t.Fatalf("register sub wallet should fail")
+	}
+	if entries := subWallets.Registry.Entries(); len(entries) != 0 {
+		t.Fatalf("failed registration should not be recorded: %v", entries)
+	}
+}
+
+func TestRegistryEntries(t *testing.T) {
+	registry := NewRegistry()
+	for _, path := range []string{"m/0'/10'", "m/0'/9'", "m/1'", "m/0'"} {
+		if err := registry.Add(&Entry{Path: path, Id: did.Identifier("did:axn:" + path)}); err != nil {
+			t.Fatalf("add %s fail: %v", path, err)
+		}
+	}
+	if err := registry.Add(&Entry{Path: "m/1'"}); err == nil {
+		t.Fatalf("add a registered path should fail")
+	}
+	if err := registry.Add(&Entry{Path: "m/1"}); err == nil {
+		t.Fatalf("add an invalid path should fail")
+	}
+
+	var paths []string
+	for _, entry := range registry.Entries() {
+		paths = append(paths, entry.Path)
+	}
+	if fmt.Sprint(paths) != "[m/0' m/0'/9' m/0'/10' m/1']" {
+		t.Fatalf("entries should be in path order: %v", paths)
+	}
+	if path, _ := registry.NextPath("m/0'"); path != "m/0'/0'" {
+		t.Fatalf("next path should be m/0'/0' not %s", path)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keyfile seals private keys with AES-256-GCM and writes them
+// to files only readable by their owner. It is shared by the key stores
+// of the SDK.
+package keyfile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// ErrCorrupted is returned by Open when the nonce of the sealed data
+// does not fit the cipher.
+var ErrCorrupted = errors.New("sealed data corrupted")
+
+// NewAEAD returns the AES-GCM cipher of key.
+func NewAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// AdditionalData binds a sealed private key to its wallet, a file
+// copied under another DID fails to unseal.
+func AdditionalData(id, publicKey string) []byte {
+	return []byte(id + "\n" + publicKey)
+}
+
+// Seal seals plaintext with a random nonce.
+func Seal(aead cipher.AEAD, plaintext, additionalData []byte) (nonce, sealed []byte, err error) {
+	nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+	sealed = aead.Seal(nil, nonce, plaintext, additionalData)
+	return
+}
+
+// Open unseals data sealed by Seal.
+func Open(aead cipher.AEAD, nonce, sealed, additionalData []byte) ([]byte, error) {
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrCorrupted
+	}
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
+
+// Name returns the file name of the key pair of wallet id.
+func Name(id string) string {
+	return url.QueryEscape(id) + ".json"
+}
+
+// WriteFile writes data to the file name of dir, only readable by its
+// owner. It writes to a temporary file renamed once complete, so that a
+// crash never leaves a truncated file behind.
+func WriteFile(dir, name string, data []byte) (err error) {
+	f, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, name))
+}
@@ -0,0 +1,259 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package localsafebox is a software safebox keeping wallet key pairs in
+// encrypted files, for air-gapped and test environments where the
+// safebox service is not reachable. It takes the place of the service
+// with api.WithSafebox.
+//
+// Each private key is sealed with AES-256-GCM under a key derived from
+// the master key of the safebox and the security code returned when it
+// is taken in trust, so that neither the files nor the master key alone
+// reveal it.
+package localsafebox
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/arxanchain/sdk-go-common/structs/safebox"
+	"github.com/arxanchain/wallet-sdk-go/internal/keyfile"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeySize is the size of the master key of a safebox.
+//
+const KeySize = 32
+
+const (
+	metaFile     = "safebox.json"
+	saltSize     = 16
+	codeSize     = 16
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	checkMessage = "localsafebox"
+)
+
+// Safebox keeps key pairs in a directory, one file per wallet.
+//
+type Safebox struct {
+	dir string
+	key []byte
+	mu  sync.Mutex
+}
+
+var _ safebox.ISafeboxClient = (*Safebox)(nil)
+
+// record is the file content of a key pair.
+type record struct {
+	UserDid   string `json:"user_did"`
+	PublicKey string `json:"public_key"`
+	Nonce     []byte `json:"nonce"`
+	Sealed    []byte `json:"sealed"`
+}
+
+// meta is the file content describing the master key derivation of a
+// safebox opened with a passphrase.
+type meta struct {
+	Salt  []byte `json:"salt"`
+	Check []byte `json:"check"`
+}
+
+// New returns a safebox keeping key pairs in dir, sealed with the
+// KeySize bytes master key. The directory is created if needed.
+//
+func New(dir string, key []byte) (*Safebox, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("master key size should be %d bytes, not %d", KeySize, len(key))
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Safebox{dir: dir, key: append([]byte(nil), key...)}, nil
+}
+
+// Open returns a safebox keeping key pairs in dir, with a master key
+// derived from passphrase with scrypt. The salt is generated when the
+// safebox is created, later opens fail if passphrase is not the one it
+// was created with.
+//
+func Open(dir string, passphrase []byte) (s *Safebox, err error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must be set")
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+
+	var m meta
+	data, err := ioutil.ReadFile(filepath.Join(dir, metaFile))
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, &m); err != nil {
+			return
+		}
+	case os.IsNotExist(err):
+		m.Salt = make([]byte, saltSize)
+		if _, err = rand.Read(m.Salt); err != nil {
+			return
+		}
+	default:
+		return
+	}
+
+	key, err := scrypt.Key(passphrase, m.Salt, scryptN, scryptR, scryptP, KeySize)
+	if err != nil {
+		return
+	}
+	check := mac(key, checkMessage)
+	if m.Check == nil {
+		m.Check = check
+		if data, err = json.Marshal(&m); err != nil {
+			return
+		}
+		if err = keyfile.WriteFile(dir, metaFile, data); err != nil {
+			return
+		}
+	} else if !hmac.Equal(m.Check, check) {
+		return nil, fmt.Errorf("passphrase invalid")
+	}
+
+	return &Safebox{dir: dir, key: key}, nil
+}
+
+// TrusteeKeyPair seals the key pair of a wallet and returns the security
+// code unsealing it. A key pair already kept for the wallet is replaced,
+// its security code no longer works.
+//
+func (s *Safebox) TrusteeKeyPair(header http.Header, body *safebox.SaveKeyPairRequetBody) (result *safebox.SecurityCode, err error) {
+	if body == nil || body.UserDid == "" || body.PrivateKey == "" {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+
+	code := make([]byte, codeSize)
+	if _, err = rand.Read(code); err != nil {
+		return
+	}
+	securityCode := hex.EncodeToString(code)
+
+	aead, err := s.aead(securityCode)
+	if err != nil {
+		return
+	}
+	r := &record{
+		UserDid:   body.UserDid,
+		PublicKey: body.PublicKey,
+	}
+	r.Nonce, r.Sealed, err = keyfile.Seal(aead, []byte(body.PrivateKey), keyfile.AdditionalData(body.UserDid, body.PublicKey))
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = keyfile.WriteFile(s.dir, keyfile.Name(body.UserDid), data); err != nil {
+		return
+	}
+
+	result = &safebox.SecurityCode{Code: securityCode}
+	return
+}
+
+// QueryPrivateKey unseals the key pair of a wallet with its security
+// code.
+//
+func (s *Safebox) QueryPrivateKey(header http.Header, body *safebox.OperateKeyInfo) (result *safebox.KeyPair, err error) {
+	if body == nil || body.UserDid == "" || body.Code == "" {
+		err = fmt.Errorf("request payload invalid")
+		return
+	}
+	r, err := s.load(body.UserDid)
+	if err != nil {
+		return
+	}
+	aead, err := s.aead(body.Code)
+	if err != nil {
+		return
+	}
+	// The requested DID is bound, not the one stored in the file
+	privateKey, err := keyfile.Open(aead, r.Nonce, r.Sealed, keyfile.AdditionalData(body.UserDid, r.PublicKey))
+	if err == keyfile.ErrCorrupted {
+		err = fmt.Errorf("key pair of %s corrupted", body.UserDid)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("security code invalid")
+		return
+	}
+
+	result = &safebox.KeyPair{
+		PrivateKey: string(privateKey),
+		PublicKey:  r.PublicKey,
+	}
+	return
+}
+
+// QueryPublicKey returns the public key of a wallet, which is not
+// sealed.
+//
+func (s *Safebox) QueryPublicKey(header http.Header, id string) (publicKey string, err error) {
+	r, err := s.load(id)
+	if err != nil {
+		return
+	}
+	publicKey = r.PublicKey
+	return
+}
+
+func (s *Safebox) load(id string) (r *record, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, keyfile.Name(id)))
+	if os.IsNotExist(err) {
+		err = fmt.Errorf("key pair of %s not found", id)
+		return
+	}
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &r)
+	return
+}
+
+// aead returns the cipher sealing the private keys of securityCode.
+func (s *Safebox) aead(securityCode string) (cipher.AEAD, error) {
+	return keyfile.NewAEAD(mac(s.key, securityCode))
+}
+
+func mac(key []byte, message string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(message))
+	return h.Sum(nil)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localsafebox
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/arxanchain/sdk-go-common/structs/safebox"
+	"github.com/arxanchain/wallet-sdk-go/internal/keyfile"
+)
+
+const (
+	testDID        = "did:axn:001"
+	testPrivateKey = "WBZNmTTf34Kg+pQOTSIRL+JeQYDfj7InWc0A/9kvNvQSI8Ue8iRD8gn9CNmGO2EjJILF/3RELmEcbuS5G0d+Mg=="
+	testPublicKey  = "EiPFHvIkQ/IJ/QjZhjthIySCxf90RC5hHG7kuRtHfjI="
+)
+
+func trustee(t *testing.T, s *Safebox) string {
+	code, err := s.TrusteeKeyPair(http.Header{}, &safebox.SaveKeyPairRequetBody{
+		UserDid:    testDID,
+		PrivateKey: testPrivateKey,
+		PublicKey:  testPublicKey,
+	})
+	if err != nil {
+		t.Fatalf("trustee key pair fail: %v", err)
+	}
+	if code.Code == "" {
+		t.Fatalf("security code should be returned")
+	}
+	return code.Code
+}
+
+func TestSafebox(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, bytes.Repeat([]byte{1}, KeySize))
+	if err != nil {
+		t.Fatalf("new safebox fail: %v", err)
+	}
+	code := trustee(t, s)
+
+	keyPair, err := s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID, Code: code})
+	if err != nil {
+		t.Fatalf("query private key fail: %v", err)
+	}
+	if keyPair.PrivateKey != testPrivateKey || keyPair.PublicKey != testPublicKey {
+		t.Fatalf("key pair should be unsealed: %+v", keyPair)
+	}
+	publicKey, err := s.QueryPublicKey(http.Header{}, testDID)
+	if err != nil || publicKey != testPublicKey {
+		t.Fatalf("public key should be %s, not %s: %v", testPublicKey, publicKey, err)
+	}
+
+	//the private key is not stored in clear
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("safebox should hold one file: %v %v", files, err)
+	}
+	data, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if bytes.Contains(data, []byte(testPrivateKey)) {
+		t.Fatalf("private key should be sealed")
+	}
+	info, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("key pair file should only be readable by its owner, mode %v", info.Mode())
+	}
+
+	//wrong security code, master key or wallet
+	if _, err = s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID, Code: "0123"}); err == nil {
+		t.Fatalf("query with a wrong security code should fail")
+	}
+	other, err := New(dir, bytes.Repeat([]byte{2}, KeySize))
+	if err != nil {
+		t.Fatalf("new safebox fail: %v", err)
+	}
+	if _, err = other.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID, Code: code}); err == nil {
+		t.Fatalf("query with a wrong master key should fail")
+	}
+	if _, err = s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: "did:axn:002", Code: code}); err == nil {
+		t.Fatalf("query of an unknown wallet should fail")
+	}
+
+	//trusting the key pair again revokes the previous code
+	newCode := trustee(t, s)
+	if _, err = s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID, Code: code}); err == nil {
+		t.Fatalf("query with a revoked security code should fail")
+	}
+	if _, err = s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID, Code: newCode}); err != nil {
+		t.Fatalf("query with the new security code fail: %v", err)
+	}
+}
+
+func TestSafeboxCopiedRecord(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, make([]byte, KeySize))
+	if err != nil {
+		t.Fatalf("new safebox fail: %v", err)
+	}
+	code := trustee(t, s)
+
+	//the key pair file of the wallet is copied under another DID
+	data, err := ioutil.ReadFile(filepath.Join(dir, keyfile.Name(testDID)))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, keyfile.Name("did:axn:002")), data, 0600); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err = s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: "did:axn:002", Code: code}); err == nil {
+		t.Fatalf("query of a key pair copied under another DID should fail")
+	}
+}
+
+func TestSafeboxInvalid(t *testing.T) {
+	if _, err := New(t.TempDir(), []byte("short")); err == nil {
+		t.Fatalf("new safebox with a short key should fail")
+	}
+	s, err := New(t.TempDir(), make([]byte, KeySize))
+	if err != nil {
+		t.Fatalf("new safebox fail: %v", err)
+	}
+	if _, err = s.TrusteeKeyPair(http.Header{}, &safebox.SaveKeyPairRequetBody{UserDid: testDID}); err == nil {
+		t.Fatalf("trustee of an empty private key should fail")
+	}
+	if _, err = s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID}); err == nil {
+		t.Fatalf("query without security code should fail")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("open safebox fail: %v", err)
+	}
+	code := trustee(t, s)
+
+	if _, err = Open(dir, []byte("wrong")); err == nil {
+		t.Fatalf("open with a wrong passphrase should fail")
+	}
+	s, err = Open(dir, []byte("passphrase"))
+	if err != nil {
+		t.Fatalf("reopen safebox fail: %v", err)
+	}
+	keyPair, err := s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID, Code: code})
+	if err != nil || keyPair.PrivateKey != testPrivateKey {
+		t.Fatalf("key pair should be unsealed after reopening: %+v %v", keyPair, err)
+	}
+}
+
+func TestSafeboxConcurrent(t *testing.T) {
+	s, err := New(t.TempDir(), make([]byte, KeySize))
+	if err != nil {
+		t.Fatalf("new safebox fail: %v", err)
+	}
+	code := trustee(t, s)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.QueryPrivateKey(http.Header{}, &safebox.OperateKeyInfo{UserDid: testDID, Code: code}); err != nil {
+				t.Errorf("query private key fail: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,164 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exports wallet client metrics to Prometheus.
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arxanchain/sdk-go-common/errors"
+	"github.com/arxanchain/wallet-sdk-go/api"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "wallet_client"
+
+// Collector is a prometheus.Collector of wallet client metrics.
+//
+// It counts requests and their latency per platform endpoint, error
+// codes returned by the platform, failed safebox key lookups and
+// blockchain transaction events received on the callback url.
+//
+type Collector struct {
+	requests        *prometheus.CounterVec
+	latency         *prometheus.HistogramVec
+	platformErrors  *prometheus.CounterVec
+	safeboxFailures *prometheus.CounterVec
+	callbackEvents  *prometheus.CounterVec
+}
+
+// NewCollector returns a Collector observing the requests of w.
+//
+// It must be called right after api.NewWalletClient, before w is used.
+//
+func NewCollector(w *api.WalletClient) *Collector {
+	c := &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of requests sent to the wallet platform.",
+		}, []string{"method", "endpoint", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests sent to the wallet platform.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "endpoint"}),
+		platformErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "platform_errors_total",
+			Help:      "Number of error codes returned by the wallet platform.",
+		}, []string{"endpoint", "errcode"}),
+		safeboxFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "safebox_failures_total",
+			Help:      "Number of failed safebox operations.",
+		}, []string{"operation"}),
+		callbackEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "callback_events_total",
+			Help:      "Number of blockchain transaction events received on the callback url.",
+		}, []string{"status"}),
+	}
+
+	w.Use(c.middleware)
+	w.OnSafebox(c.observeSafebox)
+
+	return c
+}
+
+// Describe implements prometheus.Collector.
+//
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.latency.Describe(ch)
+	c.platformErrors.Describe(ch)
+	c.safeboxFailures.Describe(ch)
+	c.callbackEvents.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+//
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.latency.Collect(ch)
+	c.platformErrors.Collect(ch)
+	c.safeboxFailures.Collect(ch)
+	c.callbackEvents.Collect(ch)
+}
+
+func (c *Collector) middleware(next api.Handler) api.Handler {
+	return func(call *api.Call) error {
+		start := time.Now()
+		err := next(call)
+		c.latency.WithLabelValues(call.Method, call.Path).Observe(time.Since(start).Seconds())
+
+		result := "ok"
+		switch {
+		case err != nil:
+			result = "error"
+		case call.Response != nil && call.Response.ErrCode != errors.SuccCode:
+			result = "platform_error"
+			c.platformErrors.WithLabelValues(call.Path, strconv.Itoa(call.Response.ErrCode)).Inc()
+		}
+		c.requests.WithLabelValues(call.Method, call.Path, result).Inc()
+
+		return err
+	}
+}
+
+func (c *Collector) observeSafebox(event *api.SafeboxEvent) {
+	if event.Err != nil {
+		c.safeboxFailures.WithLabelValues(event.Operation).Inc()
+	}
+}
+
+// CallbackHandler wraps the http handler serving the callback url and
+// counts the blockchain transaction events it receives, labelled by
+// status "valid", "invalid" or "malformed".
+//
+func (c *Collector) CallbackHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			c.callbackEvents.WithLabelValues("malformed").Inc()
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		var event struct {
+			IsInvalid bool `json:"is_invalid"`
+		}
+		switch {
+		case json.Unmarshal(body, &event) != nil:
+			c.callbackEvents.WithLabelValues("malformed").Inc()
+		case event.IsInvalid:
+			c.callbackEvents.WithLabelValues("invalid").Inc()
+		default:
+			c.callbackEvents.WithLabelValues("valid").Inc()
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
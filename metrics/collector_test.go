@@ -0,0 +1,170 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	restapi "github.com/arxanchain/sdk-go-common/rest/api"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	gock "gopkg.in/h2non/gock.v1"
+)
+
+func newCollector(t *testing.T, trustee bool) (*api.WalletClient, *Collector, *httptest.Server) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	w, err := api.NewWalletClient(&restapi.Config{
+		Address:              "http://127.0.0.1:8006",
+		HttpClient:           client,
+		TrusteeKeyPairEnable: trustee,
+	})
+	if err != nil {
+		t.Fatalf("New wallet client fail: %v", err)
+	}
+
+	c := NewCollector(w)
+	registry := prometheus.NewRegistry()
+	if err = registry.Register(c); err != nil {
+		t.Fatalf("register collector fail: %v", err)
+	}
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	return w, c, server
+}
+
+func scrape(t *testing.T, server *httptest.Server) string {
+	// gock replaces http.DefaultTransport, scrape with a dedicated one
+	client := &http.Client{Transport: &http.Transport{}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("scrape metrics fail: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read metrics fail: %v", err)
+	}
+	return string(body)
+}
+
+func expectMetric(t *testing.T, metrics, line string) {
+	if !strings.Contains(metrics, line) {
+		t.Fatalf("metrics should contain [%s], got:\n%s", line, metrics)
+	}
+}
+
+func TestCollectorRequests(t *testing.T) {
+	w, _, server := newCollector(t, false)
+	defer server.Close()
+	defer gock.Off()
+
+	byPayload, err := json.Marshal(&wallet.WalletBalance{})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	//mock http request
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/balance").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 0, Payload: string(byPayload)})
+	gock.New("http://127.0.0.1:8006").
+		Get("/v1/wallet/balance").
+		Reply(200).
+		JSON(&rtstructs.Response{ErrCode: 8005, ErrMessage: "wallet not found"})
+
+	if _, err = w.GetWalletBalance(http.Header{}, "did:axn:001"); err != nil {
+		t.Fatalf("get wallet balance fail: %v", err)
+	}
+	if _, err = w.GetWalletBalance(http.Header{}, "did:axn:002"); err == nil {
+		t.Fatalf("get wallet balance should fail")
+	}
+	if _, err = w.GetWalletBalance(http.Header{}, "did:axn:003"); err == nil {
+		t.Fatalf("get wallet balance should fail without mock")
+	}
+
+	metrics := scrape(t, server)
+	expectMetric(t, metrics, `wallet_client_requests_total{endpoint="/v1/wallet/balance",method="GET",result="ok"} 1`)
+	expectMetric(t, metrics, `wallet_client_requests_total{endpoint="/v1/wallet/balance",method="GET",result="platform_error"} 1`)
+	expectMetric(t, metrics, `wallet_client_requests_total{endpoint="/v1/wallet/balance",method="GET",result="error"} 1`)
+	expectMetric(t, metrics, `wallet_client_request_duration_seconds_count{endpoint="/v1/wallet/balance",method="GET"} 3`)
+	expectMetric(t, metrics, `wallet_client_platform_errors_total{endpoint="/v1/wallet/balance",errcode="8005"} 1`)
+}
+
+func TestCollectorSafeboxFailures(t *testing.T) {
+	w, _, server := newCollector(t, true)
+	defer server.Close()
+	defer gock.Off()
+
+	//no safebox mock, the private key lookup fails
+	body := &wallet.TransferCTokenBody{
+		From:   "did:axn:001",
+		To:     "did:axn:002",
+		Tokens: []*wallet.TokenAmount{{TokenId: "token-id-001", Amount: 50}},
+	}
+	signParam := &pki.SignatureParam{
+		Creator:      "did:axn:001",
+		Nonce:        "nonce",
+		SecurityCode: "security-code",
+	}
+	if _, err := w.TransferCToken(http.Header{}, body, signParam); err == nil {
+		t.Fatalf("transfer colored token should fail")
+	}
+
+	metrics := scrape(t, server)
+	expectMetric(t, metrics, `wallet_client_safebox_failures_total{operation="QueryPrivateKey"} 1`)
+}
+
+func TestCollectorCallbackEvents(t *testing.T) {
+	_, c, server := newCollector(t, false)
+	defer server.Close()
+
+	var received []string
+	handler := c.CallbackHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, string(body))
+	}))
+
+	events := []string{
+		`{"transaction_id":"tx-001","is_invalid":false}`,
+		`{"transaction_id":"tx-002","is_invalid":true}`,
+		`not json`,
+	}
+	for _, event := range events {
+		r := httptest.NewRequest("POST", "/callback", bytes.NewBufferString(event))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if len(received) != len(events) || received[0] != events[0] {
+		t.Fatalf("callback handler should forward the events unchanged: %v", received)
+	}
+
+	metrics := scrape(t, server)
+	expectMetric(t, metrics, `wallet_client_callback_events_total{status="valid"} 1`)
+	expectMetric(t, metrics, `wallet_client_callback_events_total{status="invalid"} 1`)
+	expectMetric(t, metrics, `wallet_client_callback_events_total{status="malformed"} 1`)
+}
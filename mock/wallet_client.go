@@ -0,0 +1,521 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mock provides a mock of the wallet client, to test code
+// depending on api.IWalletClient or its role interfaces without a
+// wallet service.
+package mock
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"sync"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/api"
+)
+
+// Call is a recorded method call.
+//
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// WalletClient is a mock implementing api.IWalletClient.
+//
+// Each method records its call and delegates to the function field of
+// the same name suffixed with Func. Methods whose function is not set
+// return a "not implemented" error and zero values.
+//
+type WalletClient struct {
+	RegisterFunc                       func(header http.Header, body *wallet.RegisterWalletBody) (*wallet.WalletResponse, error)
+	RegisterSubWalletFunc              func(header http.Header, body *wallet.RegisterSubWalletBody) (*wallet.WalletResponse, error)
+	RegisterWithPublicKeyFunc          func(header http.Header, body *wallet.RegisterWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error)
+	RegisterSubWalletWithPublicKeyFunc func(header http.Header, body *wallet.RegisterSubWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error)
+	GetWalletBalanceFunc               func(header http.Header, id did.Identifier) (*wallet.WalletBalance, error)
+	GetWalletInfoFunc                  func(header http.Header, id did.Identifier) (*wallet.WalletInfo, error)
+	TrusteeKeyPairFunc                 func(header http.Header, id did.Identifier, keyPair *wallet.KeyPair) (*wallet.WalletResponse, error)
+	RotateKeyFunc                      func(header http.Header, body *api.RotateKeyBody, signParams *pki.SignatureParam) (*api.RotateKeyResponse, error)
+	SweepWalletFunc                    func(header http.Header, body *api.SweepBody, signParams *pki.SignatureParam) (*api.SweepResponse, error)
+	CreatePOEFunc                      func(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	UpdatePOEFunc                      func(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	QueryPOEFunc                       func(header http.Header, id did.Identifier) (*wallet.POEPayload, error)
+	UploadPOEFileFunc                  func(header http.Header, poeID string, poeFile string, readOnly bool) (*wallet.UploadResponse, error)
+	IssueCTokenFunc                    func(header http.Header, body *wallet.IssueBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	IssueAssetFunc                     func(header http.Header, body *wallet.IssueAssetBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	TransferCTokenFunc                 func(header http.Header, body *wallet.TransferCTokenBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	TransferAssetFunc                  func(header http.Header, body *wallet.TransferAssetBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error)
+	BatchTransferCTokenFunc            func(header http.Header, body *api.BatchTransferCTokenBody, signParams *pki.SignatureParam) (*api.BatchTransferCTokenResponse, error)
+	SendApprovalProposalFunc           func(header http.Header, body *wallet.TransferCTokenBody, policy *api.ApprovalPolicy) (*api.ApprovalProposal, error)
+	SignApprovedTxsFunc                func(header http.Header, proposal *api.ApprovalProposal, signParams *pki.SignatureParam) ([]*pw.TX, error)
+	PrepareSwapFunc                    func(header http.Header, body *api.SwapBody) (*api.Swap, error)
+	SignSwapFunc                       func(header http.Header, swap *api.Swap, body *api.SwapBody, signParams *pki.SignatureParam) error
+	SubmitSwapFunc                     func(header http.Header, swap *api.Swap) (*wallet.WalletResponse, error)
+	SubmitSwapSequentialFunc           func(header http.Header, swap *api.Swap) (*wallet.WalletResponse, error)
+	SendIssueCTokenProposalFunc        func(header http.Header, body *wallet.IssueBody) (*wallet.IssueCTokenPrepareResponse, error)
+	SendIssueAssetProposalFunc         func(header http.Header, body *wallet.IssueAssetBody) ([]*pw.TX, error)
+	SendTransferCTokenProposalFunc     func(header http.Header, body *wallet.TransferCTokenBody) ([]*pw.TX, error)
+	SendTransferAssetProposalFunc      func(header http.Header, body *wallet.TransferAssetBody) ([]*pw.TX, error)
+	EstimateFeeFunc                    func(header http.Header, body interface{}) (*api.FeeEstimate, error)
+	CheckIntentFunc                    func(header http.Header, body interface{}, txs []*pw.TX) error
+	PlanSignTxsFunc                    func(header http.Header, txs []*pw.TX, signParams *pki.SignatureParam) ([]*api.TxSigner, error)
+	SignTxsFunc                        func(txs []*pw.TX, signParams *pki.SignatureParam) error
+	SignTxFunc                         func(tx *pw.TX, signParams *pki.SignatureParam) error
+	ProcessTxFunc                      func(header http.Header, txs []*pw.TX) (*wallet.WalletResponse, error)
+	QueryTransactionLogsFunc           func(header http.Header, id did.Identifier, txType string, num, page int32) ([]*pw.UTXO, error)
+	QueryTransactionUTXOFunc           func(header http.Header, id did.Identifier, num, page int32) ([]*pw.UTXO, error)
+	QueryTransactionSTXOFunc           func(header http.Header, id did.Identifier, num, page int32) ([]*pw.UTXO, error)
+	IndexSetFunc                       func(header http.Header, body *wallet.IndexSetPayload) ([]string, error)
+	IndexGetFunc                       func(header http.Header, body *wallet.IndexGetPayload) ([]string, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ api.IWalletClient = (*WalletClient)(nil)
+
+// Calls returns the calls made so far, in order.
+//
+func (m *WalletClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]Call, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// CallCount returns the number of calls made to method.
+//
+func (m *WalletClient) CallCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, call := range m.calls {
+		if call.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *WalletClient) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+func notImplemented(method string) error {
+	return fmt.Errorf("mock: %s not implemented", method)
+}
+
+// Register records the call and calls RegisterFunc.
+//
+func (m *WalletClient) Register(header http.Header, body *wallet.RegisterWalletBody) (*wallet.WalletResponse, error) {
+	m.record("Register", header, body)
+	if m.RegisterFunc == nil {
+		return nil, notImplemented("Register")
+	}
+	return m.RegisterFunc(header, body)
+}
+
+// RegisterSubWallet records the call and calls RegisterSubWalletFunc.
+//
+func (m *WalletClient) RegisterSubWallet(header http.Header, body *wallet.RegisterSubWalletBody) (*wallet.WalletResponse, error) {
+	m.record("RegisterSubWallet", header, body)
+	if m.RegisterSubWalletFunc == nil {
+		return nil, notImplemented("RegisterSubWallet")
+	}
+	return m.RegisterSubWalletFunc(header, body)
+}
+
+// RegisterWithPublicKey records the call and calls RegisterWithPublicKeyFunc.
+//
+func (m *WalletClient) RegisterWithPublicKey(header http.Header, body *wallet.RegisterWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error) {
+	m.record("RegisterWithPublicKey", header, body, publicKey)
+	if m.RegisterWithPublicKeyFunc == nil {
+		return nil, notImplemented("RegisterWithPublicKey")
+	}
+	return m.RegisterWithPublicKeyFunc(header, body, publicKey)
+}
+
+// RegisterSubWalletWithPublicKey records the call and calls
+// RegisterSubWalletWithPublicKeyFunc.
+//
+func (m *WalletClient) RegisterSubWalletWithPublicKey(header http.Header, body *wallet.RegisterSubWalletBody, publicKey ed25519.PublicKey) (*wallet.WalletResponse, error) {
+	m.record("RegisterSubWalletWithPublicKey", header, body, publicKey)
+	if m.RegisterSubWalletWithPublicKeyFunc == nil {
+		return nil, notImplemented("RegisterSubWalletWithPublicKey")
+	}
+	return m.RegisterSubWalletWithPublicKeyFunc(header, body, publicKey)
+}
+
+// GetWalletBalance records the call and calls GetWalletBalanceFunc.
+//
+func (m *WalletClient) GetWalletBalance(header http.Header, id did.Identifier) (*wallet.WalletBalance, error) {
+	m.record("GetWalletBalance", header, id)
+	if m.GetWalletBalanceFunc == nil {
+		return nil, notImplemented("GetWalletBalance")
+	}
+	return m.GetWalletBalanceFunc(header, id)
+}
+
+// GetWalletInfo records the call and calls GetWalletInfoFunc.
+//
+func (m *WalletClient) GetWalletInfo(header http.Header, id did.Identifier) (*wallet.WalletInfo, error) {
+	m.record("GetWalletInfo", header, id)
+	if m.GetWalletInfoFunc == nil {
+		return nil, notImplemented("GetWalletInfo")
+	}
+	return m.GetWalletInfoFunc(header, id)
+}
+
+// TrusteeKeyPair records the call and calls TrusteeKeyPairFunc.
+//
+func (m *WalletClient) TrusteeKeyPair(header http.Header, id did.Identifier, keyPair *wallet.KeyPair) (*wallet.WalletResponse, error) {
+	m.record("TrusteeKeyPair", header, id, keyPair)
+	if m.TrusteeKeyPairFunc == nil {
+		return nil, notImplemented("TrusteeKeyPair")
+	}
+	return m.TrusteeKeyPairFunc(header, id, keyPair)
+}
+
+// RotateKey records the call and calls RotateKeyFunc.
+//
+func (m *WalletClient) RotateKey(header http.Header, body *api.RotateKeyBody, signParams *pki.SignatureParam) (*api.RotateKeyResponse, error) {
+	m.record("RotateKey", header, body, signParams)
+	if m.RotateKeyFunc == nil {
+		return nil, notImplemented("RotateKey")
+	}
+	return m.RotateKeyFunc(header, body, signParams)
+}
+
+// SweepWallet records the call and calls SweepWalletFunc.
+//
+func (m *WalletClient) SweepWallet(header http.Header, body *api.SweepBody, signParams *pki.SignatureParam) (*api.SweepResponse, error) {
+	m.record("SweepWallet", header, body, signParams)
+	if m.SweepWalletFunc == nil {
+		return nil, notImplemented("SweepWallet")
+	}
+	return m.SweepWalletFunc(header, body, signParams)
+}
+
+// CreatePOE records the call and calls CreatePOEFunc.
+//
+func (m *WalletClient) CreatePOE(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error) {
+	m.record("CreatePOE", header, body, signParams)
+	if m.CreatePOEFunc == nil {
+		return nil, notImplemented("CreatePOE")
+	}
+	return m.CreatePOEFunc(header, body, signParams)
+}
+
+// UpdatePOE records the call and calls UpdatePOEFunc.
+//
+func (m *WalletClient) UpdatePOE(header http.Header, body *wallet.POEBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error) {
+	m.record("UpdatePOE", header, body, signParams)
+	if m.UpdatePOEFunc == nil {
+		return nil, notImplemented("UpdatePOE")
+	}
+	return m.UpdatePOEFunc(header, body, signParams)
+}
+
+// QueryPOE records the call and calls QueryPOEFunc.
+//
+func (m *WalletClient) QueryPOE(header http.Header, id did.Identifier) (*wallet.POEPayload, error) {
+	m.record("QueryPOE", header, id)
+	if m.QueryPOEFunc == nil {
+		return nil, notImplemented("QueryPOE")
+	}
+	return m.QueryPOEFunc(header, id)
+}
+
+// UploadPOEFile records the call and calls UploadPOEFileFunc.
+//
+func (m *WalletClient) UploadPOEFile(header http.Header, poeID string, poeFile string, readOnly bool) (*wallet.UploadResponse, error) {
+	m.record("UploadPOEFile", header, poeID, poeFile, readOnly)
+	if m.UploadPOEFileFunc == nil {
+		return nil, notImplemented("UploadPOEFile")
+	}
+	return m.UploadPOEFileFunc(header, poeID, poeFile, readOnly)
+}
+
+// IssueCToken records the call and calls IssueCTokenFunc.
+//
+func (m *WalletClient) IssueCToken(header http.Header, body *wallet.IssueBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error) {
+	m.record("IssueCToken", header, body, signParams)
+	if m.IssueCTokenFunc == nil {
+		return nil, notImplemented("IssueCToken")
+	}
+	return m.IssueCTokenFunc(header, body, signParams)
+}
+
+// IssueAsset records the call and calls IssueAssetFunc.
+//
+func (m *WalletClient) IssueAsset(header http.Header, body *wallet.IssueAssetBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error) {
+	m.record("IssueAsset", header, body, signParams)
+	if m.IssueAssetFunc == nil {
+		return nil, notImplemented("IssueAsset")
+	}
+	return m.IssueAssetFunc(header, body, signParams)
+}
+
+// TransferCToken records the call and calls TransferCTokenFunc.
+//
+func (m *WalletClient) TransferCToken(header http.Header, body *wallet.TransferCTokenBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error) {
+	m.record("TransferCToken", header, body, signParams)
+	if m.TransferCTokenFunc == nil {
+		return nil, notImplemented("TransferCToken")
+	}
+	return m.TransferCTokenFunc(header, body, signParams)
+}
+
+// TransferAsset records the call and calls TransferAssetFunc.
+//
+func (m *WalletClient) TransferAsset(header http.Header, body *wallet.TransferAssetBody, signParams *pki.SignatureParam) (*wallet.WalletResponse, error) {
+	m.record("TransferAsset", header, body, signParams)
+	if m.TransferAssetFunc == nil {
+		return nil, notImplemented("TransferAsset")
+	}
+	return m.TransferAssetFunc(header, body, signParams)
+}
+
+// BatchTransferCToken records the call and calls BatchTransferCTokenFunc.
+//
+func (m *WalletClient) BatchTransferCToken(header http.Header, body *api.BatchTransferCTokenBody, signParams *pki.SignatureParam) (*api.BatchTransferCTokenResponse, error) {
+	m.record("BatchTransferCToken", header, body, signParams)
+	if m.BatchTransferCTokenFunc == nil {
+		return nil, notImplemented("BatchTransferCToken")
+	}
+	return m.BatchTransferCTokenFunc(header, body, signParams)
+}
+
+// SendApprovalProposal records the call and calls SendApprovalProposalFunc.
+//
+func (m *WalletClient) SendApprovalProposal(header http.Header, body *wallet.TransferCTokenBody, policy *api.ApprovalPolicy) (*api.ApprovalProposal, error) {
+	m.record("SendApprovalProposal", header, body, policy)
+	if m.SendApprovalProposalFunc == nil {
+		return nil, notImplemented("SendApprovalProposal")
+	}
+	return m.SendApprovalProposalFunc(header, body, policy)
+}
+
+// SignApprovedTxs records the call and calls SignApprovedTxsFunc.
+//
+func (m *WalletClient) SignApprovedTxs(header http.Header, proposal *api.ApprovalProposal, signParams *pki.SignatureParam) ([]*pw.TX, error) {
+	m.record("SignApprovedTxs", header, proposal, signParams)
+	if m.SignApprovedTxsFunc == nil {
+		return nil, notImplemented("SignApprovedTxs")
+	}
+	return m.SignApprovedTxsFunc(header, proposal, signParams)
+}
+
+// PrepareSwap records the call and calls PrepareSwapFunc.
+//
+func (m *WalletClient) PrepareSwap(header http.Header, body *api.SwapBody) (*api.Swap, error) {
+	m.record("PrepareSwap", header, body)
+	if m.PrepareSwapFunc == nil {
+		return nil, notImplemented("PrepareSwap")
+	}
+	return m.PrepareSwapFunc(header, body)
+}
+
+// SignSwap records the call and calls SignSwapFunc.
+//
+func (m *WalletClient) SignSwap(header http.Header, swap *api.Swap, body *api.SwapBody, signParams *pki.SignatureParam) error {
+	m.record("SignSwap", header, swap, body, signParams)
+	if m.SignSwapFunc == nil {
+		return notImplemented("SignSwap")
+	}
+	return m.SignSwapFunc(header, swap, body, signParams)
+}
+
+// SubmitSwap records the call and calls SubmitSwapFunc.
+//
+func (m *WalletClient) SubmitSwap(header http.Header, swap *api.Swap) (*wallet.WalletResponse, error) {
+	m.record("SubmitSwap", header, swap)
+	if m.SubmitSwapFunc == nil {
+		return nil, notImplemented("SubmitSwap")
+	}
+	return m.SubmitSwapFunc(header, swap)
+}
+
+// SubmitSwapSequential records the call and calls SubmitSwapSequentialFunc.
+//
+func (m *WalletClient) SubmitSwapSequential(header http.Header, swap *api.Swap) (*wallet.WalletResponse, error) {
+	m.record("SubmitSwapSequential", header, swap)
+	if m.SubmitSwapSequentialFunc == nil {
+		return nil, notImplemented("SubmitSwapSequential")
+	}
+	return m.SubmitSwapSequentialFunc(header, swap)
+}
+
+// SendIssueCTokenProposal records the call and calls SendIssueCTokenProposalFunc.
+//
+func (m *WalletClient) SendIssueCTokenProposal(header http.Header, body *wallet.IssueBody) (*wallet.IssueCTokenPrepareResponse, error) {
+	m.record("SendIssueCTokenProposal", header, body)
+	if m.SendIssueCTokenProposalFunc == nil {
+		return nil, notImplemented("SendIssueCTokenProposal")
+	}
+	return m.SendIssueCTokenProposalFunc(header, body)
+}
+
+// SendIssueAssetProposal records the call and calls SendIssueAssetProposalFunc.
+//
+func (m *WalletClient) SendIssueAssetProposal(header http.Header, body *wallet.IssueAssetBody) ([]*pw.TX, error) {
+	m.record("SendIssueAssetProposal", header, body)
+	if m.SendIssueAssetProposalFunc == nil {
+		return nil, notImplemented("SendIssueAssetProposal")
+	}
+	return m.SendIssueAssetProposalFunc(header, body)
+}
+
+// SendTransferCTokenProposal records the call and calls SendTransferCTokenProposalFunc.
+//
+func (m *WalletClient) SendTransferCTokenProposal(header http.Header, body *wallet.TransferCTokenBody) ([]*pw.TX, error) {
+	m.record("SendTransferCTokenProposal", header, body)
+	if m.SendTransferCTokenProposalFunc == nil {
+		return nil, notImplemented("SendTransferCTokenProposal")
+	}
+	return m.SendTransferCTokenProposalFunc(header, body)
+}
+
+// SendTransferAssetProposal records the call and calls SendTransferAssetProposalFunc.
+//
+func (m *WalletClient) SendTransferAssetProposal(header http.Header, body *wallet.TransferAssetBody) ([]*pw.TX, error) {
+	m.record("SendTransferAssetProposal", header, body)
+	if m.SendTransferAssetProposalFunc == nil {
+		return nil, notImplemented("SendTransferAssetProposal")
+	}
+	return m.SendTransferAssetProposalFunc(header, body)
+}
+
+// EstimateFee records the call and calls EstimateFeeFunc.
+//
+func (m *WalletClient) EstimateFee(header http.Header, body interface{}) (*api.FeeEstimate, error) {
+	m.record("EstimateFee", header, body)
+	if m.EstimateFeeFunc == nil {
+		return nil, notImplemented("EstimateFee")
+	}
+	return m.EstimateFeeFunc(header, body)
+}
+
+// CheckIntent records the call and calls CheckIntentFunc.
+//
+func (m *WalletClient) CheckIntent(header http.Header, body interface{}, txs []*pw.TX) error {
+	m.record("CheckIntent", header, body, txs)
+	if m.CheckIntentFunc == nil {
+		return notImplemented("CheckIntent")
+	}
+	return m.CheckIntentFunc(header, body, txs)
+}
+
+// PlanSignTxs records the call and calls PlanSignTxsFunc.
+//
+func (m *WalletClient) PlanSignTxs(header http.Header, txs []*pw.TX, signParams *pki.SignatureParam) ([]*api.TxSigner, error) {
+	m.record("PlanSignTxs", header, txs, signParams)
+	if m.PlanSignTxsFunc == nil {
+		return nil, notImplemented("PlanSignTxs")
+	}
+	return m.PlanSignTxsFunc(header, txs, signParams)
+}
+
+// SignTxs records the call and calls SignTxsFunc.
+//
+func (m *WalletClient) SignTxs(txs []*pw.TX, signParams *pki.SignatureParam) error {
+	m.record("SignTxs", txs, signParams)
+	if m.SignTxsFunc == nil {
+		return notImplemented("SignTxs")
+	}
+	return m.SignTxsFunc(txs, signParams)
+}
+
+// SignTx records the call and calls SignTxFunc.
+//
+func (m *WalletClient) SignTx(tx *pw.TX, signParams *pki.SignatureParam) error {
+	m.record("SignTx", tx, signParams)
+	if m.SignTxFunc == nil {
+		return notImplemented("SignTx")
+	}
+	return m.SignTxFunc(tx, signParams)
+}
+
+// ProcessTx records the call and calls ProcessTxFunc.
+//
+func (m *WalletClient) ProcessTx(header http.Header, txs []*pw.TX) (*wallet.WalletResponse, error) {
+	m.record("ProcessTx", header, txs)
+	if m.ProcessTxFunc == nil {
+		return nil, notImplemented("ProcessTx")
+	}
+	return m.ProcessTxFunc(header, txs)
+}
+
+// QueryTransactionLogs records the call and calls QueryTransactionLogsFunc.
+//
+func (m *WalletClient) QueryTransactionLogs(header http.Header, id did.Identifier, txType string, num, page int32) ([]*pw.UTXO, error) {
+	m.record("QueryTransactionLogs", header, id, txType, num, page)
+	if m.QueryTransactionLogsFunc == nil {
+		return nil, notImplemented("QueryTransactionLogs")
+	}
+	return m.QueryTransactionLogsFunc(header, id, txType, num, page)
+}
+
+// QueryTransactionUTXO records the call and calls QueryTransactionUTXOFunc.
+//
+func (m *WalletClient) QueryTransactionUTXO(header http.Header, id did.Identifier, num, page int32) ([]*pw.UTXO, error) {
+	m.record("QueryTransactionUTXO", header, id, num, page)
+	if m.QueryTransactionUTXOFunc == nil {
+		return nil, notImplemented("QueryTransactionUTXO")
+	}
+	return m.QueryTransactionUTXOFunc(header, id, num, page)
+}
+
+// QueryTransactionSTXO records the call and calls QueryTransactionSTXOFunc.
+//
+func (m *WalletClient) QueryTransactionSTXO(header http.Header, id did.Identifier, num, page int32) ([]*pw.UTXO, error) {
+	m.record("QueryTransactionSTXO", header, id, num, page)
+	if m.QueryTransactionSTXOFunc == nil {
+		return nil, notImplemented("QueryTransactionSTXO")
+	}
+	return m.QueryTransactionSTXOFunc(header, id, num, page)
+}
+
+// IndexSet records the call and calls IndexSetFunc.
+//
+func (m *WalletClient) IndexSet(header http.Header, body *wallet.IndexSetPayload) ([]string, error) {
+	m.record("IndexSet", header, body)
+	if m.IndexSetFunc == nil {
+		return nil, notImplemented("IndexSet")
+	}
+	return m.IndexSetFunc(header, body)
+}
+
+// IndexGet records the call and calls IndexGetFunc.
+//
+func (m *WalletClient) IndexGet(header http.Header, body *wallet.IndexGetPayload) ([]string, error) {
+	m.record("IndexGet", header, body)
+	if m.IndexGetFunc == nil {
+		return nil, notImplemented("IndexGet")
+	}
+	return m.IndexGetFunc(header, body)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mock
+
+import (
+	"net/http"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/api"
+)
+
+// transfer is sample code depending on role interfaces only.
+func transfer(t api.TokenTransferer, body *wallet.TransferCTokenBody, signParams *pki.SignatureParam) ([]string, error) {
+	txs, err := t.SendTransferCTokenProposal(nil, body)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.SignTxs(txs, signParams); err != nil {
+		return nil, err
+	}
+	resp, err := t.ProcessTx(nil, txs)
+	if err != nil {
+		return nil, err
+	}
+	return resp.TransactionIds, nil
+}
+
+func TestWalletClientFuncs(t *testing.T) {
+	txs := []*pw.TX{{Founder: "did:axn:from"}}
+	m := &WalletClient{
+		SendTransferCTokenProposalFunc: func(header http.Header, body *wallet.TransferCTokenBody) ([]*pw.TX, error) {
+			return txs, nil
+		},
+		SignTxsFunc: func(signed []*pw.TX, signParams *pki.SignatureParam) error {
+			if len(signed) != 1 || signed[0] != txs[0] {
+				t.Fatalf("SignTxs should be called with the proposal txs")
+			}
+			return nil
+		},
+		ProcessTxFunc: func(header http.Header, processed []*pw.TX) (*wallet.WalletResponse, error) {
+			return &wallet.WalletResponse{TransactionIds: []string{"tx-1"}}, nil
+		},
+	}
+
+	ids, err := transfer(m, &wallet.TransferCTokenBody{From: "did:axn:from"}, &pki.SignatureParam{})
+	if err != nil {
+		t.Fatalf("transfer fail: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "tx-1" {
+		t.Fatalf("transaction ids should be [tx-1] not %v", ids)
+	}
+
+	calls := m.Calls()
+	expected := []string{"SendTransferCTokenProposal", "SignTxs", "ProcessTx"}
+	if len(calls) != len(expected) {
+		t.Fatalf("%d calls should be recorded not %d", len(expected), len(calls))
+	}
+	for i, method := range expected {
+		if calls[i].Method != method {
+			t.Fatalf("call %d should be %s not %s", i, method, calls[i].Method)
+		}
+	}
+	if body := calls[0].Args[1].(*wallet.TransferCTokenBody); body.From != "did:axn:from" {
+		t.Fatalf("recorded body should be the one passed to the call")
+	}
+}
+
+func TestWalletClientNotImplemented(t *testing.T) {
+	var client api.IWalletClient = &WalletClient{}
+
+	result, err := client.Register(nil, &wallet.RegisterWalletBody{})
+	if err == nil {
+		t.Fatalf("unset Register should return an error")
+	}
+	if result != nil {
+		t.Fatalf("unset Register should return a nil result")
+	}
+	if err = client.SignTx(&pw.TX{}, nil); err == nil {
+		t.Fatalf("unset SignTx should return an error")
+	}
+	if n := client.(*WalletClient).CallCount("Register"); n != 1 {
+		t.Fatalf("Register should be called once not %d", n)
+	}
+}
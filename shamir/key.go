@@ -0,0 +1,80 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shamir
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/utils"
+)
+
+// SplitSignParam splits the ed25519 private key of signParams, base64
+// encoded as in pki.SignatureParam, into n shares any k of which
+// reconstruct it.
+//
+func SplitSignParam(signParams *pki.SignatureParam, n, k int) ([]*Share, error) {
+	if signParams == nil || signParams.PrivateKey == "" {
+		return nil, fmt.Errorf("request signature params invalid")
+	}
+	privateKey, err := decodePrivateKey(signParams.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return Split(privateKey, n, k)
+}
+
+// CombineSignParam reconstructs the private key of shares into the
+// signature params of creator. It fails unless at least the threshold
+// of shares is supplied and they reconstruct a valid ed25519 private
+// key.
+//
+func CombineSignParam(shares []*Share, creator did.Identifier, nonce string) (*pki.SignatureParam, error) {
+	secret, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	encoded := utils.EncodeBase64(secret)
+	if _, err = decodePrivateKey(encoded); err != nil {
+		return nil, err
+	}
+	return &pki.SignatureParam{
+		Creator:    creator,
+		Nonce:      nonce,
+		PrivateKey: encoded,
+	}, nil
+}
+
+// decodePrivateKey decodes a base64 encoded ed25519 private key, whose
+// public half must match its seed.
+func decodePrivateKey(encoded string) ([]byte, error) {
+	decoded, err := utils.DecodeBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("private key invalid: %v", err)
+	}
+	privateKey := []byte(decoded)
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("private key invalid: length %d", len(privateKey))
+	}
+	if !bytes.Equal(ed25519.NewKeyFromSeed(privateKey[:ed25519.SeedSize]), privateKey) {
+		return nil, fmt.Errorf("private key invalid: public key does not match")
+	}
+	return privateKey, nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package shamir splits wallet private keys into shares with Shamir's
+// secret sharing, so that any threshold of them reconstructs the key
+// and fewer reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// MaxShares is the maximum number of shares of a secret.
+//
+const MaxShares = 255
+
+// Share is one share of a secret.
+//
+type Share struct {
+	// Group identifies the shares of the same split, shares of
+	// different splits never combine.
+	Group string
+	// Threshold is the number of shares reconstructing the secret.
+	Threshold int
+	// Index is the x coordinate of the share, from 1 to MaxShares.
+	Index int
+	// Value holds one byte per byte of the secret.
+	Value []byte
+}
+
+// Split splits secret into n shares, any k of which reconstruct it.
+//
+func Split(secret []byte, n, k int) ([]*Share, error) {
+	return SplitFrom(rand.Reader, secret, n, k)
+}
+
+// SplitFrom splits secret like Split, reading the random polynomial
+// coefficients and the group from r.
+//
+func SplitFrom(r io.Reader, secret []byte, n, k int) ([]*Share, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret must not be empty")
+	}
+	if k < 2 || k > n || n > MaxShares {
+		return nil, fmt.Errorf("shares %d of threshold %d invalid: need 2 <= threshold <= shares <= %d", n, k, MaxShares)
+	}
+
+	group := make([]byte, 4)
+	if _, err := io.ReadFull(r, group); err != nil {
+		return nil, err
+	}
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{
+			Group:     hex.EncodeToString(group),
+			Threshold: k,
+			Index:     i + 1,
+			Value:     make([]byte, len(secret)),
+		}
+	}
+
+	// One polynomial of degree k-1 per byte, whose constant term is
+	// the secret byte
+	coefficients := make([]byte, k)
+	for b, s := range secret {
+		coefficients[0] = s
+		if _, err := io.ReadFull(r, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			share.Value[b] = evaluate(coefficients, byte(share.Index))
+		}
+	}
+	for i := range coefficients {
+		coefficients[i] = 0
+	}
+	return shares, nil
+}
+
+// Combine reconstructs the secret of shares. It needs at least the
+// threshold of distinct shares of the same group, extra shares are
+// ignored. Shares of a threshold out of the range of SplitFrom are
+// rejected.
+//
+func Combine(shares []*Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares")
+	}
+	first := shares[0]
+	if err := validThreshold(first.Threshold); err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool)
+	var used []*Share
+	for _, share := range shares {
+		if share.Group != first.Group || share.Threshold != first.Threshold || len(share.Value) != len(first.Value) {
+			return nil, fmt.Errorf("share %d is not of group %s", share.Index, first.Group)
+		}
+		if share.Index < 1 || share.Index > MaxShares {
+			return nil, fmt.Errorf("share index %d invalid", share.Index)
+		}
+		if seen[share.Index] {
+			continue
+		}
+		seen[share.Index] = true
+		if len(used) < first.Threshold {
+			used = append(used, share)
+		}
+	}
+	if len(used) < first.Threshold {
+		return nil, fmt.Errorf("%d distinct shares needed, got %d", first.Threshold, len(used))
+	}
+
+	// Lagrange interpolation at x = 0
+	secret := make([]byte, len(first.Value))
+	for i, share := range used {
+		xi := byte(share.Index)
+		basis := byte(1)
+		for j, other := range used {
+			if i == j {
+				continue
+			}
+			xj := byte(other.Index)
+			basis = mul(basis, div(xj, xj^xi))
+		}
+		for b := range secret {
+			secret[b] ^= mul(share.Value[b], basis)
+		}
+	}
+	return secret, nil
+}
+
+// validThreshold checks a share threshold, as SplitFrom does.
+func validThreshold(k int) error {
+	if k < 2 || k > MaxShares {
+		return fmt.Errorf("share threshold %d invalid: need 2 <= threshold <= %d", k, MaxShares)
+	}
+	return nil
+}
+
+// evaluate evaluates the polynomial of coefficients at x, in GF(2^8).
+func evaluate(coefficients []byte, x byte) byte {
+	y := byte(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// mul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x + 1, without
+// branching on the operands.
+func mul(a, b byte) byte {
+	p := byte(0)
+	for i := 0; i < 8; i++ {
+		p ^= a & -(b & 1)
+		carry := a >> 7
+		a = a<<1 ^ 0x1b&-carry
+		b >>= 1
+	}
+	return p
+}
+
+// div divides in GF(2^8), b must not be 0.
+func div(a, b byte) byte {
+	// b^254 is the inverse of b
+	inv := byte(1)
+	for i := 0; i < 254; i++ {
+		inv = mul(inv, b)
+	}
+	return mul(a, inv)
+}
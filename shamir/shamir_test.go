@@ -0,0 +1,199 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shamir
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/wallet-sdk-go/api"
+)
+
+func TestGF256(t *testing.T) {
+	// 0x53 and 0xca are inverses modulo the AES polynomial
+	if p := mul(0x53, 0xca); p != 1 {
+		t.Fatalf("0x53 * 0xca should be 1 not %#x", p)
+	}
+	for a := 1; a < 256; a++ {
+		if q := div(byte(a), byte(a)); q != 1 {
+			t.Fatalf("%#x / %#x should be 1 not %#x", a, a, q)
+		}
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("enterprise issuing wallet private key")
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("split fail: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("5 shares should be returned not %d", len(shares))
+	}
+
+	subsets := [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}, {3, 3, 1, 0}}
+	for _, subset := range subsets {
+		var picked []*Share
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		combined, err := Combine(picked)
+		if err != nil {
+			t.Fatalf("combine %v fail: %v", subset, err)
+		}
+		if !bytes.Equal(combined, secret) {
+			t.Fatalf("combine %v should reconstruct the secret", subset)
+		}
+	}
+
+	if _, err = Combine(shares[:2]); err == nil {
+		t.Fatalf("combine below the threshold should fail")
+	}
+	if _, err = Combine([]*Share{shares[0], shares[0], shares[1]}); err == nil {
+		t.Fatalf("duplicated shares should not count")
+	}
+	others, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("split fail: %v", err)
+	}
+	if _, err = Combine([]*Share{shares[0], shares[1], others[2]}); err == nil {
+		t.Fatalf("shares of different groups should not combine")
+	}
+
+	for _, c := range []struct{ n, k int }{{5, 1}, {3, 4}, {256, 3}} {
+		if _, err = Split(secret, c.n, c.k); err == nil {
+			t.Fatalf("split into %d shares of threshold %d should fail", c.n, c.k)
+		}
+	}
+	if _, err = Split(nil, 3, 2); err == nil {
+		t.Fatalf("split of an empty secret should fail")
+	}
+}
+
+func TestShareText(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatalf("split fail: %v", err)
+	}
+	text := shares[1].String()
+	if !strings.HasPrefix(text, "AXNSHARE1:") {
+		t.Fatalf("text should start with the prefix: %s", text)
+	}
+	for _, r := range text {
+		if !strings.ContainsRune("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ:", r) {
+			t.Fatalf("text should only use QR alphanumeric characters: %s", text)
+		}
+	}
+
+	// Case and white space are ignored
+	parsed, err := ParseShare(" " + strings.ToLower(text[:20]) + "\n" + text[20:] + " ")
+	if err != nil {
+		t.Fatalf("parse share fail: %v", err)
+	}
+	if parsed.Group != shares[1].Group || parsed.Threshold != 2 || parsed.Index != 2 || !bytes.Equal(parsed.Value, shares[1].Value) {
+		t.Fatalf("parsed share invalid: %+v", parsed)
+	}
+
+	// A typo is detected
+	typo := []byte(text)
+	i := strings.LastIndex(text, ":") - 1
+	if typo[i] == 'A' {
+		typo[i] = 'B'
+	} else {
+		typo[i] = 'A'
+	}
+	if _, err = ParseShare(string(typo)); err == nil {
+		t.Fatalf("share with a typo should fail")
+	}
+	if _, err = ParseShare("not a share"); err == nil {
+		t.Fatalf("invalid share should fail")
+	}
+
+	// Thresholds and indexes out of range are rejected, even with a
+	// valid checksum
+	for _, forged := range []*Share{
+		{Group: shares[1].Group, Threshold: 0, Index: 2, Value: shares[1].Value},
+		{Group: shares[1].Group, Threshold: 1, Index: 2, Value: shares[1].Value},
+		{Group: shares[1].Group, Threshold: MaxShares + 1, Index: 2, Value: shares[1].Value},
+		{Group: shares[1].Group, Threshold: 2, Index: 0, Value: shares[1].Value},
+	} {
+		if _, err = ParseShare(forged.String()); err == nil {
+			t.Fatalf("share of threshold %d and index %d should fail", forged.Threshold, forged.Index)
+		}
+	}
+}
+
+func TestCombineThresholdInvalid(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatalf("split fail: %v", err)
+	}
+	for _, k := range []int{-1, 0, 1, MaxShares + 1} {
+		forged := *shares[0]
+		forged.Threshold = k
+		if secret, err := Combine([]*Share{&forged}); err == nil {
+			t.Fatalf("combine shares of threshold %d should fail, got %x", k, secret)
+		}
+	}
+}
+
+func TestSignParam(t *testing.T) {
+	key, err := api.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	signParams := key.SignParam("did:axn:enterprise", "nonce")
+
+	shares, err := SplitSignParam(signParams, 3, 2)
+	if err != nil {
+		t.Fatalf("split sign param fail: %v", err)
+	}
+	var texts []string
+	for _, share := range shares {
+		texts = append(texts, share.String())
+	}
+
+	var parsed []*Share
+	for _, text := range texts[1:] {
+		share, err := ParseShare(text)
+		if err != nil {
+			t.Fatalf("parse share fail: %v", err)
+		}
+		parsed = append(parsed, share)
+	}
+	combined, err := CombineSignParam(parsed, "did:axn:enterprise", "nonce-2")
+	if err != nil {
+		t.Fatalf("combine sign param fail: %v", err)
+	}
+	if combined.PrivateKey != signParams.PrivateKey || combined.Creator != "did:axn:enterprise" || combined.Nonce != "nonce-2" {
+		t.Fatalf("combined sign param invalid: %+v", combined)
+	}
+
+	if _, err = CombineSignParam(parsed[:1], "did:axn:enterprise", "nonce"); err == nil {
+		t.Fatalf("combine sign param below the threshold should fail")
+	}
+	// A corrupted share reconstructs an inconsistent key
+	parsed[0].Value[40] ^= 1
+	if _, err = CombineSignParam(parsed, "did:axn:enterprise", "nonce"); err == nil {
+		t.Fatalf("combine corrupted shares should fail")
+	}
+	if _, err = SplitSignParam(&pki.SignatureParam{Creator: "did:axn:enterprise"}, 3, 2); err == nil {
+		t.Fatalf("split without private key should fail")
+	}
+}
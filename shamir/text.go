@@ -0,0 +1,98 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shamir
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// textPrefix starts the text encoding of a share.
+const textPrefix = "AXNSHARE1"
+
+// encoding only uses characters of the QR code alphanumeric mode.
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// String returns the text encoding of the share, such as
+//
+//	AXNSHARE1:1A2B3C4D:3:1:<BASE32 VALUE>:<CHECKSUM>
+//
+// with the group, threshold, index, value and a checksum of the
+// preceding fields. It only uses upper case letters, digits and
+// colons, so that it can be printed or encoded in a QR code in
+// alphanumeric mode.
+//
+func (s *Share) String() string {
+	text := fmt.Sprintf("%s:%s:%d:%d:%s", textPrefix, strings.ToUpper(s.Group), s.Threshold, s.Index, encoding.EncodeToString(s.Value))
+	return text + ":" + checksum(text)
+}
+
+// ParseShare parses the text encoding of a share, see Share.String. It
+// fails if the checksum does not match, such as after a typo, or if the
+// threshold or index is out of range.
+//
+func ParseShare(text string) (*Share, error) {
+	text = strings.ToUpper(strings.Join(strings.Fields(text), ""))
+	i := strings.LastIndex(text, ":")
+	if i < 0 || checksum(text[:i]) != text[i+1:] {
+		return nil, fmt.Errorf("share checksum invalid")
+	}
+
+	fields := strings.Split(text[:i], ":")
+	if len(fields) != 5 || fields[0] != textPrefix {
+		return nil, fmt.Errorf("share format invalid")
+	}
+	group, err := hex.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("share group invalid: %v", err)
+	}
+	threshold, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("share threshold invalid: %v", err)
+	}
+	if err = validThreshold(threshold); err != nil {
+		return nil, err
+	}
+	index, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("share index invalid: %v", err)
+	}
+	if index < 1 || index > MaxShares {
+		return nil, fmt.Errorf("share index %d invalid", index)
+	}
+	value, err := encoding.DecodeString(fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("share value invalid: %v", err)
+	}
+	return &Share{
+		Group:     hex.EncodeToString(group),
+		Threshold: threshold,
+		Index:     index,
+		Value:     value,
+	}, nil
+}
+
+// checksum returns the first 4 bytes of the sha256 hash of text, upper
+// case hex encoded.
+func checksum(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return strings.ToUpper(hex.EncodeToString(sum[:4]))
+}
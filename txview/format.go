@@ -0,0 +1,185 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package txview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format is an output format of the summaries.
+//
+type Format int
+
+// Formats of the summaries.
+const (
+	// Text renders one line per TX, input and output.
+	Text Format = iota
+	// JSON renders indented JSON.
+	JSON
+	// Table renders aligned columns, one row per input and output.
+	Table
+)
+
+// Write renders the TX set to w in format f.
+//
+func (s *TxSet) Write(w io.Writer, f Format) error {
+	switch f {
+	case Text:
+		return s.writeText(w)
+	case JSON:
+		return writeJSON(w, s)
+	case Table:
+		return s.writeTable(w)
+	}
+	return fmt.Errorf("format invalid: %d", f)
+}
+
+// String renders the TX set as text.
+//
+func (s *TxSet) String() string {
+	var buf bytes.Buffer
+	s.writeText(&buf)
+	return buf.String()
+}
+
+func (s *TxSet) writeText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	for _, tx := range s.Txs {
+		ew.printf("tx %d founder %s (%d in, %d out)\n", tx.Ix, tx.Founder, len(tx.Inputs), len(tx.Outputs))
+		for i, in := range tx.Inputs {
+			ew.printf("  in  %d %s:%d\n", i, in.SourceTxDataHash, in.Ix)
+		}
+		for _, out := range tx.Outputs {
+			ew.printf("  out %d %d %s -> %s %s\n", out.Ix, out.Value, out.TokenId, out.Addr, out.Script.text())
+		}
+		ew.printf("  amounts %s\n", tx.Amounts)
+	}
+	ew.printf("total %s\n", s.Amounts)
+	return ew.err
+}
+
+func (s *TxSet) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	ew := &errWriter{w: tw}
+	ew.printf("TX\tFOUNDER\tDIR\tIX\tTOKEN\tVALUE\tADDR/SOURCE\tSCRIPT\n")
+	for _, tx := range s.Txs {
+		for i, in := range tx.Inputs {
+			ew.printf("%d\t%s\tin\t%d\t\t\t%s:%d\t\n", tx.Ix, tx.Founder, i, in.SourceTxDataHash, in.Ix)
+		}
+		for _, out := range tx.Outputs {
+			ew.printf("%d\t%s\tout\t%d\t%s\t%d\t%s\t%s\n", tx.Ix, tx.Founder, out.Ix, out.TokenId, out.Value, out.Addr, out.Script.text())
+		}
+	}
+	if ew.err != nil {
+		return ew.err
+	}
+	return tw.Flush()
+}
+
+// Write renders the UTXO list to w in format f.
+//
+func (l *UTXOList) Write(w io.Writer, f Format) error {
+	switch f {
+	case Text:
+		return l.writeText(w)
+	case JSON:
+		return writeJSON(w, l)
+	case Table:
+		return l.writeTable(w)
+	}
+	return fmt.Errorf("format invalid: %d", f)
+}
+
+// String renders the UTXO list as text.
+//
+func (l *UTXOList) String() string {
+	var buf bytes.Buffer
+	l.writeText(&buf)
+	return buf.String()
+}
+
+func (l *UTXOList) writeText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	for _, utxo := range l.UTXOs {
+		ew.printf("utxo %s:%s %d %s -> %s founder %s %s\n", utxo.SourceTxDataHash, utxo.Ix, utxo.Value, utxo.TokenId, utxo.Addr, utxo.Founder, utxo.Script.text())
+	}
+	ew.printf("total %s\n", l.Amounts)
+	return ew.err
+}
+
+func (l *UTXOList) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	ew := &errWriter{w: tw}
+	ew.printf("SOURCE\tIX\tTOKEN\tVALUE\tADDR\tFOUNDER\tSCRIPT\n")
+	for _, utxo := range l.UTXOs {
+		ew.printf("%s\t%s\t%s\t%d\t%s\t%s\t%s\n", utxo.SourceTxDataHash, utxo.Ix, utxo.TokenId, utxo.Value, utxo.Addr, utxo.Founder, utxo.Script.text())
+	}
+	if ew.err != nil {
+		return ew.err
+	}
+	return tw.Flush()
+}
+
+// String renders the amounts as "token=value" pairs in token order.
+//
+func (a Amounts) String() string {
+	pairs := make([]string, 0, len(a))
+	for _, token := range a.Tokens() {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", token, a[token]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// text renders the script on one line.
+func (s *Script) text() string {
+	switch {
+	case s == nil:
+		return "no script"
+	case s.Err != "":
+		return "invalid script " + base64.StdEncoding.EncodeToString(s.Raw)
+	case s.Signed:
+		return fmt.Sprintf("signed by %s nonce %s signature %s", s.Creator, s.Nonce, base64.StdEncoding.EncodeToString(s.Signature))
+	}
+	return "unsigned public key " + base64.StdEncoding.EncodeToString(s.PublicKey)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// errWriter keeps the first write error.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package txview decodes wallet TXs and UTXOs into human-readable
+// summaries, rendered as text, JSON or tables.
+package txview
+
+import (
+	"encoding/json"
+	"sort"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+)
+
+// Script is a decoded output script.
+//
+type Script struct {
+	Creator   string `json:"creator,omitempty"`
+	Created   int64  `json:"created,omitempty"`
+	Nonce     string `json:"nonce,omitempty"`
+	PublicKey []byte `json:"public_key,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+	// Signed reports whether the script carries a signature.
+	Signed bool `json:"signed"`
+
+	// Raw and Err are set when the script is not a JSON encoded
+	// pw.UTXOSignature.
+	Raw []byte `json:"raw,omitempty"`
+	Err string `json:"error,omitempty"`
+}
+
+// DecodeScript decodes an output script, it returns nil for an empty
+// script.
+//
+func DecodeScript(script []byte) *Script {
+	if len(script) == 0 {
+		return nil
+	}
+	var sig pw.UTXOSignature
+	if err := json.Unmarshal(script, &sig); err != nil {
+		return &Script{Raw: script, Err: err.Error()}
+	}
+	return &Script{
+		Creator:   sig.Creator,
+		Created:   sig.Created,
+		Nonce:     sig.Nonce,
+		PublicKey: sig.PublicKey,
+		Signature: sig.Signature,
+		Signed:    len(sig.Signature) != 0,
+	}
+}
+
+// Input is a decoded TX input.
+//
+type Input struct {
+	SourceTxDataHash string `json:"source_tx_data_hash"`
+	Ix               int32  `json:"ix"`
+}
+
+// Output is a decoded TX output.
+//
+type Output struct {
+	Ix      int     `json:"ix"`
+	TokenId string  `json:"token_id"`
+	CType   int32   `json:"ctype"`
+	Value   int64   `json:"value"`
+	Addr    string  `json:"addr"`
+	Until   int64   `json:"until,omitempty"`
+	Script  *Script `json:"script,omitempty"`
+}
+
+// Tx is a decoded TX.
+//
+type Tx struct {
+	Ix      int       `json:"ix"`
+	Founder string    `json:"founder"`
+	Ver     int32     `json:"ver,omitempty"`
+	Type    int32     `json:"type,omitempty"`
+	Inputs  []*Input  `json:"inputs"`
+	Outputs []*Output `json:"outputs"`
+	// Amounts is the value of the outputs by token id.
+	Amounts Amounts `json:"amounts"`
+}
+
+// TxSet is a decoded set of TXs, such as the TXs of a proposal.
+//
+type TxSet struct {
+	Txs []*Tx `json:"txs"`
+	// Amounts is the value of the outputs of all TXs by token id.
+	Amounts Amounts `json:"amounts"`
+}
+
+// DecodeTxs decodes txs.
+//
+func DecodeTxs(txs []*pw.TX) *TxSet {
+	set := &TxSet{Txs: []*Tx{}, Amounts: Amounts{}}
+	for i, tx := range txs {
+		if tx == nil {
+			continue
+		}
+		view := &Tx{
+			Ix:      i,
+			Founder: tx.Founder,
+			Ver:     tx.Ver,
+			Type:    int32(tx.Txtype),
+			Inputs:  []*Input{},
+			Outputs: []*Output{},
+			Amounts: Amounts{},
+		}
+		for _, txin := range tx.Txin {
+			view.Inputs = append(view.Inputs, &Input{
+				SourceTxDataHash: txin.SourceTxDataHash,
+				Ix:               txin.Ix,
+			})
+		}
+		for j, txout := range tx.Txout {
+			view.Outputs = append(view.Outputs, &Output{
+				Ix:      j,
+				TokenId: txout.CTokenId,
+				CType:   txout.CType,
+				Value:   txout.Value,
+				Addr:    txout.Addr,
+				Until:   txout.Until,
+				Script:  DecodeScript(txout.Script),
+			})
+			view.Amounts[txout.CTokenId] += txout.Value
+			set.Amounts[txout.CTokenId] += txout.Value
+		}
+		set.Txs = append(set.Txs, view)
+	}
+	return set
+}
+
+// UTXO is a decoded UTXO.
+//
+type UTXO struct {
+	SourceTxDataHash string  `json:"source_tx_data_hash"`
+	Ix               string  `json:"ix"`
+	TokenId          string  `json:"token_id"`
+	CType            int32   `json:"ctype"`
+	Value            int64   `json:"value"`
+	Addr             string  `json:"addr"`
+	Until            int64   `json:"until,omitempty"`
+	Founder          string  `json:"founder"`
+	TxType           int32   `json:"tx_type,omitempty"`
+	Script           *Script `json:"script,omitempty"`
+}
+
+// UTXOList is a decoded list of UTXOs, such as a page of transaction
+// logs.
+//
+type UTXOList struct {
+	UTXOs []*UTXO `json:"utxos"`
+	// Amounts is the value of the UTXOs by token id.
+	Amounts Amounts `json:"amounts"`
+}
+
+// DecodeUTXOs decodes utxos.
+//
+func DecodeUTXOs(utxos []*pw.UTXO) *UTXOList {
+	list := &UTXOList{UTXOs: []*UTXO{}, Amounts: Amounts{}}
+	for _, utxo := range utxos {
+		if utxo == nil {
+			continue
+		}
+		list.UTXOs = append(list.UTXOs, &UTXO{
+			SourceTxDataHash: utxo.SourceTxDataHash,
+			Ix:               utxo.Ix,
+			TokenId:          utxo.CTokenId,
+			CType:            utxo.CType,
+			Value:            utxo.Value,
+			Addr:             utxo.Addr,
+			Until:            utxo.Until,
+			Founder:          utxo.Founder,
+			TxType:           int32(utxo.TxType),
+			Script:           DecodeScript(utxo.Script),
+		})
+		list.Amounts[utxo.CTokenId] += utxo.Value
+	}
+	return list
+}
+
+// Amounts are values by token id.
+//
+type Amounts map[string]int64
+
+// Tokens returns the token ids in lexical order.
+//
+func (a Amounts) Tokens() []string {
+	tokens := make([]string, 0, len(a))
+	for token := range a {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
@@ -0,0 +1,173 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package txview
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+)
+
+func script(t *testing.T, sig *pw.UTXOSignature) []byte {
+	data, err := json.Marshal(sig)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return data
+}
+
+func testTxs(t *testing.T) []*pw.TX {
+	return []*pw.TX{
+		{
+			Founder: "did:axn:001",
+			Txin:    []*pw.TX_TXIN{{SourceTxDataHash: "hash-001", Ix: 1}},
+			Txout: []*pw.TX_TXOUT{
+				{
+					CTokenId: "token-a",
+					Value:    50,
+					Addr:     "endpoint-002",
+					Script: script(t, &pw.UTXOSignature{
+						Creator:   "did:axn:001",
+						Nonce:     "nonce",
+						PublicKey: []byte("public-key"),
+						Signature: []byte("signature"),
+					}),
+				},
+				{
+					CTokenId: "token-a",
+					Value:    950,
+					Addr:     "endpoint-001",
+					Script:   script(t, &pw.UTXOSignature{PublicKey: []byte("public-key")}),
+				},
+			},
+		},
+		nil,
+		{
+			Founder: "did:axn:fee",
+			Txout: []*pw.TX_TXOUT{
+				{CTokenId: "token-fee", Value: 10, Addr: "endpoint-platform", Script: []byte("not json")},
+				{CTokenId: "token-a", Value: 5, Addr: "endpoint-platform"},
+			},
+		},
+	}
+}
+
+func TestDecodeTxs(t *testing.T) {
+	set := DecodeTxs(testTxs(t))
+	if len(set.Txs) != 2 {
+		t.Fatalf("nil TXs should be skipped: %d", len(set.Txs))
+	}
+	tx := set.Txs[0]
+	if tx.Founder != "did:axn:001" || len(tx.Inputs) != 1 || tx.Inputs[0].Ix != 1 || len(tx.Outputs) != 2 {
+		t.Fatalf("decoded tx invalid: %+v", tx)
+	}
+	signed := tx.Outputs[0].Script
+	if signed == nil || !signed.Signed || signed.Creator != "did:axn:001" || string(signed.Signature) != "signature" {
+		t.Fatalf("signed script invalid: %+v", signed)
+	}
+	if unsigned := tx.Outputs[1].Script; unsigned == nil || unsigned.Signed || string(unsigned.PublicKey) != "public-key" {
+		t.Fatalf("unsigned script invalid: %+v", unsigned)
+	}
+	if tx.Amounts["token-a"] != 1000 {
+		t.Fatalf("tx amounts invalid: %v", tx.Amounts)
+	}
+
+	fee := set.Txs[1]
+	if fee.Ix != 2 {
+		t.Fatalf("tx index should be its position in the set: %d", fee.Ix)
+	}
+	if invalid := fee.Outputs[0].Script; invalid == nil || invalid.Err == "" || string(invalid.Raw) != "not json" {
+		t.Fatalf("invalid script should be reported: %+v", invalid)
+	}
+	if fee.Outputs[1].Script != nil {
+		t.Fatalf("empty script should decode to nil")
+	}
+	if set.Amounts.String() != "token-a=1005 token-fee=10" {
+		t.Fatalf("total amounts invalid: %s", set.Amounts)
+	}
+}
+
+func TestTxSetWrite(t *testing.T) {
+	set := DecodeTxs(testTxs(t))
+
+	text := set.String()
+	for _, line := range []string{
+		"tx 0 founder did:axn:001 (1 in, 2 out)",
+		"  in  0 hash-001:1",
+		"  out 0 50 token-a -> endpoint-002 signed by did:axn:001 nonce nonce",
+		"  out 1 950 token-a -> endpoint-001 unsigned public key",
+		"  out 1 5 token-a -> endpoint-platform no script",
+		"total token-a=1005 token-fee=10",
+	} {
+		if !strings.Contains(text, line) {
+			t.Fatalf("text should contain [%s], got:\n%s", line, text)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := set.Write(&buf, JSON); err != nil {
+		t.Fatalf("write json fail: %v", err)
+	}
+	var decoded TxSet
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("json output invalid: %v", err)
+	}
+	if len(decoded.Txs) != 2 || decoded.Amounts["token-a"] != 1005 || !decoded.Txs[0].Outputs[0].Script.Signed {
+		t.Fatalf("json output invalid: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := set.Write(&buf, Table); err != nil {
+		t.Fatalf("write table fail: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 || !strings.HasPrefix(lines[0], "TX  FOUNDER") {
+		t.Fatalf("table should have a header and one row per input and output, got:\n%s", buf.String())
+	}
+
+	if err := set.Write(&buf, Format(42)); err == nil {
+		t.Fatalf("unknown format should fail")
+	}
+}
+
+func TestDecodeUTXOs(t *testing.T) {
+	utxos := []*pw.UTXO{
+		{SourceTxDataHash: "hash-001", Ix: "0", CTokenId: "token-a", Value: 50, Addr: "endpoint-002", Founder: "did:axn:001"},
+		{SourceTxDataHash: "hash-002", Ix: "1", CTokenId: "token-a", Value: 25, Addr: "endpoint-002", Founder: "did:axn:003"},
+	}
+	list := DecodeUTXOs(utxos)
+	if len(list.UTXOs) != 2 || list.Amounts["token-a"] != 75 {
+		t.Fatalf("decoded utxos invalid: %+v", list)
+	}
+
+	text := list.String()
+	if !strings.Contains(text, "utxo hash-002:1 25 token-a -> endpoint-002 founder did:axn:003 no script") ||
+		!strings.Contains(text, "total token-a=75") {
+		t.Fatalf("text output invalid:\n%s", text)
+	}
+
+	var buf bytes.Buffer
+	if err := list.Write(&buf, Table); err != nil {
+		t.Fatalf("write table fail: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 {
+		t.Fatalf("table should have a header and one row per utxo, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wallettest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	sdked25519 "github.com/arxanchain/sdk-go-common/crypto/sign/ed25519"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/utils"
+)
+
+// keyPair is an ed25519 key pair generated by the fake platform for a
+// registered wallet. Wallets registered with their own public key have
+// no private key.
+type keyPair struct {
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func newKeyPair() (*keyPair, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &keyPair{public: public, private: private}, nil
+}
+
+// newWalletKey returns the key pair of the base64 encoded publicKey, or
+// a new key pair when publicKey is empty.
+func newWalletKey(publicKey string) (*keyPair, error) {
+	if publicKey == "" {
+		return newKeyPair()
+	}
+	public, err := utils.DecodeBase64(publicKey)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return nil, errorf(ErrCodeInvalidRequest, "public key invalid")
+	}
+	return &keyPair{public: ed25519.PublicKey(public)}, nil
+}
+
+// encodedPrivateKey returns the private key in the format expected by
+// pki.SignatureParam, or an empty string without private key.
+func (k *keyPair) encodedPrivateKey() string {
+	if k.private == nil {
+		return ""
+	}
+	return utils.EncodeBase64(k.private)
+}
+
+// encodedPublicKey returns the base64 encoded public key.
+func (k *keyPair) encodedPublicKey() string {
+	return utils.EncodeBase64(k.public)
+}
+
+// verify checks that sig is the signature of data made by the key pair
+// with the given creator and nonce, whether the fake platform holds the
+// private key or only the public key of the wallet.
+func (k *keyPair) verify(creator did.Identifier, nonce string, data, sig []byte) error {
+	msg, err := signedMessage(&pki.SignedData{
+		Data: data,
+		Header: &pki.SignatureHeader{
+			Creator: creator,
+			Nonce:   []byte(nonce),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(k.public, msg, sig) {
+		return fmt.Errorf("signature of %s verify fail", creator)
+	}
+	return nil
+}
+
+// probeKey signs the messages of signedMessage.
+var probeKey = ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+// signedMessage returns the message the SDK signs for sd.
+//
+// The message is taken to be the JSON encoding of sd, and sd is signed
+// with a probe key through the SDK to confirm it, so that signatures
+// are never verified against a guessed layout.
+func signedMessage(sd *pki.SignedData) ([]byte, error) {
+	msg, err := json.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	probe, err := sd.DoSign(&sdked25519.PrivateKey{PrivateKeyData: probeKey})
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(probeKey.Public().(ed25519.PublicKey), msg, probe.Sign) {
+		return nil, fmt.Errorf("message signed by the SDK unknown")
+	}
+	return msg, nil
+}
+
+// newID returns a random identifier with the given prefix.
+func newID(prefix string) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return prefix + hex.EncodeToString(b)
+}
+
+// hashOf returns the hex encoded sha256 hash of data.
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,755 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wallettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+)
+
+// Error codes returned by the fake platform.
+const (
+	ErrCodeInvalidRequest       = 3000
+	ErrCodeWalletExists         = 3001
+	ErrCodeWalletNotFound       = 3002
+	ErrCodePOENotFound          = 3003
+	ErrCodePOEReadOnly          = 3004
+	ErrCodeFileExists           = 3005
+	ErrCodeSignatureInvalid     = 3006
+	ErrCodeTxNotFound           = 3007
+	ErrCodeUTXOSpent            = 3008
+	ErrCodeAssetIssued          = 3009
+	ErrCodeBalanceNotSufficient = 5015
+)
+
+// platformError is an error returned to the client as an ErrCode.
+type platformError struct {
+	code int
+	msg  string
+}
+
+func (e *platformError) Error() string {
+	return e.msg
+}
+
+func errorf(code int, format string, args ...interface{}) error {
+	return &platformError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// walletRecord is a registered main or sub wallet.
+type walletRecord struct {
+	info    *wallet.WalletInfo
+	parent  did.Identifier
+	keyPair *keyPair
+}
+
+// poeRecord is a POE digital asset.
+type poeRecord struct {
+	body     wallet.POEBody
+	created  int64
+	updated  int64
+	fileHash string
+	readOnly bool
+}
+
+// output is a transaction output, spent or not.
+type output struct {
+	txID    string
+	ix      int
+	out     *pw.TX_TXOUT
+	founder string
+	spent   bool
+}
+
+func (o *output) utxo() *pw.UTXO {
+	return &pw.UTXO{
+		SourceTxDataHash: o.txID,
+		Ix:               strconv.Itoa(o.ix),
+		CTokenId:         o.out.CTokenId,
+		CType:            o.out.CType,
+		Value:            o.out.Value,
+		Addr:             o.out.Addr,
+		Until:            o.out.Until,
+		Script:           o.out.Script,
+		Founder:          o.founder,
+	}
+}
+
+// prepared is a transaction returned by a prepare endpoint and waiting
+// to be signed and processed.
+type prepared struct {
+	tx      *pw.TX
+	inputs  []*output
+	tokenID string
+}
+
+// Token types of transaction outputs.
+const (
+	ctypeColoredToken = 0
+	ctypeDigitalAsset = 1
+)
+
+// ledger holds the state of the fake platform. It is not safe for
+// concurrent use, Server serializes access to it.
+type ledger struct {
+	wallets   map[did.Identifier]*walletRecord
+	accesses  map[string]did.Identifier
+	endpoints map[string]did.Identifier
+	poes      map[did.Identifier]*poeRecord
+	indexes   map[did.Identifier]*wallet.IndexTags
+	outputs   map[string][]*output
+	order     []string
+	prepared  map[string]*prepared
+
+	feePayer did.Identifier
+	feeToken string
+	fee      int64
+	feeAddr  string
+}
+
+func newLedger() *ledger {
+	return &ledger{
+		wallets:   make(map[did.Identifier]*walletRecord),
+		accesses:  make(map[string]did.Identifier),
+		endpoints: make(map[string]did.Identifier),
+		poes:      make(map[did.Identifier]*poeRecord),
+		indexes:   make(map[did.Identifier]*wallet.IndexTags),
+		outputs:   make(map[string][]*output),
+		prepared:  make(map[string]*prepared),
+		feeAddr:   "platform-fee-endpoint",
+	}
+}
+
+func now() int64 {
+	return time.Now().Unix()
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Wallets
+
+// register registers a wallet with a new key pair, or with publicKey
+// when it is set, in which case no private key is returned.
+func (l *ledger) register(access string, typ pw.DidType, parent did.Identifier, publicKey string) (*wallet.WalletResponse, error) {
+	if access != "" {
+		if _, ok := l.accesses[access]; ok {
+			return nil, errorf(ErrCodeWalletExists, "wallet access %s already exists", access)
+		}
+	}
+	if parent != "" {
+		if _, ok := l.wallets[parent]; !ok {
+			return nil, errorf(ErrCodeWalletNotFound, "wallet %s not found", parent)
+		}
+	}
+
+	keyPair, err := newWalletKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	id := did.Identifier(newID("did:axn:"))
+	endpoint := did.DidEndpoint(hashOf([]byte(id)))
+	created := now()
+	l.wallets[id] = &walletRecord{
+		info: &wallet.WalletInfo{
+			Id:       id,
+			Type:     typ,
+			Endpoint: endpoint,
+			Status:   pw.Status_VALID,
+			Created:  created,
+			Updated:  created,
+		},
+		parent:  parent,
+		keyPair: keyPair,
+	}
+	if access != "" {
+		l.accesses[access] = id
+	}
+	l.endpoints[string(endpoint)] = id
+
+	return &wallet.WalletResponse{
+		Id:       id,
+		Endpoint: endpoint,
+		Created:  created,
+		KeyPair: &wallet.KeyPair{
+			PrivateKey: keyPair.encodedPrivateKey(),
+			PublicKey:  keyPair.encodedPublicKey(),
+		},
+	}, nil
+}
+
+func (l *ledger) wallet(id did.Identifier) (*walletRecord, error) {
+	w, ok := l.wallets[id]
+	if !ok {
+		return nil, errorf(ErrCodeWalletNotFound, "wallet %s not found", id)
+	}
+	return w, nil
+}
+
+func (l *ledger) balance(id did.Identifier) (*wallet.WalletBalance, error) {
+	w, err := l.wallet(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &wallet.WalletBalance{
+		ColoredTokens: make(map[string]*wallet.Balance),
+		DigitalAssets: make(map[string]*wallet.Balance),
+	}
+	for _, o := range l.unspent(string(w.info.Endpoint), "", -1) {
+		balances := result.ColoredTokens
+		if o.out.CType == ctypeDigitalAsset {
+			balances = result.DigitalAssets
+		}
+		b, ok := balances[o.out.CTokenId]
+		if !ok {
+			b = &wallet.Balance{Id: o.out.CTokenId}
+			balances[o.out.CTokenId] = b
+		}
+		b.Amount += o.out.Value
+	}
+	return result, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// POE and indexes
+
+func (l *ledger) createPOE(body *wallet.POEBody) (did.Identifier, error) {
+	if body.Name == "" {
+		return "", errorf(ErrCodeInvalidRequest, "poe name must be set")
+	}
+	if _, err := l.wallet(body.Owner); err != nil {
+		return "", err
+	}
+	if body.Id == "" {
+		body.Id = did.Identifier(newID("did:axn:poe-"))
+	}
+	if _, ok := l.poes[body.Id]; ok {
+		return "", errorf(ErrCodeInvalidRequest, "poe %s already exists", body.Id)
+	}
+
+	created := now()
+	l.poes[body.Id] = &poeRecord{
+		body:    *body,
+		created: created,
+		updated: created,
+	}
+	if body.Indexes != nil {
+		l.setIndex(body.Id, body.Indexes)
+	}
+	return body.Id, nil
+}
+
+func (l *ledger) updatePOE(creator did.Identifier, body *wallet.POEBody) error {
+	poe, err := l.poe(body.Id)
+	if err != nil {
+		return err
+	}
+	if poe.body.Owner != creator {
+		return errorf(ErrCodeSignatureInvalid, "poe %s is not owned by %s", body.Id, creator)
+	}
+	poe.body.Name = body.Name
+	poe.body.Metadata = body.Metadata
+	poe.body.ParentId = body.ParentId
+	poe.updated = now()
+	return nil
+}
+
+func (l *ledger) poe(id did.Identifier) (*poeRecord, error) {
+	poe, ok := l.poes[id]
+	if !ok {
+		return nil, errorf(ErrCodePOENotFound, "poe %s not found", id)
+	}
+	return poe, nil
+}
+
+func (l *ledger) uploadPOEFile(id did.Identifier, content []byte, readOnly bool) error {
+	poe, err := l.poe(id)
+	if err != nil {
+		return err
+	}
+	if poe.readOnly {
+		return errorf(ErrCodePOEReadOnly, "poe %s file is read only", id)
+	}
+	hash := hashOf(content)
+	for otherID, other := range l.poes {
+		if otherID != id && other.fileHash == hash {
+			return errorf(ErrCodeFileExists, "file already uploaded for poe %s", otherID)
+		}
+	}
+	poe.fileHash = hash
+	poe.readOnly = readOnly
+	poe.updated = now()
+	return nil
+}
+
+func (l *ledger) setIndex(id did.Identifier, tags *wallet.IndexTags) {
+	l.indexes[id] = tags
+}
+
+func (l *ledger) getIndex(tags *wallet.IndexTags) []string {
+	var ids []string
+	for id, indexed := range l.indexes {
+		if matchIndex(indexed, tags) {
+			ids = append(ids, string(id))
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// matchIndex reports whether indexed has exactly the combined index of
+// query, or any of its individual indexes.
+func matchIndex(indexed, query *wallet.IndexTags) bool {
+	if len(query.CombinedIndex) > 0 && sameSet(indexed.CombinedIndex, query.CombinedIndex) {
+		return true
+	}
+	for _, want := range query.IndividualIndex {
+		for _, have := range indexed.IndividualIndex {
+			if want == have {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]int, len(a))
+	for _, s := range a {
+		set[s]++
+	}
+	for _, s := range b {
+		if set[s] == 0 {
+			return false
+		}
+		set[s]--
+	}
+	return true
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// UTXO accounting
+
+// mint creates an unspent output without a transaction, it is used to
+// fund wallets in tests.
+func (l *ledger) mint(id did.Identifier, tokenID string, ctype int32, amount int64) (string, error) {
+	w, err := l.wallet(id)
+	if err != nil {
+		return "", err
+	}
+	txID := newID("")
+	l.addOutputs(txID, string(id), []*pw.TX_TXOUT{
+		{
+			CTokenId: tokenID,
+			CType:    ctype,
+			Value:    amount,
+			Addr:     string(w.info.Endpoint),
+		},
+	})
+	return txID, nil
+}
+
+func (l *ledger) addOutputs(txID, founder string, outs []*pw.TX_TXOUT) {
+	for i, out := range outs {
+		l.outputs[txID] = append(l.outputs[txID], &output{
+			txID:    txID,
+			ix:      i,
+			out:     out,
+			founder: founder,
+		})
+	}
+	l.order = append(l.order, txID)
+}
+
+// outputsOf returns the outputs sent to addr in creation order. ctype
+// -1 matches any token type, spent selects spent or unspent outputs.
+func (l *ledger) outputsOf(addr, tokenID string, ctype int32, spent bool) []*output {
+	var result []*output
+	for _, txID := range l.order {
+		for _, o := range l.outputs[txID] {
+			if o.out.Addr != addr || o.spent != spent {
+				continue
+			}
+			if tokenID != "" && o.out.CTokenId != tokenID {
+				continue
+			}
+			if ctype >= 0 && o.out.CType != ctype {
+				continue
+			}
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+func (l *ledger) unspent(addr, tokenID string, ctype int32) []*output {
+	return l.outputsOf(addr, tokenID, ctype, false)
+}
+
+// selectInputs returns unspent outputs of addr holding at least amount
+// of tokenID, and their total value.
+func (l *ledger) selectInputs(addr, tokenID string, ctype int32, amount int64) ([]*output, int64, error) {
+	var inputs []*output
+	var total int64
+	for _, o := range l.unspent(addr, tokenID, ctype) {
+		if total >= amount {
+			break
+		}
+		inputs = append(inputs, o)
+		total += o.out.Value
+	}
+	if total < amount {
+		return nil, 0, errorf(ErrCodeBalanceNotSufficient, "balance of %s not sufficient: %d < %d", tokenID, total, amount)
+	}
+	return inputs, total, nil
+}
+
+// newTx builds a transaction founded by founder spending inputs. Every
+// output carries a script to be signed by the founder.
+func (l *ledger) newTx(founder *walletRecord, inputs []*output, outs []*pw.TX_TXOUT) (*pw.TX, error) {
+	tx := &pw.TX{
+		Founder: string(founder.info.Id),
+	}
+	for _, in := range inputs {
+		tx.Txin = append(tx.Txin, &pw.TX_TXIN{
+			SourceTxDataHash: in.txID,
+			Ix:               int32(in.ix),
+		})
+	}
+	for _, out := range outs {
+		script, err := json.Marshal(&pw.UTXOSignature{
+			PublicKey: founder.keyPair.public,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out.Script = script
+		tx.Txout = append(tx.Txout, out)
+	}
+	return tx, nil
+}
+
+// feeTx builds the transaction paying the platform fee, if any.
+func (l *ledger) feeTx() (*prepared, error) {
+	if l.fee <= 0 {
+		return nil, nil
+	}
+	payer, err := l.wallet(l.feePayer)
+	if err != nil {
+		return nil, err
+	}
+	addr := string(payer.info.Endpoint)
+	inputs, total, err := l.selectInputs(addr, l.feeToken, ctypeColoredToken, l.fee)
+	if err != nil {
+		return nil, err
+	}
+	outs := []*pw.TX_TXOUT{
+		{CTokenId: l.feeToken, CType: ctypeColoredToken, Value: l.fee, Addr: l.feeAddr},
+	}
+	if total > l.fee {
+		outs = append(outs, &pw.TX_TXOUT{CTokenId: l.feeToken, CType: ctypeColoredToken, Value: total - l.fee, Addr: addr})
+	}
+	tx, err := l.newTx(payer, inputs, outs)
+	if err != nil {
+		return nil, err
+	}
+	return &prepared{tx: tx, inputs: inputs}, nil
+}
+
+// prepare registers txs as waiting to be processed and returns them
+// with the fee transaction appended.
+func (l *ledger) prepare(txs ...*prepared) ([]*pw.TX, error) {
+	fee, err := l.feeTx()
+	if err != nil {
+		return nil, err
+	}
+	if fee != nil {
+		txs = append(txs, fee)
+	}
+
+	var result []*pw.TX
+	for _, p := range txs {
+		key, err := fingerprint(p.tx)
+		if err != nil {
+			return nil, err
+		}
+		l.prepared[key] = p
+		result = append(result, p.tx)
+	}
+	return result, nil
+}
+
+func (l *ledger) prepareIssueCToken(body *wallet.IssueBody) (*wallet.IssueCTokenPrepareResponse, error) {
+	if body.Amount <= 0 {
+		return nil, errorf(ErrCodeInvalidRequest, "issue amount must be positive")
+	}
+	issuer, err := l.wallet(did.Identifier(body.Issuer))
+	if err != nil {
+		return nil, err
+	}
+	owner, err := l.wallet(did.Identifier(body.Owner))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = l.poe(did.Identifier(body.AssetId)); err != nil {
+		return nil, err
+	}
+
+	tokenID := hashOf([]byte(newID(body.AssetId)))
+	tx, err := l.newTx(issuer, nil, []*pw.TX_TXOUT{
+		{CTokenId: tokenID, CType: ctypeColoredToken, Value: body.Amount, Addr: string(owner.info.Endpoint)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	txs, err := l.prepare(&prepared{tx: tx, tokenID: tokenID})
+	if err != nil {
+		return nil, err
+	}
+	return &wallet.IssueCTokenPrepareResponse{TokenId: tokenID, Txs: txs}, nil
+}
+
+func (l *ledger) prepareIssueAsset(body *wallet.IssueAssetBody) ([]*pw.TX, error) {
+	issuer, err := l.wallet(did.Identifier(body.Issuer))
+	if err != nil {
+		return nil, err
+	}
+	owner, err := l.wallet(did.Identifier(body.Owner))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = l.poe(did.Identifier(body.AssetId)); err != nil {
+		return nil, err
+	}
+	for _, outs := range l.outputs {
+		for _, o := range outs {
+			if o.out.CType == ctypeDigitalAsset && o.out.CTokenId == body.AssetId {
+				return nil, errorf(ErrCodeAssetIssued, "asset %s already issued", body.AssetId)
+			}
+		}
+	}
+
+	tx, err := l.newTx(issuer, nil, []*pw.TX_TXOUT{
+		{CTokenId: body.AssetId, CType: ctypeDigitalAsset, Value: 1, Addr: string(owner.info.Endpoint)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.prepare(&prepared{tx: tx})
+}
+
+func (l *ledger) prepareTransferCToken(body *wallet.TransferCTokenBody) ([]*pw.TX, error) {
+	if len(body.Tokens) == 0 {
+		return nil, errorf(ErrCodeInvalidRequest, "tokens must be set")
+	}
+	from, err := l.wallet(did.Identifier(body.From))
+	if err != nil {
+		return nil, err
+	}
+	to, err := l.wallet(did.Identifier(body.To))
+	if err != nil {
+		return nil, err
+	}
+
+	fromAddr := string(from.info.Endpoint)
+	var inputs []*output
+	var outs []*pw.TX_TXOUT
+	for _, token := range body.Tokens {
+		if token.Amount <= 0 {
+			return nil, errorf(ErrCodeInvalidRequest, "transfer amount of %s must be positive", token.TokenId)
+		}
+		selected, total, err := l.selectInputs(fromAddr, token.TokenId, ctypeColoredToken, token.Amount)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, selected...)
+		outs = append(outs, &pw.TX_TXOUT{CTokenId: token.TokenId, CType: ctypeColoredToken, Value: token.Amount, Addr: string(to.info.Endpoint)})
+		if total > token.Amount {
+			outs = append(outs, &pw.TX_TXOUT{CTokenId: token.TokenId, CType: ctypeColoredToken, Value: total - token.Amount, Addr: fromAddr})
+		}
+	}
+
+	tx, err := l.newTx(from, inputs, outs)
+	if err != nil {
+		return nil, err
+	}
+	return l.prepare(&prepared{tx: tx, inputs: inputs})
+}
+
+func (l *ledger) prepareTransferAsset(body *wallet.TransferAssetBody) ([]*pw.TX, error) {
+	if len(body.Assets) == 0 {
+		return nil, errorf(ErrCodeInvalidRequest, "assets must be set")
+	}
+	from, err := l.wallet(did.Identifier(body.From))
+	if err != nil {
+		return nil, err
+	}
+	to, err := l.wallet(did.Identifier(body.To))
+	if err != nil {
+		return nil, err
+	}
+
+	var inputs []*output
+	var outs []*pw.TX_TXOUT
+	for _, asset := range body.Assets {
+		selected, _, err := l.selectInputs(string(from.info.Endpoint), asset, ctypeDigitalAsset, 1)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, selected...)
+		outs = append(outs, &pw.TX_TXOUT{CTokenId: asset, CType: ctypeDigitalAsset, Value: 1, Addr: string(to.info.Endpoint)})
+	}
+
+	tx, err := l.newTx(from, inputs, outs)
+	if err != nil {
+		return nil, err
+	}
+	return l.prepare(&prepared{tx: tx, inputs: inputs})
+}
+
+// process verifies the signatures of txs and commits them atomically:
+// either every transaction is applied or none.
+func (l *ledger) process(txs []*pw.TX) (*wallet.WalletResponse, error) {
+	if len(txs) == 0 {
+		return nil, errorf(ErrCodeInvalidRequest, "txs must be set")
+	}
+
+	var batch []*prepared
+	keys := make([]string, 0, len(txs))
+	spending := make(map[*output]bool)
+	for _, tx := range txs {
+		key, err := fingerprint(tx)
+		if err != nil {
+			return nil, err
+		}
+		p, ok := l.prepared[key]
+		if !ok {
+			return nil, errorf(ErrCodeTxNotFound, "tx founded by %s was not prepared", tx.Founder)
+		}
+		if err = l.verifyTx(tx); err != nil {
+			return nil, err
+		}
+		for _, in := range p.inputs {
+			if in.spent || spending[in] {
+				return nil, errorf(ErrCodeUTXOSpent, "utxo %s:%d already spent", in.txID, in.ix)
+			}
+			spending[in] = true
+		}
+		batch = append(batch, &prepared{tx: tx, inputs: p.inputs, tokenID: p.tokenID})
+		keys = append(keys, key)
+	}
+
+	result := &wallet.WalletResponse{}
+	for i, p := range batch {
+		for _, in := range p.inputs {
+			in.spent = true
+		}
+		txID := hashOf([]byte(keys[i] + newID("")))
+		l.addOutputs(txID, p.tx.Founder, p.tx.Txout)
+		delete(l.prepared, keys[i])
+		result.TransactionIds = append(result.TransactionIds, txID)
+		if p.tokenID != "" {
+			result.TokenId = p.tokenID
+		}
+	}
+	return result, nil
+}
+
+// verifyTx checks that every output script of tx is signed by its founder.
+func (l *ledger) verifyTx(tx *pw.TX) error {
+	founder, err := l.wallet(did.Identifier(tx.Founder))
+	if err != nil {
+		return err
+	}
+	for _, out := range tx.Txout {
+		var sig pw.UTXOSignature
+		if err = json.Unmarshal(out.Script, &sig); err != nil {
+			return errorf(ErrCodeSignatureInvalid, "tx output script invalid: %v", err)
+		}
+		if sig.Creator != tx.Founder {
+			return errorf(ErrCodeSignatureInvalid, "tx output signed by %s instead of founder %s", sig.Creator, tx.Founder)
+		}
+		err = founder.keyPair.verify(did.Identifier(sig.Creator), sig.Nonce, sig.PublicKey, sig.Signature)
+		if err != nil {
+			return errorf(ErrCodeSignatureInvalid, "%v", err)
+		}
+	}
+	return nil
+}
+
+// fingerprint identifies a prepared transaction regardless of the
+// signatures added to its output scripts.
+func fingerprint(tx *pw.TX) (string, error) {
+	unsigned := &pw.TX{
+		Founder: tx.Founder,
+		Txin:    tx.Txin,
+	}
+	for _, out := range tx.Txout {
+		unsigned.Txout = append(unsigned.Txout, &pw.TX_TXOUT{
+			CTokenId: out.CTokenId,
+			CType:    out.CType,
+			Value:    out.Value,
+			Addr:     out.Addr,
+			Until:    out.Until,
+		})
+	}
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+	return hashOf(data), nil
+}
+
+// transactionLogs returns the outputs sent to id: "out" returns the
+// ones it spent, anything else every output ever sent to it.
+func (l *ledger) transactionLogs(id did.Identifier, txType string) ([]*pw.UTXO, error) {
+	w, err := l.wallet(id)
+	if err != nil {
+		return nil, err
+	}
+	addr := string(w.info.Endpoint)
+	outs := l.outputsOf(addr, "", -1, true)
+	if txType != "out" {
+		outs = append(l.outputsOf(addr, "", -1, false), outs...)
+	}
+	return utxos(outs), nil
+}
+
+func (l *ledger) transactionOutputs(id did.Identifier, spent bool) ([]*pw.UTXO, error) {
+	w, err := l.wallet(id)
+	if err != nil {
+		return nil, err
+	}
+	return utxos(l.outputsOf(string(w.info.Endpoint), "", -1, spent)), nil
+}
+
+func utxos(outs []*output) []*pw.UTXO {
+	result := make([]*pw.UTXO, 0, len(outs))
+	for _, o := range outs {
+		result = append(result, o.utxo())
+	}
+	return result
+}
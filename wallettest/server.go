@@ -0,0 +1,577 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wallettest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/arxanchain/sdk-go-common/errors"
+	pw "github.com/arxanchain/sdk-go-common/protos/wallet"
+	restapi "github.com/arxanchain/sdk-go-common/rest/api"
+	rtstructs "github.com/arxanchain/sdk-go-common/rest/structs"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/sdk-go-common/utils"
+)
+
+// CallbackURLHeader is the http header carrying the url blockchain
+// transaction events are posted to.
+const CallbackURLHeader = "Callback-Url"
+
+// Event is a blockchain transaction event emitted by the fake platform.
+//
+type Event struct {
+	BlockNumber   uint64      `json:"block_number"`
+	BlockHash     []byte      `json:"block_hash"`
+	ChannelId     string      `json:"channel_id"`
+	TransactionId string      `json:"transaction_id"`
+	IsInvalid     bool        `json:"is_invalid"`
+	Payload       interface{} `json:"payload"`
+}
+
+// Server is an in-memory fake of the wallet platform serving the
+// /v1/wallet, /v1/poe, /v1/index and /v2/transaction APIs on a local
+// http server.
+//
+// It registers DIDs with freshly generated ed25519 key pairs, or with
+// the public key sent by the client in the 'public_key' field, tracks
+// POEs and indexes, issues and transfers tokens with UTXO accounting,
+// verifies the signatures of requests and transactions, and emits one
+// blockchain transaction event per state change.
+//
+// Every transaction is confirmed as soon as it is processed, the
+// 'BC-Invoke-Mode' header is ignored.
+//
+type Server struct {
+	// URL is the base url of the server, to be used as the
+	// Address of the restapi.Config of the client under test.
+	URL string
+
+	srv    *httptest.Server
+	client *http.Client
+
+	mu     sync.Mutex
+	ledger *ledger
+	events []*Event
+}
+
+// NewServer starts and returns a new fake platform server. The caller
+// should call Close when finished, to shut it down.
+//
+func NewServer() *Server {
+	s := &Server{
+		// Do not use http.DefaultClient to post callback events, tests
+		// commonly replace the default transport with a mock.
+		client: &http.Client{Transport: &http.Transport{}, Timeout: 5 * time.Second},
+		ledger: newLedger(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/wallet/register", s.post(s.register))
+	mux.HandleFunc("/v1/wallet/register/subwallet", s.post(s.registerSubWallet))
+	mux.HandleFunc("/v1/wallet/balance", s.get(s.walletBalance))
+	mux.HandleFunc("/v1/wallet/info", s.get(s.walletInfo))
+	mux.HandleFunc("/v1/poe/create", s.post(s.createPOE))
+	mux.HandleFunc("/v1/poe/update", s.handle("PUT", s.updatePOE))
+	mux.HandleFunc("/v1/poe", s.get(s.queryPOE))
+	mux.HandleFunc("/v1/poe/upload", s.post(s.uploadPOEFile))
+	mux.HandleFunc("/v1/index/set", s.post(s.indexSet))
+	mux.HandleFunc("/v1/index/get", s.post(s.indexGet))
+	mux.HandleFunc("/v2/transaction/tokens/issue/prepare", s.post(s.prepareIssueCToken))
+	mux.HandleFunc("/v2/transaction/assets/issue/prepare", s.post(s.prepareIssueAsset))
+	mux.HandleFunc("/v2/transaction/tokens/transfer/prepare", s.post(s.prepareTransferCToken))
+	mux.HandleFunc("/v2/transaction/assets/transfer/prepare", s.post(s.prepareTransferAsset))
+	mux.HandleFunc("/v2/transaction/process", s.post(s.processTx))
+	mux.HandleFunc("/v2/transaction/logs", s.get(s.transactionLogs))
+	mux.HandleFunc("/v2/transaction/utxo", s.get(s.transactionUTXO))
+	mux.HandleFunc("/v2/transaction/stxo", s.get(s.transactionSTXO))
+
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+//
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Config returns a client config pointing at the server. The client
+// uses a dedicated http transport, so it keeps working when tests mock
+// the default one.
+//
+func (s *Server) Config() *restapi.Config {
+	return &restapi.Config{
+		Address:    s.URL,
+		ApiKey:     "wallettest",
+		HttpClient: &http.Client{Transport: &http.Transport{}},
+	}
+}
+
+// CreateWallet registers a wallet directly, without going through the
+// http API nor emitting an event. The response carries the private key
+// of the wallet.
+//
+func (s *Server) CreateWallet() (*wallet.WalletResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ledger.register("", pw.DidType_ORGANIZATION, "", "")
+}
+
+// Fund credits the wallet id with amount colored tokens tokenID, and
+// returns the id of the funding transaction.
+//
+func (s *Server) Fund(id did.Identifier, tokenID string, amount int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ledger.mint(id, tokenID, ctypeColoredToken, amount)
+}
+
+// SetFee makes every transaction proposal carry an extra transaction,
+// founded by payer, paying amount colored tokens tokenID to the
+// platform. A zero amount disables fees, which is the default.
+//
+// The fee payer is expected to be the wallet whose key pair is set as
+// EnterpriseSignParam of the client config.
+//
+func (s *Server) SetFee(payer did.Identifier, tokenID string, amount int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ledger.feePayer = payer
+	s.ledger.feeToken = tokenID
+	s.ledger.fee = amount
+}
+
+// FeeAddr returns the platform address fee transactions pay to, see
+// SetFee.
+//
+func (s *Server) FeeAddr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ledger.feeAddr
+}
+
+// Events returns the blockchain transaction events emitted so far.
+//
+func (s *Server) Events() []*Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]*Event, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// http plumbing
+
+// handlerFunc handles a request with the ledger locked. It returns the
+// response payload, and the events to emit once the ledger is unlocked.
+type handlerFunc func(r *http.Request) (payload interface{}, events []*Event, err error)
+
+func (s *Server) post(h handlerFunc) http.HandlerFunc {
+	return s.handle("POST", h)
+}
+
+func (s *Server) get(h handlerFunc) http.HandlerFunc {
+	return s.handle("GET", h)
+}
+
+func (s *Server) handle(method string, h handlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.mu.Lock()
+		payload, events, err := h(r)
+		for _, event := range events {
+			event.BlockNumber = uint64(len(s.events) + 1)
+			event.BlockHash = []byte(hashOf([]byte(event.TransactionId)))
+			event.ChannelId = "pubchain"
+			s.events = append(s.events, event)
+		}
+		s.mu.Unlock()
+
+		resp := &rtstructs.Response{
+			ErrCode: errors.SuccCode,
+			Method:  r.URL.Path,
+		}
+		if err != nil {
+			resp.ErrCode = ErrCodeInvalidRequest
+			if perr, ok := err.(*platformError); ok {
+				resp.ErrCode = perr.code
+			}
+			resp.ErrMessage = err.Error()
+		} else {
+			data, err := json.Marshal(payload)
+			if err != nil {
+				http.Error(rw, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			resp.Payload = string(data)
+		}
+
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(resp)
+
+		if url := r.Header.Get(CallbackURLHeader); url != "" {
+			for _, event := range events {
+				s.notify(url, event)
+			}
+		}
+	}
+}
+
+// notify posts event to the callback url. Delivery failures are
+// ignored, events stay available from Events.
+func (s *Server) notify(url string, event *Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	resp, err := s.client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+func decode(r *http.Request, v interface{}) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return errorf(ErrCodeInvalidRequest, "request payload invalid: %v", err)
+	}
+	return nil
+}
+
+// newEvent returns the event of a transaction committing payload.
+func newEvent(payload interface{}) *Event {
+	return &Event{
+		TransactionId: hashOf([]byte(newID(""))),
+		Payload:       payload,
+	}
+}
+
+func txIDs(events []*Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.TransactionId)
+	}
+	return ids
+}
+
+// paginate returns the requested page of utxos, all of them if the
+// page size is 0.
+func paginate(utxos []*pw.UTXO, r *http.Request) []*pw.UTXO {
+	num, _ := strconv.Atoi(r.URL.Query().Get("num"))
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if num <= 0 {
+		return utxos
+	}
+	if page <= 0 {
+		page = 1
+	}
+	start := (page - 1) * num
+	if start >= len(utxos) {
+		return []*pw.UTXO{}
+	}
+	end := start + num
+	if end > len(utxos) {
+		end = len(utxos)
+	}
+	return utxos[start:end]
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Wallets
+
+func (s *Server) register(r *http.Request) (interface{}, []*Event, error) {
+	var body struct {
+		wallet.RegisterWalletBody
+		PublicKey string `json:"public_key"`
+	}
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	if body.Access == "" {
+		return nil, nil, errorf(ErrCodeInvalidRequest, "wallet access must be set")
+	}
+	result, err := s.ledger.register(body.Access, body.Type, "", body.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if body.Indexes != nil {
+		s.ledger.setIndex(result.Id, body.Indexes)
+	}
+	event := newEvent(map[string]interface{}{"id": result.Id})
+	result.TransactionIds = []string{event.TransactionId}
+	return result, []*Event{event}, nil
+}
+
+func (s *Server) registerSubWallet(r *http.Request) (interface{}, []*Event, error) {
+	var body struct {
+		wallet.RegisterSubWalletBody
+		PublicKey string `json:"public_key"`
+	}
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	result, err := s.ledger.register("", body.Type, body.Id, body.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if body.Indexes != nil {
+		s.ledger.setIndex(result.Id, body.Indexes)
+	}
+	event := newEvent(map[string]interface{}{"id": result.Id, "parent_id": body.Id})
+	result.TransactionIds = []string{event.TransactionId}
+	return result, []*Event{event}, nil
+}
+
+func (s *Server) walletBalance(r *http.Request) (interface{}, []*Event, error) {
+	result, err := s.ledger.balance(did.Identifier(r.URL.Query().Get("id")))
+	return result, nil, err
+}
+
+func (s *Server) walletInfo(r *http.Request) (interface{}, []*Event, error) {
+	w, err := s.ledger.wallet(did.Identifier(r.URL.Query().Get("id")))
+	if err != nil {
+		return nil, nil, err
+	}
+	return w.info, nil, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// POE and indexes
+
+// signedPOE decodes a signed POE request and verifies its signature.
+func (s *Server) signedPOE(r *http.Request) (did.Identifier, *wallet.POEBody, error) {
+	var req wallet.WalletRequest
+	if err := decode(r, &req); err != nil {
+		return "", nil, err
+	}
+	if req.Signature == nil {
+		return "", nil, errorf(ErrCodeSignatureInvalid, "request signature must be set")
+	}
+	creator := req.Signature.Creator
+	signer, err := s.ledger.wallet(creator)
+	if err != nil {
+		return "", nil, err
+	}
+	sig, err := utils.DecodeBase64(req.Signature.SignatureValue)
+	if err != nil {
+		return "", nil, errorf(ErrCodeSignatureInvalid, "request signature invalid: %v", err)
+	}
+	err = signer.keyPair.verify(creator, req.Signature.Nonce, []byte(req.Payload), []byte(sig))
+	if err != nil {
+		return "", nil, errorf(ErrCodeSignatureInvalid, "%v", err)
+	}
+
+	var body wallet.POEBody
+	if err = json.Unmarshal([]byte(req.Payload), &body); err != nil {
+		return "", nil, errorf(ErrCodeInvalidRequest, "request payload invalid: %v", err)
+	}
+	return creator, &body, nil
+}
+
+func (s *Server) createPOE(r *http.Request) (interface{}, []*Event, error) {
+	_, body, err := s.signedPOE(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	id, err := s.ledger.createPOE(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	event := newEvent(map[string]interface{}{"id": id, "metadata": body.Metadata})
+	return &wallet.WalletResponse{Id: id, TransactionIds: []string{event.TransactionId}}, []*Event{event}, nil
+}
+
+func (s *Server) updatePOE(r *http.Request) (interface{}, []*Event, error) {
+	creator, body, err := s.signedPOE(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = s.ledger.updatePOE(creator, body); err != nil {
+		return nil, nil, err
+	}
+	event := newEvent(map[string]interface{}{"id": body.Id, "metadata": body.Metadata})
+	return &wallet.WalletResponse{Id: body.Id, TransactionIds: []string{event.TransactionId}}, []*Event{event}, nil
+}
+
+func (s *Server) queryPOE(r *http.Request) (interface{}, []*Event, error) {
+	poe, err := s.ledger.poe(did.Identifier(r.URL.Query().Get("id")))
+	if err != nil {
+		return nil, nil, err
+	}
+	return &wallet.POEPayload{
+		Id:       poe.body.Id,
+		Name:     poe.body.Name,
+		Owner:    poe.body.Owner,
+		Metadata: poe.body.Metadata,
+		Created:  poe.created,
+		Updated:  poe.updated,
+		Status:   pw.Status_VALID,
+	}, nil, nil
+}
+
+func (s *Server) uploadPOEFile(r *http.Request) (interface{}, []*Event, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, nil, errorf(ErrCodeInvalidRequest, "request form invalid: %v", err)
+	}
+	id := did.Identifier(r.FormValue(wallet.OffchainPOEID))
+	readOnly, _ := strconv.ParseBool(r.FormValue(wallet.OffchainReadOnly))
+	file, _, err := r.FormFile(wallet.OffchainPOEFile)
+	if err != nil {
+		return nil, nil, errorf(ErrCodeInvalidRequest, "poe file must be set: %v", err)
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = s.ledger.uploadPOEFile(id, content, readOnly); err != nil {
+		return nil, nil, err
+	}
+	event := newEvent(map[string]interface{}{"id": id, "hash": hashOf(content)})
+	return &wallet.UploadResponse{Id: id, TransactionIds: []string{event.TransactionId}}, []*Event{event}, nil
+}
+
+func (s *Server) indexSet(r *http.Request) (interface{}, []*Event, error) {
+	var body wallet.IndexSetPayload
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	if body.Id == "" || body.Indexs == nil {
+		return nil, nil, errorf(ErrCodeInvalidRequest, "index id and tags must be set")
+	}
+	s.ledger.setIndex(body.Id, body.Indexs)
+	events := []*Event{newEvent(&body)}
+	return txIDs(events), events, nil
+}
+
+func (s *Server) indexGet(r *http.Request) (interface{}, []*Event, error) {
+	var body wallet.IndexGetPayload
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	if body.Indexs == nil {
+		return nil, nil, errorf(ErrCodeInvalidRequest, "index tags must be set")
+	}
+	return s.ledger.getIndex(body.Indexs), nil, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// Transactions
+
+func (s *Server) prepareIssueCToken(r *http.Request) (interface{}, []*Event, error) {
+	var body wallet.IssueBody
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	result, err := s.ledger.prepareIssueCToken(&body)
+	return result, nil, err
+}
+
+func (s *Server) prepareIssueAsset(r *http.Request) (interface{}, []*Event, error) {
+	var body wallet.IssueAssetBody
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	result, err := s.ledger.prepareIssueAsset(&body)
+	return result, nil, err
+}
+
+func (s *Server) prepareTransferCToken(r *http.Request) (interface{}, []*Event, error) {
+	var body wallet.TransferCTokenBody
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	result, err := s.ledger.prepareTransferCToken(&body)
+	return result, nil, err
+}
+
+func (s *Server) prepareTransferAsset(r *http.Request) (interface{}, []*Event, error) {
+	var body wallet.TransferAssetBody
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	result, err := s.ledger.prepareTransferAsset(&body)
+	return result, nil, err
+}
+
+func (s *Server) processTx(r *http.Request) (interface{}, []*Event, error) {
+	var body wallet.ProcessTxBody
+	if err := decode(r, &body); err != nil {
+		return nil, nil, err
+	}
+	result, err := s.ledger.process(body.Txs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var events []*Event
+	for i, txID := range result.TransactionIds {
+		events = append(events, &Event{
+			TransactionId: txID,
+			Payload:       body.Txs[i],
+		})
+	}
+	return result, events, nil
+}
+
+func (s *Server) transactionLogs(r *http.Request) (interface{}, []*Event, error) {
+	query := r.URL.Query()
+	result, err := s.ledger.transactionLogs(did.Identifier(query.Get("id")), query.Get("type"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return paginate(result, r), nil, nil
+}
+
+func (s *Server) transactionUTXO(r *http.Request) (interface{}, []*Event, error) {
+	result, err := s.ledger.transactionOutputs(did.Identifier(r.URL.Query().Get("id")), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return paginate(result, r), nil, nil
+}
+
+func (s *Server) transactionSTXO(r *http.Request) (interface{}, []*Event, error) {
+	result, err := s.ledger.transactionOutputs(did.Identifier(r.URL.Query().Get("id")), true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return paginate(result, r), nil, nil
+}
@@ -0,0 +1,726 @@
+/*
+Copyright ArxanFintech Technology Ltd. 2018 All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wallettest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/arxanchain/sdk-go-common/rest"
+	restapi "github.com/arxanchain/sdk-go-common/rest/api"
+	"github.com/arxanchain/sdk-go-common/structs/did"
+	"github.com/arxanchain/sdk-go-common/structs/pki"
+	"github.com/arxanchain/sdk-go-common/structs/wallet"
+	"github.com/arxanchain/wallet-sdk-go/api"
+)
+
+// callbackRecorder records the events posted to its url.
+type callbackRecorder struct {
+	*httptest.Server
+	mu     sync.Mutex
+	events []*Event
+}
+
+func newCallbackRecorder() *callbackRecorder {
+	c := &callbackRecorder{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+			c.mu.Lock()
+			c.events = append(c.events, &event)
+			c.mu.Unlock()
+		}
+	}))
+	return c
+}
+
+func (c *callbackRecorder) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.events)
+}
+
+func newClient(t *testing.T, s *Server, enterprise *wallet.WalletResponse, opts ...api.Option) *api.WalletClient {
+	config := s.Config()
+	if enterprise != nil {
+		config.EnterpriseSignParam = &restapi.EnterpriseSignParam{
+			Creator:    string(enterprise.Id),
+			Nonce:      "nonce",
+			PrivateKey: enterprise.KeyPair.PrivateKey,
+		}
+	}
+	client, err := api.NewWalletClient(config, opts...)
+	if err != nil {
+		t.Fatalf("New wallet client fail: %v", err)
+	}
+	return client
+}
+
+func signParams(w *wallet.WalletResponse) *pki.SignatureParam {
+	return &pki.SignatureParam{
+		Creator:    w.Id,
+		Nonce:      "nonce",
+		PrivateKey: w.KeyPair.PrivateKey,
+	}
+}
+
+func register(t *testing.T, client *api.WalletClient, header http.Header, access string) *wallet.WalletResponse {
+	resp, err := client.Register(header, &wallet.RegisterWalletBody{
+		Access: access,
+		Secret: "secret",
+	})
+	if err != nil {
+		t.Fatalf("register %s fail: %v", access, err)
+	}
+	if resp.Id == "" || resp.KeyPair == nil || resp.KeyPair.PrivateKey == "" {
+		t.Fatalf("register %s returned invalid wallet: %+v", access, resp)
+	}
+	return resp
+}
+
+func createPOE(t *testing.T, client *api.WalletClient, header http.Header, owner *wallet.WalletResponse, name string) did.Identifier {
+	resp, err := client.CreatePOE(header, &wallet.POEBody{
+		Name:     name,
+		Owner:    owner.Id,
+		Metadata: []byte("metadata of " + name),
+	}, signParams(owner))
+	if err != nil {
+		t.Fatalf("create poe %s fail: %v", name, err)
+	}
+	return resp.Id
+}
+
+func errCode(t *testing.T, err error) int {
+	if err == nil {
+		t.Fatalf("error should not be nil")
+	}
+	coded, ok := err.(rest.HTTPCodedError)
+	if !ok {
+		t.Fatalf("error %v should be HTTPCodedError", err)
+	}
+	return coded.Code()
+}
+
+func balanceOf(t *testing.T, client *api.WalletClient, id did.Identifier, tokenID string) int64 {
+	balance, err := client.GetWalletBalance(nil, id)
+	if err != nil {
+		t.Fatalf("get wallet balance of %s fail: %v", id, err)
+	}
+	if b, ok := balance.ColoredTokens[tokenID]; ok {
+		return b.Amount
+	}
+	if b, ok := balance.DigitalAssets[tokenID]; ok {
+		return b.Amount
+	}
+	return 0
+}
+
+func TestServerTokenLifecycle(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	callback := newCallbackRecorder()
+	defer callback.Close()
+
+	enterprise, err := s.CreateWallet()
+	if err != nil {
+		t.Fatalf("create enterprise wallet fail: %v", err)
+	}
+	if _, err = s.Fund(enterprise.Id, "fee-token", 10); err != nil {
+		t.Fatalf("fund enterprise wallet fail: %v", err)
+	}
+	s.SetFee(enterprise.Id, "fee-token", 1)
+
+	client := newClient(t, s, enterprise)
+	header := http.Header{}
+	header.Set(CallbackURLHeader, callback.URL)
+
+	issuer := register(t, client, header, "issuer")
+	owner := register(t, client, header, "owner")
+	poeID := createPOE(t, client, header, issuer, "gold")
+
+	// Issue colored tokens from the POE to the owner
+	issued, err := client.IssueCToken(header, &wallet.IssueBody{
+		Issuer:  string(issuer.Id),
+		Owner:   string(owner.Id),
+		AssetId: string(poeID),
+		Amount:  1000,
+	}, signParams(issuer))
+	if err != nil {
+		t.Fatalf("issue ctoken fail: %v", err)
+	}
+	if issued.TokenId == "" {
+		t.Fatalf("issued token id should not be empty")
+	}
+	if len(issued.TransactionIds) != 2 {
+		t.Fatalf("issue should commit token and fee transactions, got %v", issued.TransactionIds)
+	}
+	if b := balanceOf(t, client, owner.Id, issued.TokenId); b != 1000 {
+		t.Fatalf("owner balance should be 1000 not %d", b)
+	}
+
+	// Transfer part of them back to the issuer
+	_, err = client.TransferCToken(header, &wallet.TransferCTokenBody{
+		From:   string(owner.Id),
+		To:     string(issuer.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: issued.TokenId, Amount: 300}},
+	}, signParams(owner))
+	if err != nil {
+		t.Fatalf("transfer ctoken fail: %v", err)
+	}
+	if b := balanceOf(t, client, owner.Id, issued.TokenId); b != 700 {
+		t.Fatalf("owner balance should be 700 not %d", b)
+	}
+	if b := balanceOf(t, client, issuer.Id, issued.TokenId); b != 300 {
+		t.Fatalf("issuer balance should be 300 not %d", b)
+	}
+	if b := balanceOf(t, client, enterprise.Id, "fee-token"); b != 8 {
+		t.Fatalf("enterprise should have paid 2 fees, balance 8 not %d", b)
+	}
+
+	// The spent issued output moved to STXO, the change stayed in UTXO
+	stxo, err := client.QueryTransactionSTXO(nil, owner.Id, 0, 1)
+	if err != nil {
+		t.Fatalf("query stxo fail: %v", err)
+	}
+	if len(stxo) != 1 || stxo[0].Value != 1000 {
+		t.Fatalf("owner stxo should be the issued output, got %+v", stxo)
+	}
+	utxo, err := client.QueryTransactionUTXO(nil, owner.Id, 0, 1)
+	if err != nil {
+		t.Fatalf("query utxo fail: %v", err)
+	}
+	if len(utxo) != 1 || utxo[0].Value != 700 {
+		t.Fatalf("owner utxo should be the change output, got %+v", utxo)
+	}
+	logs, err := client.QueryTransactionLogs(nil, owner.Id, "in", 1, 2)
+	if err != nil {
+		t.Fatalf("query transaction logs fail: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("second page of one log should have one entry, got %d", len(logs))
+	}
+
+	// Spending more than the balance is rejected
+	_, err = client.TransferCToken(header, &wallet.TransferCTokenBody{
+		From:   string(owner.Id),
+		To:     string(issuer.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: issued.TokenId, Amount: 701}},
+	}, signParams(owner))
+	if code := errCode(t, err); code != ErrCodeBalanceNotSufficient {
+		t.Fatalf("error code should be %d not %d", ErrCodeBalanceNotSufficient, code)
+	}
+
+	// 2 registrations, 1 POE, 2 issue and 2 transfer transactions
+	if n := len(s.Events()); n != 7 {
+		t.Fatalf("server should have emitted 7 events not %d", n)
+	}
+	if n := callback.count(); n != 7 {
+		t.Fatalf("callback should have received 7 events not %d", n)
+	}
+}
+
+func TestServerBatchTransfer(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newClient(t, s, nil)
+
+	payer := register(t, client, nil, "payer")
+	alice := register(t, client, nil, "alice")
+	bob := register(t, client, nil, "bob")
+	carol := register(t, client, nil, "carol")
+	if _, err := s.Fund(payer.Id, "salary", 100); err != nil {
+		t.Fatalf("fund payer wallet fail: %v", err)
+	}
+
+	salary := func(amount int64) []*wallet.TokenAmount {
+		return []*wallet.TokenAmount{{TokenId: "salary", Amount: amount}}
+	}
+	resp, err := client.BatchTransferCToken(nil, &api.BatchTransferCTokenBody{
+		From: string(payer.Id),
+		Entries: []*api.BatchTransferEntry{
+			{To: string(alice.Id), Tokens: salary(10)},
+			{To: string(bob.Id), Tokens: salary(20)},
+			{To: string(alice.Id), Tokens: salary(5)},
+			{To: string(carol.Id), Tokens: salary(500)},
+		},
+	}, signParams(payer))
+	if err != nil {
+		t.Fatalf("batch transfer fail: %v", err)
+	}
+	if len(resp.Results) != 3 {
+		t.Fatalf("batch transfer should report 3 recipients not %d", len(resp.Results))
+	}
+	failed := resp.Failed()
+	if len(failed) != 1 || failed[0].To != string(carol.Id) {
+		t.Fatalf("only the transfer to carol should fail, got %d failures", len(failed))
+	}
+	if code := errCode(t, failed[0].Err); code != ErrCodeBalanceNotSufficient {
+		t.Fatalf("error code should be %d not %d", ErrCodeBalanceNotSufficient, code)
+	}
+
+	if b := balanceOf(t, client, alice.Id, "salary"); b != 15 {
+		t.Fatalf("alice balance should be 15 not %d", b)
+	}
+	if b := balanceOf(t, client, bob.Id, "salary"); b != 20 {
+		t.Fatalf("bob balance should be 20 not %d", b)
+	}
+	if b := balanceOf(t, client, payer.Id, "salary"); b != 65 {
+		t.Fatalf("payer balance should be 65 not %d", b)
+	}
+}
+
+func TestServerIntentCheck(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	enterprise, err := s.CreateWallet()
+	if err != nil {
+		t.Fatalf("create enterprise wallet fail: %v", err)
+	}
+	if _, err = s.Fund(enterprise.Id, "fee-token", 10); err != nil {
+		t.Fatalf("fund enterprise wallet fail: %v", err)
+	}
+	s.SetFee(enterprise.Id, "fee-token", 1)
+
+	client := newClient(t, s, enterprise, api.WithIntentCheck(&api.IntentCheck{
+		MaxFee:   map[string]int64{"fee-token": 1},
+		FeeAddrs: []string{s.FeeAddr()},
+	}))
+
+	issuer := register(t, client, nil, "issuer")
+	owner := register(t, client, nil, "owner")
+	poeID := createPOE(t, client, nil, issuer, "gold")
+	issued, err := client.IssueCToken(nil, &wallet.IssueBody{
+		Issuer:  string(issuer.Id),
+		Owner:   string(owner.Id),
+		AssetId: string(poeID),
+		Amount:  1000,
+	}, signParams(issuer))
+	if err != nil {
+		t.Fatalf("issue ctoken fail: %v", err)
+	}
+	_, err = client.TransferCToken(nil, &wallet.TransferCTokenBody{
+		From:   string(owner.Id),
+		To:     string(issuer.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: issued.TokenId, Amount: 300}},
+	}, signParams(owner))
+	if err != nil {
+		t.Fatalf("transfer ctoken fail: %v", err)
+	}
+	if b := balanceOf(t, client, issuer.Id, issued.TokenId); b != 300 {
+		t.Fatalf("issuer balance should be 300 not %d", b)
+	}
+
+	// A fee raised above the limit is rejected before signing
+	s.SetFee(enterprise.Id, "fee-token", 2)
+	_, err = client.TransferCToken(nil, &wallet.TransferCTokenBody{
+		From:   string(owner.Id),
+		To:     string(issuer.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: issued.TokenId, Amount: 300}},
+	}, signParams(owner))
+	ierr, ok := err.(*api.IntentError)
+	if !ok {
+		t.Fatalf("error should be an *api.IntentError not %v", err)
+	}
+	if len(ierr.Mismatches) != 1 || ierr.Mismatches[0].Kind != api.MismatchFee {
+		t.Fatalf("fee mismatch should be reported: %v", err)
+	}
+}
+
+func TestServerAssetLifecycle(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newClient(t, s, nil)
+
+	issuer := register(t, client, nil, "issuer")
+	owner := register(t, client, nil, "owner")
+	buyer := register(t, client, nil, "buyer")
+	poeID := createPOE(t, client, nil, issuer, "painting")
+
+	_, err := client.IssueAsset(nil, &wallet.IssueAssetBody{
+		Issuer:  string(issuer.Id),
+		Owner:   string(owner.Id),
+		AssetId: string(poeID),
+	}, signParams(issuer))
+	if err != nil {
+		t.Fatalf("issue asset fail: %v", err)
+	}
+	_, err = client.IssueAsset(nil, &wallet.IssueAssetBody{
+		Issuer:  string(issuer.Id),
+		Owner:   string(owner.Id),
+		AssetId: string(poeID),
+	}, signParams(issuer))
+	if code := errCode(t, err); code != ErrCodeAssetIssued {
+		t.Fatalf("error code should be %d not %d", ErrCodeAssetIssued, code)
+	}
+
+	// Transactions signed with another key than the founder's are rejected
+	forged := signParams(owner)
+	forged.PrivateKey = buyer.KeyPair.PrivateKey
+	_, err = client.TransferAsset(nil, &wallet.TransferAssetBody{
+		From:   string(owner.Id),
+		To:     string(buyer.Id),
+		Assets: []string{string(poeID)},
+	}, forged)
+	if code := errCode(t, err); code != ErrCodeSignatureInvalid {
+		t.Fatalf("error code should be %d not %d", ErrCodeSignatureInvalid, code)
+	}
+
+	_, err = client.TransferAsset(nil, &wallet.TransferAssetBody{
+		From:   string(owner.Id),
+		To:     string(buyer.Id),
+		Assets: []string{string(poeID)},
+	}, signParams(owner))
+	if err != nil {
+		t.Fatalf("transfer asset fail: %v", err)
+	}
+	if b := balanceOf(t, client, buyer.Id, string(poeID)); b != 1 {
+		t.Fatalf("buyer should own the asset")
+	}
+	if b := balanceOf(t, client, owner.Id, string(poeID)); b != 0 {
+		t.Fatalf("owner should not own the asset anymore")
+	}
+}
+
+func TestServerSwap(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newClient(t, s, nil)
+
+	issuer := register(t, client, nil, "issuer")
+	seller := register(t, client, nil, "seller")
+	buyer := register(t, client, nil, "buyer")
+	other := register(t, client, nil, "other")
+	poeID := createPOE(t, client, nil, issuer, "painting")
+	_, err := client.IssueAsset(nil, &wallet.IssueAssetBody{
+		Issuer:  string(issuer.Id),
+		Owner:   string(seller.Id),
+		AssetId: string(poeID),
+	}, signParams(issuer))
+	if err != nil {
+		t.Fatalf("issue asset fail: %v", err)
+	}
+	if _, err = s.Fund(buyer.Id, "coin", 100); err != nil {
+		t.Fatalf("fund buyer wallet fail: %v", err)
+	}
+
+	prepareSwap := func() *api.Swap {
+		body := &api.SwapBody{
+			TokenOwner: string(buyer.Id),
+			Tokens:     []*wallet.TokenAmount{{TokenId: "coin", Amount: 60}},
+			AssetOwner: string(seller.Id),
+			Assets:     []string{string(poeID)},
+		}
+		swap, err := client.PrepareSwap(nil, body)
+		if err != nil {
+			t.Fatalf("prepare swap fail: %v", err)
+		}
+		if err = client.SignSwap(nil, swap, body, signParams(buyer)); err != nil {
+			t.Fatalf("buyer sign fail: %v", err)
+		}
+		if err = client.SignSwap(nil, swap, body, signParams(seller)); err != nil {
+			t.Fatalf("seller sign fail: %v", err)
+		}
+		return swap
+	}
+
+	// The buyer spends the coins before the swap is submitted, the
+	// whole swap is rejected
+	swap := prepareSwap()
+	_, err = client.TransferCToken(nil, &wallet.TransferCTokenBody{
+		From:   string(buyer.Id),
+		To:     string(other.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: "coin", Amount: 100}},
+	}, signParams(buyer))
+	if err != nil {
+		t.Fatalf("transfer ctoken fail: %v", err)
+	}
+	_, err = client.SubmitSwap(nil, swap)
+	swapErr, ok := err.(*api.SwapError)
+	if !ok {
+		t.Fatalf("error should be a *api.SwapError not %v", err)
+	}
+	if code := errCode(t, swapErr.Err); code != ErrCodeUTXOSpent {
+		t.Fatalf("error code should be %d not %d", ErrCodeUTXOSpent, code)
+	}
+	if b := balanceOf(t, client, seller.Id, string(poeID)); b != 1 {
+		t.Fatalf("seller should still own the asset")
+	}
+
+	if _, err = s.Fund(buyer.Id, "coin", 100); err != nil {
+		t.Fatalf("fund buyer wallet fail: %v", err)
+	}
+	if _, err = client.SubmitSwap(nil, prepareSwap()); err != nil {
+		t.Fatalf("submit swap fail: %v", err)
+	}
+	if b := balanceOf(t, client, buyer.Id, string(poeID)); b != 1 {
+		t.Fatalf("buyer should own the asset")
+	}
+	if b := balanceOf(t, client, seller.Id, "coin"); b != 60 {
+		t.Fatalf("seller balance should be 60 not %d", b)
+	}
+	if b := balanceOf(t, client, buyer.Id, "coin"); b != 40 {
+		t.Fatalf("buyer balance should be 40 not %d", b)
+	}
+}
+
+func TestServerPOE(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newClient(t, s, nil)
+
+	owner := register(t, client, nil, "owner")
+	other := register(t, client, nil, "other")
+	poeID := createPOE(t, client, nil, owner, "contract")
+	otherID := createPOE(t, client, nil, other, "copy")
+
+	// A request signed with the wrong key is rejected
+	forged := signParams(owner)
+	forged.PrivateKey = other.KeyPair.PrivateKey
+	_, err := client.CreatePOE(nil, &wallet.POEBody{Name: "forged", Owner: owner.Id}, forged)
+	if code := errCode(t, err); code != ErrCodeSignatureInvalid {
+		t.Fatalf("error code should be %d not %d", ErrCodeSignatureInvalid, code)
+	}
+
+	_, err = client.UpdatePOE(nil, &wallet.POEBody{Id: poeID, Name: "contract v2", Owner: owner.Id}, signParams(owner))
+	if err != nil {
+		t.Fatalf("update poe fail: %v", err)
+	}
+	_, err = client.UpdatePOE(nil, &wallet.POEBody{Id: poeID, Name: "stolen", Owner: other.Id}, signParams(other))
+	if code := errCode(t, err); code != ErrCodeSignatureInvalid {
+		t.Fatalf("error code should be %d not %d", ErrCodeSignatureInvalid, code)
+	}
+	poe, err := client.QueryPOE(nil, poeID)
+	if err != nil {
+		t.Fatalf("query poe fail: %v", err)
+	}
+	if poe.Name != "contract v2" || poe.Owner != owner.Id {
+		t.Fatalf("poe should be updated by its owner only, got %+v", poe)
+	}
+
+	dir, err := ioutil.TempDir("", "wallettest")
+	if err != nil {
+		t.Fatalf("create temp dir fail: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "contract.pdf")
+	if err = ioutil.WriteFile(file, []byte("signed contract"), 0600); err != nil {
+		t.Fatalf("write poe file fail: %v", err)
+	}
+
+	if _, err = client.UploadPOEFile(nil, string(poeID), file, true); err != nil {
+		t.Fatalf("upload poe file fail: %v", err)
+	}
+	_, err = client.UploadPOEFile(nil, string(poeID), file, false)
+	if code := errCode(t, err); code != ErrCodePOEReadOnly {
+		t.Fatalf("error code should be %d not %d", ErrCodePOEReadOnly, code)
+	}
+	_, err = client.UploadPOEFile(nil, string(otherID), file, false)
+	if code := errCode(t, err); code != ErrCodeFileExists {
+		t.Fatalf("error code should be %d not %d", ErrCodeFileExists, code)
+	}
+}
+
+func TestServerIndex(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newClient(t, s, nil)
+
+	_, err := client.IndexSet(nil, &wallet.IndexSetPayload{
+		Id: "did:axn:1",
+		Indexs: &wallet.IndexTags{
+			CombinedIndex:   []string{"a", "b"},
+			IndividualIndex: []string{"x"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("index set fail: %v", err)
+	}
+	_, err = client.IndexSet(nil, &wallet.IndexSetPayload{
+		Id: "did:axn:2",
+		Indexs: &wallet.IndexTags{
+			IndividualIndex: []string{"x", "y"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("index set fail: %v", err)
+	}
+
+	ids, err := client.IndexGet(nil, &wallet.IndexGetPayload{
+		Indexs: &wallet.IndexTags{CombinedIndex: []string{"b", "a"}},
+	})
+	if err != nil {
+		t.Fatalf("index get fail: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "did:axn:1" {
+		t.Fatalf("combined index should match did:axn:1 only, got %v", ids)
+	}
+	ids, err = client.IndexGet(nil, &wallet.IndexGetPayload{
+		Indexs: &wallet.IndexTags{IndividualIndex: []string{"x"}},
+	})
+	if err != nil {
+		t.Fatalf("index get fail: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("individual index should match both ids, got %v", ids)
+	}
+}
+
+func TestServerRegisterWithPublicKey(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newClient(t, s, nil)
+
+	key, err := api.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("generate key pair fail: %v", err)
+	}
+	local, err := client.RegisterWithPublicKey(nil, &wallet.RegisterWalletBody{
+		Access: "local",
+		Secret: "secret",
+	}, key.PublicKey)
+	if err != nil {
+		t.Fatalf("register with public key fail: %v", err)
+	}
+	if local.KeyPair == nil || local.KeyPair.PrivateKey != "" || local.KeyPair.PublicKey != key.EncodedPublicKey() {
+		t.Fatalf("registered wallet should only hold the public key: %+v", local.KeyPair)
+	}
+	owner := register(t, client, nil, "owner")
+
+	// Requests and TXs signed with the local private key are accepted
+	localSignParams := key.SignParam(local.Id, "nonce")
+	if _, err = client.CreatePOE(nil, &wallet.POEBody{Name: "local", Owner: local.Id}, localSignParams); err != nil {
+		t.Fatalf("create poe signed locally fail: %v", err)
+	}
+	if _, err = s.Fund(local.Id, "token", 100); err != nil {
+		t.Fatalf("fund wallet fail: %v", err)
+	}
+	_, err = client.TransferCToken(nil, &wallet.TransferCTokenBody{
+		From:   string(local.Id),
+		To:     string(owner.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: "token", Amount: 30}},
+	}, localSignParams)
+	if err != nil {
+		t.Fatalf("transfer signed locally fail: %v", err)
+	}
+	if b := balanceOf(t, client, owner.Id, "token"); b != 30 {
+		t.Fatalf("owner balance should be 30 not %d", b)
+	}
+
+	// Signatures of another key are rejected
+	other, err := api.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("generate key pair fail: %v", err)
+	}
+	if _, err = client.CreatePOE(nil, &wallet.POEBody{Name: "other", Owner: local.Id}, other.SignParam(local.Id, "nonce")); err == nil {
+		t.Fatalf("create poe signed with another key should fail")
+	}
+	_, err = client.TransferCToken(nil, &wallet.TransferCTokenBody{
+		From:   string(local.Id),
+		To:     string(owner.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: "token", Amount: 30}},
+	}, other.SignParam(local.Id, "nonce"))
+	if err == nil {
+		t.Fatalf("transfer signed with another key should fail")
+	}
+	if b := balanceOf(t, client, owner.Id, "token"); b != 30 {
+		t.Fatalf("owner balance should still be 30 not %d", b)
+	}
+
+	// Sub wallets and invalid public keys
+	sub, err := client.RegisterSubWalletWithPublicKey(nil, &wallet.RegisterSubWalletBody{Id: local.Id}, other.PublicKey)
+	if err != nil {
+		t.Fatalf("register sub wallet with public key fail: %v", err)
+	}
+	if sub.KeyPair == nil || sub.KeyPair.PrivateKey != "" {
+		t.Fatalf("registered sub wallet should only hold the public key: %+v", sub.KeyPair)
+	}
+	if _, err = client.RegisterWithPublicKey(nil, &wallet.RegisterWalletBody{Access: "short", Secret: "secret"}, key.PublicKey[:8]); err == nil {
+		t.Fatalf("register with an invalid public key should fail")
+	}
+}
+
+func TestServerRotateKey(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	rotations := &api.KeyRotations{}
+	client := newClient(t, s, nil, api.WithKeyRotations(rotations))
+
+	issuer := register(t, client, nil, "issuer")
+	old := register(t, client, nil, "old")
+	poeID := createPOE(t, client, nil, issuer, "painting")
+	_, err := client.IssueAsset(nil, &wallet.IssueAssetBody{
+		Issuer:  string(issuer.Id),
+		Owner:   string(old.Id),
+		AssetId: string(poeID),
+	}, signParams(issuer))
+	if err != nil {
+		t.Fatalf("issue asset fail: %v", err)
+	}
+	for _, amount := range []int64{30, 20, 50} {
+		if _, err = s.Fund(old.Id, "token-a", amount); err != nil {
+			t.Fatalf("fund wallet fail: %v", err)
+		}
+	}
+	if _, err = s.Fund(old.Id, "token-b", 7); err != nil {
+		t.Fatalf("fund wallet fail: %v", err)
+	}
+
+	resp, err := client.RotateKey(nil, &api.RotateKeyBody{
+		Id:       old.Id,
+		Register: &wallet.RegisterWalletBody{Access: "rotated", Secret: "secret"},
+		PageSize: 2,
+	}, signParams(old))
+	if err != nil {
+		t.Fatalf("rotate key fail: %v", err)
+	}
+	rotated := resp.Wallet.Id
+	if rotated == old.Id || resp.Wallet.KeyPair.PrivateKey != "" {
+		t.Fatalf("new wallet should be registered with the new public key: %+v", resp.Wallet)
+	}
+	if resp.KeyPair.EncodedPublicKey() != resp.Wallet.KeyPair.PublicKey {
+		t.Fatalf("new wallet public key should be the generated one")
+	}
+
+	for token, amount := range map[string]int64{"token-a": 100, "token-b": 7, string(poeID): 1} {
+		if b := balanceOf(t, client, rotated, token); b != amount {
+			t.Fatalf("new wallet balance of %s should be %d not %d", token, amount, b)
+		}
+		if b := balanceOf(t, client, old.Id, token); b != 0 {
+			t.Fatalf("old wallet balance of %s should be 0 not %d", token, b)
+		}
+	}
+	if id := rotations.Resolve(old.Id); id != rotated {
+		t.Fatalf("old wallet should resolve to %s not %s", rotated, id)
+	}
+
+	// The new wallet is used with the new key
+	_, err = client.TransferCToken(nil, &wallet.TransferCTokenBody{
+		From:   string(rotated),
+		To:     string(issuer.Id),
+		Tokens: []*wallet.TokenAmount{{TokenId: "token-a", Amount: 10}},
+	}, resp.KeyPair.SignParam(rotated, "nonce"))
+	if err != nil {
+		t.Fatalf("transfer from the new wallet fail: %v", err)
+	}
+}